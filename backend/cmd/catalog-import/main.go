@@ -0,0 +1,140 @@
+// Command catalog-import creates videos for video files already stored in a
+// pCloud account folder.
+//
+//	catalog-import -account <credential-id> -path /Movies -recursive -dry-run
+//
+// Imported videos are unpublished drafts unless -publish is given.
+package main
+
+import (
+	"bobastream/config"
+	"bobastream/internal/pcloud"
+	"bobastream/internal/repositories"
+	"bobastream/internal/services"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	accountFlag := flag.String("account", "", "pCloud credential ID to scan (required)")
+	folderID := flag.Int64("folder", 0, "pCloud folder ID to scan (0 is the root)")
+	folderPath := flag.String("path", "", "pCloud folder path to scan, e.g. /Movies (overrides -folder)")
+	recursive := flag.Bool("recursive", false, "also scan subfolders")
+	dryRun := flag.Bool("dry-run", false, "only show what would be imported")
+	createCategories := flag.Bool("create-categories", false, "create categories for unknown folder names")
+	publish := flag.Bool("publish", false, "publish imported videos (by default they are drafts to review first)")
+	limit := flag.Int("limit", 0, "import at most this many files (0 means all)")
+	jsonOutput := flag.Bool("json", false, "print the full result as JSON")
+	flag.Parse()
+
+	credentialID, err := uuid.Parse(*accountFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "a valid -account credential ID is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	if err := config.LoadConfig(); err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// Initialize database
+	if err := config.InitDatabase(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer config.CloseDatabase()
+
+	videoRepo := repositories.NewVideoRepository(config.DB)
+	pcloudRepo := repositories.NewPCloudCredentialRepository(config.DB)
+
+	pcloudClient := pcloud.NewClient(pcloud.Config{
+		USBaseURL:   config.GlobalConfig.PCloud.BaseURL,
+		EUBaseURL:   config.GlobalConfig.PCloud.EUBaseURL,
+		Timeout:     config.GlobalConfig.PCloud.Timeout(),
+		MaxAttempts: config.GlobalConfig.PCloud.MaxAttempts,
+	})
+
+	videoService := services.NewVideoService(
+		videoRepo,
+		repositories.NewWrapperLinkRepository(config.DB),
+		repositories.NewVideoViewRepository(config.DB),
+		repositories.NewVideoLikeRepository(config.DB),
+		repositories.NewTagRepository(config.DB),
+	)
+	categoryRepo := repositories.NewCategoryRepository(config.DB)
+	accountSelector := services.NewAccountSelector(
+		pcloudRepo,
+		videoRepo,
+		categoryRepo,
+		repositories.NewSettingRepository(config.DB),
+		config.GlobalConfig.PCloud.SelectionStrategy,
+		time.Duration(config.GlobalConfig.PCloud.SelectionTrafficHours)*time.Hour,
+	)
+	pcloudService := services.NewPCloudService(pcloudRepo, videoRepo, pcloudClient, accountSelector)
+	categoryService := services.NewCategoryService(categoryRepo)
+	catalogImportService := services.NewCatalogImportService(pcloudRepo, videoRepo, pcloudService, videoService, categoryService)
+
+	// Ctrl+C stops after the current file
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *dryRun {
+		log.Println("🔍 Dry run: nothing will be imported")
+	}
+	if *publish {
+		log.Println("📢 Imported videos will be published")
+	} else {
+		log.Println("📝 Imported videos will be drafts; pass -publish to publish them")
+	}
+
+	result, err := catalogImportService.Import(ctx, services.CatalogImportOptions{
+		CredentialID:     credentialID,
+		FolderID:         *folderID,
+		FolderPath:       *folderPath,
+		Recursive:        *recursive,
+		DryRun:           *dryRun,
+		CreateCategories: *createCategories,
+		Publish:          *publish,
+		Limit:            *limit,
+	})
+	if result == nil {
+		log.Fatal("❌ Catalog import failed: ", err)
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(result)
+	} else {
+		for _, item := range result.Items {
+			line := fmt.Sprintf("%-8s %s -> %q", item.Action, item.Path, item.Title)
+			if item.Category != "" {
+				line += fmt.Sprintf(" [%s]", item.Category)
+			}
+			if item.DurationSeconds > 0 {
+				line += fmt.Sprintf(" %ds", item.DurationSeconds)
+			}
+			if item.Reason != "" {
+				line += " (" + item.Reason + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+
+	log.Printf("✅ Scanned %d video files: %d imported, %d skipped, %d failed, %d remaining\n",
+		result.Scanned, result.Imported, result.Skipped, result.Failed, result.Remaining)
+
+	if err != nil {
+		log.Fatal("❌ Catalog import interrupted: ", err)
+	}
+}
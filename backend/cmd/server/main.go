@@ -6,6 +6,7 @@ import (
 	"bobastream/internal/cron"
 	"bobastream/internal/handlers"
 	"bobastream/internal/middleware"
+	"bobastream/internal/pcloud"
 	"bobastream/internal/repositories"
 	"bobastream/internal/services"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -56,31 +58,151 @@ func main() {
 	adImpressionRepo := repositories.NewAdImpressionRepository(config.DB)
 	analyticsRepo := repositories.NewAnalyticsRepository(config.DB)
 	pcloudRepo := repositories.NewPCloudCredentialRepository(config.DB)
+	importJobRepo := repositories.NewImportJobRepository(config.DB)
+	pcloudHealthRepo := repositories.NewPCloudHealthCheckRepository(config.DB)
+	settingRepo := repositories.NewSettingRepository(config.DB)
+	jobRepo := repositories.NewJobRepository(config.DB)
+	searchQueryRepo := repositories.NewSearchQueryRepository(config.DB)
+	tagRepo := repositories.NewTagRepository(config.DB)
+	playlistRepo := repositories.NewPlaylistRepository(config.DB)
+	videoRevisionRepo := repositories.NewVideoRevisionRepository(config.DB)
+	relatedVideoRepo := repositories.NewRelatedVideoRepository(config.DB)
+	recommendationRepo := repositories.NewRecommendationRepository(config.DB)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo)
-	videoService := services.NewVideoService(videoRepo, wrapperRepo, videoViewRepo, videoLikeRepo)
+	videoService := services.NewVideoService(videoRepo, wrapperRepo, videoViewRepo, videoLikeRepo, tagRepo)
+	videoRevisionService := services.NewVideoRevisionService(videoRepo, videoRevisionRepo, tagRepo, videoService)
+	tagService := services.NewTagService(tagRepo)
+	playlistService := services.NewPlaylistService(playlistRepo, videoRepo)
 	categoryService := services.NewCategoryService(categoryRepo)
 	adService := services.NewAdService(adRepo, adImpressionRepo)
 	analyticsService := services.NewAnalyticsService(analyticsRepo, videoViewRepo, adImpressionRepo)
-	pcloudService := services.NewPCloudService(pcloudRepo, videoRepo)
+	// Initialize pCloud API client
+	pcloudClient := pcloud.NewClient(pcloud.Config{
+		USBaseURL:   config.GlobalConfig.PCloud.BaseURL,
+		EUBaseURL:   config.GlobalConfig.PCloud.EUBaseURL,
+		Timeout:     config.GlobalConfig.PCloud.Timeout(),
+		MaxAttempts: config.GlobalConfig.PCloud.MaxAttempts,
+	})
+
+	accountSelector := services.NewAccountSelector(
+		pcloudRepo,
+		videoRepo,
+		categoryRepo,
+		settingRepo,
+		config.GlobalConfig.PCloud.SelectionStrategy,
+		time.Duration(config.GlobalConfig.PCloud.SelectionTrafficHours)*time.Hour,
+	)
+	pcloudService := services.NewPCloudService(pcloudRepo, videoRepo, pcloudClient, accountSelector)
+	uploadValidator := services.NewUploadValidator(services.UploadPolicy{
+		AllowedMIMETypes: config.GlobalConfig.Upload.AllowedMIMETypes(),
+		MaxSizeBytes:     config.GlobalConfig.Upload.MaxSizeBytes(),
+		MaxDuration:      config.GlobalConfig.Upload.MaxDuration(),
+	})
+	linkRefreshService := services.NewLinkRefreshService(videoRepo, pcloudService, services.LinkRefreshOptions{
+		Window:      time.Duration(config.GlobalConfig.Links.WindowMinutes) * time.Minute,
+		Jitter:      time.Duration(config.GlobalConfig.Links.JitterMinutes) * time.Minute,
+		Workers:     config.GlobalConfig.Links.Workers,
+		BatchSize:   config.GlobalConfig.Links.BatchSize,
+		BackoffBase: time.Duration(config.GlobalConfig.Links.BackoffBaseMinutes) * time.Minute,
+		BackoffMax:  time.Duration(config.GlobalConfig.Links.BackoffMaxMinutes) * time.Minute,
+		MaxFailures: config.GlobalConfig.Links.MaxFailures,
+	})
+	pcloudHealthService := services.NewPCloudHealthService(pcloudRepo, pcloudHealthRepo, pcloudService, services.PCloudHealthOptions{
+		SlowThreshold:    time.Duration(config.GlobalConfig.PCloud.HealthSlowMS) * time.Millisecond,
+		QuarantineAfter:  config.GlobalConfig.PCloud.HealthQuarantineAfter,
+		RecoverAfter:     config.GlobalConfig.PCloud.HealthRecoverAfter,
+		HistoryRetention: time.Duration(config.GlobalConfig.PCloud.HealthHistoryDays) * 24 * time.Hour,
+	})
+	faststartService := services.NewFaststartService(videoRepo, pcloudService, services.FaststartOptions{
+		TempDir:   config.GlobalConfig.Faststart.TempDir,
+		BatchSize: config.GlobalConfig.Faststart.BatchSize,
+		Timeout:   config.GlobalConfig.Faststart.Timeout(),
+	})
+	jobQueue := services.NewJobQueue(jobRepo, services.JobQueueOptions{
+		Workers:      config.GlobalConfig.Jobs.Workers,
+		PollInterval: time.Duration(config.GlobalConfig.Jobs.PollSeconds) * time.Second,
+		MaxAttempts:  config.GlobalConfig.Jobs.MaxAttempts,
+		BackoffBase:  time.Duration(config.GlobalConfig.Jobs.BackoffBaseSeconds) * time.Second,
+		BackoffMax:   time.Duration(config.GlobalConfig.Jobs.BackoffMaxMinutes) * time.Minute,
+		Timeout:      time.Duration(config.GlobalConfig.Jobs.TimeoutMinutes) * time.Minute,
+		StaleAfter:   time.Duration(config.GlobalConfig.Jobs.StaleSeconds) * time.Second,
+		Retention:    time.Duration(config.GlobalConfig.Jobs.RetentionDays) * 24 * time.Hour,
+	})
+	uploadService := services.NewUploadService(jobQueue, videoService, pcloudService, faststartService, config.GlobalConfig.Jobs.UploadDir)
+	importService := services.NewImportService(
+		importJobRepo,
+		jobQueue,
+		videoService,
+		pcloudService,
+		uploadValidator,
+		config.GlobalConfig.Import.MaxSizeBytes(),
+		config.GlobalConfig.Import.Timeout(),
+	)
+	searchService := services.NewSearchService(videoRepo, categoryRepo, tagRepo, searchQueryRepo, settingRepo, services.SearchOptions{
+		Config:           config.GlobalConfig.Search.TSConfig,
+		PopularityWeight: float64(config.GlobalConfig.Search.PopularityWeight) / 100,
+		SuggestCacheTTL:  time.Duration(config.GlobalConfig.Search.SuggestCacheSecs) * time.Second,
+	})
+	catalogImportService := services.NewCatalogImportService(pcloudRepo, videoRepo, pcloudService, videoService, categoryService)
+	relatedVideoService := services.NewRelatedVideoService(relatedVideoRepo, videoRepo, services.RelatedVideoOptions{
+		PerVideo:        config.GlobalConfig.Related.PerVideo,
+		CoViewWindow:    time.Duration(config.GlobalConfig.Related.CoViewDays) * 24 * time.Hour,
+		RecencyHalfLife: config.GlobalConfig.Related.RecencyHalfLife,
+	})
+	recommendationService := services.NewRecommendationService(recommendationRepo, videoRepo, services.RecommendationOptions{
+		HistoryWindow:    time.Duration(config.GlobalConfig.Recommend.HistoryDays) * 24 * time.Hour,
+		HistorySize:      config.GlobalConfig.Recommend.HistorySize,
+		MinHistory:       config.GlobalConfig.Recommend.MinHistory,
+		CompletedPercent: float64(config.GlobalConfig.Recommend.CompletedPercent),
+		Neighbors:        config.GlobalConfig.Recommend.Neighbors,
+		MinViewers:       config.GlobalConfig.Recommend.MinViewers,
+		TrendingPercent:  config.GlobalConfig.Recommend.TrendingPercent,
+		TrendingWindow:   time.Duration(config.GlobalConfig.Recommend.TrendingDays) * 24 * time.Hour,
+	})
+	syndicationService := services.NewSyndicationService(videoRepo, categoryService, tagService, services.SyndicationOptions{
+		PublicURL:       config.GlobalConfig.App.PublicURL,
+		SitemapPageSize: config.GlobalConfig.Feeds.SitemapPageSize,
+		FeedSize:        config.GlobalConfig.Feeds.FeedSize,
+		CacheTTL:        config.GlobalConfig.Feeds.CacheTTL(),
+	})
+
+	// Search vectors must match the configured text search configuration
+	if err := searchService.SyncConfig(); err != nil {
+		log.Println("⚠️  Failed to sync search configuration:", err)
+	}
+
+	// Queued jobs survive restarts; stale running ones are requeued by the workers
+	jobQueue.Start()
+	log.Println("✅ Job queue workers started")
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	videoHandler := handlers.NewVideoHandler(videoService, categoryService)
+	videoHandler := handlers.NewVideoHandler(videoService, categoryService, searchService, playlistService, relatedVideoService)
 	likeHandler := handlers.NewLikeHandler(videoService)
-	streamHandler := handlers.NewStreamHandler(videoService)
+	streamHandler := handlers.NewStreamHandler(videoService, playlistService)
 	adHandler := handlers.NewAdHandler(adService)
-	adminVideoHandler := handlers.NewAdminVideoHandler(videoService, pcloudService, categoryService)
+	adminVideoHandler := handlers.NewAdminVideoHandler(videoService, pcloudService, categoryService, linkRefreshService, faststartService, uploadService, uploadValidator, videoRevisionService)
 	adminAdHandler := handlers.NewAdminAdHandler(adService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, videoService)
-	pcloudHandler := handlers.NewPCloudHandler(pcloudService)
+	pcloudHandler := handlers.NewPCloudHandler(pcloudService, catalogImportService, pcloudHealthService, accountSelector)
+	adminImportHandler := handlers.NewAdminImportHandler(importService)
+	adminJobHandler := handlers.NewAdminJobHandler(jobQueue)
+	tagHandler := handlers.NewTagHandler(tagService, videoService)
+	adminTagHandler := handlers.NewAdminTagHandler(tagService)
+	playlistHandler := handlers.NewPlaylistHandler(playlistService)
+	adminPlaylistHandler := handlers.NewAdminPlaylistHandler(playlistService)
+	adminCategoryHandler := handlers.NewAdminCategoryHandler(categoryService)
+	syndicationHandler := handlers.NewSyndicationHandler(syndicationService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+	adminRecommendationHandler := handlers.NewAdminRecommendationHandler(recommendationService)
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:      "BOBA STREAM API",
 		ServerHeader: "Fiber",
-		BodyLimit:    500 * 1024 * 1024,
+		BodyLimit:    config.GlobalConfig.Upload.BodyLimit(),
 	})
 
 	// Global middleware
@@ -159,11 +281,24 @@ func main() {
 	videos := api.Group("/videos")
 	videos.Get("/", videoHandler.GetFeed)
 	videos.Get("/search", videoHandler.SearchVideos)
+	videos.Get("/search/suggest", videoHandler.SuggestSearch)
 	videos.Get("/categories", videoHandler.GetCategories)
+	videos.Get("/categories/tree", videoHandler.GetCategoryTree)
+	videos.Get("/recommended", middleware.OptionalAuth(), recommendationHandler.GetRecommended)
 	videos.Get("/category/:categoryId", videoHandler.GetVideosByCategory)
 	videos.Get("/:id", videoHandler.GetVideoByID)
 	videos.Get("/:id/related", videoHandler.GetRelatedVideos)
-	videos.Post("/:id/view", videoHandler.TrackView)
+	videos.Post("/:id/view", middleware.OptionalAuth(), videoHandler.TrackView)
+
+	// Tag pages
+	tags := api.Group("/tags")
+	tags.Get("/popular", tagHandler.GetPopularTags)
+	tags.Get("/:slug/videos", tagHandler.GetTagVideos)
+
+	// Playlists and series
+	playlists := api.Group("/playlists")
+	playlists.Get("/", playlistHandler.GetPlaylists)
+	playlists.Get("/:slug", playlistHandler.GetPlaylist)
 
 	// Like routes
 	videos.Post("/:id/like", middleware.AuthRequired(), likeHandler.LikeVideo)
@@ -182,18 +317,73 @@ func main() {
 	ads.Post("/:id/impression", adHandler.TrackImpression)
 
 	// Watch & streaming
-	app.Get("/watch/:token", streamHandler.ShowPlayer)
+	app.Get("/watch/:slug", streamHandler.ShowPlayer)
+	app.Get("/embed/:slug", streamHandler.ShowEmbed)
 	app.Get("/stream/:token", middleware.RateLimitStream(), streamHandler.StreamVideo)
 
+	// Sitemaps & feeds
+	app.Get("/sitemap.xml", syndicationHandler.GetSitemapIndex)
+	app.Get("/sitemaps/videos-:page.xml", syndicationHandler.GetSitemapPage)
+	app.Get("/feeds/rss.xml", syndicationHandler.GetRSSFeed)
+	app.Get("/feeds/atom.xml", syndicationHandler.GetAtomFeed)
+
 	// Admin routes
 	admin := api.Group("/admin", middleware.AuthRequired(), middleware.AdminOnly())
 
 	adminVideos := admin.Group("/videos")
 	adminVideos.Get("/", adminVideoHandler.GetAllVideos)
+	adminVideos.Get("/trash", adminVideoHandler.GetTrashedVideos)
 	adminVideos.Post("/upload", adminVideoHandler.UploadVideo)
+	adminVideos.Post("/check-duplicate", adminVideoHandler.CheckDuplicate)
+	adminVideos.Get("/link-failures", adminVideoHandler.GetLinkFailures)
+	adminVideos.Post("/refresh-links", adminVideoHandler.RefreshLinks)
+	adminVideos.Get("/optimize-streaming", adminVideoHandler.GetStreamingOptimization)
+	adminVideos.Post("/optimize-streaming", adminVideoHandler.OptimizeStreaming)
 	adminVideos.Put("/:id", adminVideoHandler.UpdateVideo)
+	adminVideos.Get("/:id/revisions", adminVideoHandler.GetVideoRevisions)
+	adminVideos.Post("/:id/revisions/:version/revert", adminVideoHandler.RevertVideo)
 	adminVideos.Delete("/:id", adminVideoHandler.DeleteVideo)
 	adminVideos.Post("/:id/refresh", adminVideoHandler.RefreshVideoLink)
+	adminVideos.Post("/:id/restore", adminVideoHandler.RestoreVideo)
+	adminVideos.Delete("/:id/purge", adminVideoHandler.PurgeVideo)
+
+	adminTags := admin.Group("/tags")
+	adminTags.Get("/", adminTagHandler.GetAllTags)
+	adminTags.Put("/:id", adminTagHandler.RenameTag)
+	adminTags.Delete("/:id", adminTagHandler.DeleteTag)
+	adminTags.Post("/:id/merge", adminTagHandler.MergeTag)
+	adminTags.Post("/:id/aliases", adminTagHandler.AddAlias)
+	adminTags.Delete("/:id/aliases/:slug", adminTagHandler.DeleteAlias)
+
+	adminCategories := admin.Group("/categories")
+	adminCategories.Get("/", adminCategoryHandler.GetAllCategories)
+	adminCategories.Post("/", adminCategoryHandler.CreateCategory)
+	adminCategories.Put("/reorder", adminCategoryHandler.ReorderCategories)
+	adminCategories.Get("/:id", adminCategoryHandler.GetCategory)
+	adminCategories.Put("/:id", adminCategoryHandler.UpdateCategory)
+	adminCategories.Delete("/:id", adminCategoryHandler.DeleteCategory)
+	adminCategories.Post("/:id/toggle", adminCategoryHandler.ToggleActive)
+
+	adminPlaylists := admin.Group("/playlists")
+	adminPlaylists.Get("/", adminPlaylistHandler.GetAllPlaylists)
+	adminPlaylists.Get("/:id", adminPlaylistHandler.GetPlaylist)
+	adminPlaylists.Post("/", adminPlaylistHandler.CreatePlaylist)
+	adminPlaylists.Put("/:id", adminPlaylistHandler.UpdatePlaylist)
+	adminPlaylists.Delete("/:id", adminPlaylistHandler.DeletePlaylist)
+	adminPlaylists.Post("/:id/items", adminPlaylistHandler.AddVideos)
+	adminPlaylists.Put("/:id/items/order", adminPlaylistHandler.ReorderVideos)
+	adminPlaylists.Delete("/:id/items/:videoId", adminPlaylistHandler.RemoveVideo)
+
+	adminImports := admin.Group("/imports")
+	adminImports.Get("/", adminImportHandler.GetImports)
+	adminImports.Get("/:id", adminImportHandler.GetImport)
+	adminImports.Post("/", adminImportHandler.CreateImport)
+
+	adminJobs := admin.Group("/jobs")
+	adminJobs.Get("/", adminJobHandler.GetJobs)
+	adminJobs.Get("/:id", adminJobHandler.GetJob)
+	adminJobs.Post("/:id/retry", adminJobHandler.RetryJob)
+	adminJobs.Post("/:id/cancel", adminJobHandler.CancelJob)
 
 	adminAds := admin.Group("/ads")
 	adminAds.Get("/", adminAdHandler.GetAllAds)
@@ -210,6 +400,9 @@ func main() {
 	adminAnalytics.Get("/monthly", analyticsHandler.GetMonthlyStats)
 	adminAnalytics.Get("/top-videos", analyticsHandler.GetTopVideos)
 
+	adminRecommendations := admin.Group("/recommendations")
+	adminRecommendations.Get("/explain", adminRecommendationHandler.ExplainRecommendation)
+
 	adminPCloud := admin.Group("/pcloud/accounts")
 	adminPCloud.Get("/", pcloudHandler.GetAllAccounts)
 	adminPCloud.Get("/:id", pcloudHandler.GetAccountByID)
@@ -217,16 +410,43 @@ func main() {
 	adminPCloud.Put("/:id", pcloudHandler.UpdateAccount)
 	adminPCloud.Delete("/:id", pcloudHandler.DeleteAccount)
 	adminPCloud.Post("/:id/toggle", pcloudHandler.ToggleActive)
+	adminPCloud.Post("/:id/import", pcloudHandler.ImportCatalog)
+	adminPCloud.Get("/:id/health", pcloudHandler.GetAccountHealth)
+	adminPCloud.Post("/:id/health/check", pcloudHandler.CheckAccountHealth)
+
+	adminSelection := admin.Group("/pcloud/selection")
+	adminSelection.Get("/", pcloudHandler.GetSelectionStrategy)
+	adminSelection.Put("/", pcloudHandler.SetSelectionStrategy)
+	adminSelection.Get("/preview", pcloudHandler.PreviewSelection)
 
 	// Initialize cron jobs
 	c := cronpkg.New()
 
-	refreshLinksJob := cron.NewRefreshLinksJob(pcloudService)
+	refreshLinksJob := cron.NewRefreshLinksJob(linkRefreshService)
 	c.AddFunc(config.GlobalConfig.Cron.RefreshLinks, refreshLinksJob.Run)
 
 	aggregateStatsJob := cron.NewAggregateStatsJob(analyticsService)
 	c.AddFunc(config.GlobalConfig.Cron.AggregateStats, aggregateStatsJob.Run)
 
+	purgeDeletedVideosJob := cron.NewPurgeDeletedVideosJob(pcloudService)
+	c.AddFunc(config.GlobalConfig.Cron.PurgeDeletedVideos, purgeDeletedVideosJob.Run)
+
+	pcloudHealthJob := cron.NewPCloudHealthJob(pcloudHealthService)
+	c.AddFunc(config.GlobalConfig.Cron.PCloudHealth, pcloudHealthJob.Run)
+
+	faststartJob := cron.NewFaststartJob(faststartService)
+	c.AddFunc(config.GlobalConfig.Cron.Faststart, faststartJob.Run)
+
+	purgeJobsJob := cron.NewPurgeJobsJob(jobQueue)
+	c.AddFunc(config.GlobalConfig.Cron.PurgeJobs, purgeJobsJob.Run)
+
+	relatedVideosJob := cron.NewRelatedVideosJob(relatedVideoService)
+	c.AddFunc(config.GlobalConfig.Cron.RelatedVideos, relatedVideosJob.Run)
+	go relatedVideosJob.Run() // Precompute now instead of scoring live until the first scheduled run
+
+	recommendationsJob := cron.NewRecommendationsJob(recommendationService)
+	c.AddFunc(config.GlobalConfig.Cron.Recommendations, recommendationsJob.Run)
+
 	c.Start()
 	log.Println("✅ Cron jobs started")
 
@@ -238,6 +458,8 @@ func main() {
 		<-sigChan
 		log.Println("🛑 Shutting down server...")
 		c.Stop()
+		jobQueue.Shutdown()
+		faststartService.Shutdown()
 		cache.Close()
 		app.Shutdown()
 	}()
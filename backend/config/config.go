@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,12 +19,23 @@ type Config struct {
 	RateLimit RateLimitConfig
 	Cron      CronConfig
 	Redis     RedisConfig // ✅ ADD
+	Trash     TrashConfig
+	Import    ImportConfig
+	Links     LinkRefreshConfig
+	Faststart FaststartConfig
+	Jobs      JobsConfig
+	Upload    UploadConfig
+	Search    SearchConfig
+	Feeds     FeedsConfig
+	Related   RelatedConfig
+	Recommend RecommendConfig
 }
 
 type AppConfig struct {
-	Env  string
-	Port string
-	Host string
+	Env       string
+	Port      string
+	Host      string
+	PublicURL string // Scheme and host of public links in sitemaps, feeds and share tags, without a trailing slash
 }
 
 type DatabaseConfig struct {
@@ -42,7 +55,25 @@ type JWTConfig struct {
 }
 
 type PCloudConfig struct {
-	BaseURL string
+	BaseURL     string
+	EUBaseURL   string
+	TimeoutSec  int
+	MaxAttempts int
+
+	// Account health checks
+	HealthSlowMS          int // Latency above this marks an account degraded
+	HealthQuarantineAfter int // Consecutive failed checks before leaving upload rotation
+	HealthRecoverAfter    int // Consecutive passed checks before rejoining
+	HealthHistoryDays     int
+
+	// Upload account selection
+	SelectionStrategy     string // most_free, round_robin, least_recent_traffic, weighted or tag_pinned
+	SelectionTrafficHours int    // Traffic window of least_recent_traffic
+}
+
+// Timeout returns the per-request pCloud API timeout
+func (p PCloudConfig) Timeout() time.Duration {
+	return time.Duration(p.TimeoutSec) * time.Second
 }
 
 type CORSConfig struct {
@@ -56,8 +87,14 @@ type RateLimitConfig struct {
 }
 
 type CronConfig struct {
-	RefreshLinks   string
-	AggregateStats string
+	RefreshLinks       string
+	AggregateStats     string
+	PurgeDeletedVideos string
+	PCloudHealth       string
+	Faststart          string
+	PurgeJobs          string
+	RelatedVideos      string
+	Recommendations    string
 }
 
 // ✅ ADD: Redis configuration
@@ -68,6 +105,134 @@ type RedisConfig struct {
 	DB       int
 }
 
+// TrashConfig controls how long deleted videos stay restorable
+type TrashConfig struct {
+	RetentionDays int
+}
+
+// Retention returns the trash retention window as a duration
+func (t TrashConfig) Retention() time.Duration {
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
+// ImportConfig controls server-side imports from remote URLs
+type ImportConfig struct {
+	MaxSizeMB      int
+	TimeoutMinutes int
+}
+
+// MaxSizeBytes returns the largest file an import may fetch
+func (i ImportConfig) MaxSizeBytes() int64 {
+	return int64(i.MaxSizeMB) * 1024 * 1024
+}
+
+// Timeout returns the overall time limit for a single import
+func (i ImportConfig) Timeout() time.Duration {
+	return time.Duration(i.TimeoutMinutes) * time.Minute
+}
+
+// LinkRefreshConfig controls proactive renewal of pCloud streaming links
+type LinkRefreshConfig struct {
+	WindowMinutes      int // Renew links this long before they expire
+	JitterMinutes      int // Random extra window per video
+	Workers            int
+	BatchSize          int
+	BackoffBaseMinutes int
+	BackoffMaxMinutes  int
+	MaxFailures        int // Failures after which a video is reported as permanently failing
+}
+
+// FaststartConfig controls moving the MP4 index to the front of uploaded files
+type FaststartConfig struct {
+	TempDir        string // Where rewritten copies are written; empty uses the OS default
+	BatchSize      int    // Existing videos handled per batch run
+	TimeoutMinutes int    // Per-video limit for download, rewrite and re-upload
+}
+
+// Timeout returns the time limit for optimizing one existing video
+func (f FaststartConfig) Timeout() time.Duration {
+	return time.Duration(f.TimeoutMinutes) * time.Minute
+}
+
+// JobsConfig controls the Postgres-backed background job queue
+type JobsConfig struct {
+	Workers            int // Jobs run concurrently by this process
+	PollSeconds        int
+	MaxAttempts        int // Attempts before a job is dead-lettered
+	BackoffBaseSeconds int // Delay after the first failure, doubled per attempt
+	BackoffMaxMinutes  int
+	TimeoutMinutes     int    // Time limit for a single attempt
+	StaleSeconds       int    // Running jobs without a heartbeat this long are requeued
+	RetentionDays      int    // Finished jobs are purged after this many days
+	UploadDir          string // Spool directory for queued admin uploads
+}
+
+// UploadConfig is the content policy for admin uploads and imports
+type UploadConfig struct {
+	AllowedTypes       string // Comma-separated video MIME types
+	MaxSizeMB          int    // 0 means no limit
+	MaxDurationMinutes int    // 0 means no limit
+}
+
+// AllowedMIMETypes returns the allowed MIME types as a list
+func (u UploadConfig) AllowedMIMETypes() []string {
+	return strings.Split(u.AllowedTypes, ",")
+}
+
+// MaxSizeBytes returns the largest file that may be uploaded
+func (u UploadConfig) MaxSizeBytes() int64 {
+	return int64(u.MaxSizeMB) * 1024 * 1024
+}
+
+// MaxDuration returns the longest video that may be uploaded
+func (u UploadConfig) MaxDuration() time.Duration {
+	return time.Duration(u.MaxDurationMinutes) * time.Minute
+}
+
+// BodyLimit returns the HTTP request size limit: at least 500 MB, and enough
+// for the largest allowed upload plus form fields
+func (u UploadConfig) BodyLimit() int {
+	return int(max(500*1024*1024, u.MaxSizeBytes()+1024*1024))
+}
+
+// SearchConfig controls full-text video search
+type SearchConfig struct {
+	TSConfig         string // Postgres text search configuration: simple, indonesian or english
+	PopularityWeight int    // Percent of the ranking taken by views and likes instead of text relevance
+	SuggestCacheSecs int    // How long autocomplete suggestions are cached in Redis
+}
+
+// FeedsConfig controls sitemaps and RSS/Atom feeds
+type FeedsConfig struct {
+	SitemapPageSize int // Videos per child sitemap
+	FeedSize        int // Latest videos per RSS/Atom feed
+	CacheMinutes    int // How long rendered sitemaps and feeds are cached in Redis
+}
+
+// CacheTTL returns how long rendered sitemaps and feeds are cached
+func (f FeedsConfig) CacheTTL() time.Duration {
+	return time.Duration(f.CacheMinutes) * time.Minute
+}
+
+// RelatedConfig controls the nightly related videos computation
+type RelatedConfig struct {
+	PerVideo        int // Related videos kept per video
+	CoViewDays      int // Views older than this do not count as co-views
+	RecencyHalfLife int // Days after which the recency boost halves
+}
+
+// RecommendConfig controls personalized recommendations
+type RecommendConfig struct {
+	HistoryDays      int // Views and likes older than this are ignored
+	HistorySize      int // Most recent history videos recommendations start from
+	MinHistory       int // History videos an anonymous session needs for personalization
+	CompletedPercent int // Watched percentage at which a video is not recommended again
+	Neighbors        int // Similar videos kept per video in the model
+	MinViewers       int // Common viewers two videos need to be similar
+	TrendingPercent  int // Share of the recommendations taken by trending videos
+	TrendingDays     int // Views counted for trending videos
+}
+
 var GlobalConfig *Config
 
 // LoadConfig loads environment variables and initializes config
@@ -79,9 +244,10 @@ func LoadConfig() error {
 
 	GlobalConfig = &Config{
 		App: AppConfig{
-			Env:  getEnv("APP_ENV", "development"),
-			Port: getEnv("APP_PORT", "8080"),
-			Host: getEnv("APP_HOST", "localhost"),
+			Env:       getEnv("APP_ENV", "development"),
+			Port:      getEnv("APP_PORT", "8080"),
+			Host:      getEnv("APP_HOST", "localhost"),
+			PublicURL: strings.TrimRight(getEnv("APP_PUBLIC_URL", "http://localhost:8080"), "/"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -98,7 +264,18 @@ func LoadConfig() error {
 			RefreshExpiry: getEnv("JWT_REFRESH_EXPIRY", "168h"),
 		},
 		PCloud: PCloudConfig{
-			BaseURL: getEnv("PCLOUD_API_BASE_URL", "https://api.pcloud.com"),
+			BaseURL:     getEnv("PCLOUD_API_BASE_URL", "https://api.pcloud.com"),
+			EUBaseURL:   getEnv("PCLOUD_API_EU_BASE_URL", "https://eapi.pcloud.com"),
+			TimeoutSec:  getEnvAsInt("PCLOUD_API_TIMEOUT", 30),
+			MaxAttempts: getEnvAsInt("PCLOUD_MAX_ATTEMPTS", 3),
+
+			HealthSlowMS:          getEnvAsInt("PCLOUD_HEALTH_SLOW_MS", 3000),
+			HealthQuarantineAfter: getEnvAsInt("PCLOUD_HEALTH_QUARANTINE_AFTER", 3),
+			HealthRecoverAfter:    getEnvAsInt("PCLOUD_HEALTH_RECOVER_AFTER", 2),
+			HealthHistoryDays:     getEnvAsInt("PCLOUD_HEALTH_HISTORY_DAYS", 30),
+
+			SelectionStrategy:     getEnv("PCLOUD_SELECTION_STRATEGY", "most_free"),
+			SelectionTrafficHours: getEnvAsInt("PCLOUD_SELECTION_TRAFFIC_HOURS", 24),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173"),
@@ -109,8 +286,14 @@ func LoadConfig() error {
 			Stream: getEnvAsInt("RATE_LIMIT_STREAM", 100),
 		},
 		Cron: CronConfig{
-			RefreshLinks:   getEnv("CRON_REFRESH_LINKS", "0 */6 * * *"),
-			AggregateStats: getEnv("CRON_AGGREGATE_STATS", "0 0 * * *"),
+			RefreshLinks:       getEnv("CRON_REFRESH_LINKS", "*/30 * * * *"),
+			AggregateStats:     getEnv("CRON_AGGREGATE_STATS", "0 0 * * *"),
+			PurgeDeletedVideos: getEnv("CRON_PURGE_DELETED_VIDEOS", "0 3 * * *"),
+			PCloudHealth:       getEnv("CRON_PCLOUD_HEALTH", "*/10 * * * *"),
+			Faststart:          getEnv("CRON_FASTSTART", "0 4 * * *"),
+			PurgeJobs:          getEnv("CRON_PURGE_JOBS", "30 3 * * *"),
+			RelatedVideos:      getEnv("CRON_RELATED_VIDEOS", "0 2 * * *"),
+			Recommendations:    getEnv("CRON_RECOMMENDATIONS", "30 2 * * *"),
 		},
 		// ✅ ADD: Redis config
 		Redis: RedisConfig{
@@ -119,6 +302,68 @@ func LoadConfig() error {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
+		Trash: TrashConfig{
+			RetentionDays: getEnvAsInt("VIDEO_TRASH_RETENTION_DAYS", 30),
+		},
+		Links: LinkRefreshConfig{
+			WindowMinutes:      getEnvAsInt("LINK_REFRESH_WINDOW_MINUTES", 120),
+			JitterMinutes:      getEnvAsInt("LINK_REFRESH_JITTER_MINUTES", 30),
+			Workers:            getEnvAsInt("LINK_REFRESH_WORKERS", 4),
+			BatchSize:          getEnvAsInt("LINK_REFRESH_BATCH_SIZE", 500),
+			BackoffBaseMinutes: getEnvAsInt("LINK_REFRESH_BACKOFF_BASE_MINUTES", 5),
+			BackoffMaxMinutes:  getEnvAsInt("LINK_REFRESH_BACKOFF_MAX_MINUTES", 1440),
+			MaxFailures:        getEnvAsInt("LINK_REFRESH_MAX_FAILURES", 5),
+		},
+		Import: ImportConfig{
+			MaxSizeMB:      getEnvAsInt("IMPORT_MAX_SIZE_MB", 4096),
+			TimeoutMinutes: getEnvAsInt("IMPORT_TIMEOUT_MINUTES", 120),
+		},
+		Faststart: FaststartConfig{
+			TempDir:        getEnv("FASTSTART_TEMP_DIR", ""),
+			BatchSize:      getEnvAsInt("FASTSTART_BATCH_SIZE", 10),
+			TimeoutMinutes: getEnvAsInt("FASTSTART_TIMEOUT_MINUTES", 120),
+		},
+		Upload: UploadConfig{
+			AllowedTypes:       getEnv("UPLOAD_ALLOWED_TYPES", "video/mp4,video/quicktime,video/webm,video/x-matroska"),
+			MaxSizeMB:          getEnvAsInt("UPLOAD_MAX_SIZE_MB", 500),
+			MaxDurationMinutes: getEnvAsInt("UPLOAD_MAX_DURATION_MINUTES", 240),
+		},
+		Jobs: JobsConfig{
+			Workers:            getEnvAsInt("JOBS_WORKERS", 2),
+			PollSeconds:        getEnvAsInt("JOBS_POLL_SECONDS", 5),
+			MaxAttempts:        getEnvAsInt("JOBS_MAX_ATTEMPTS", 5),
+			BackoffBaseSeconds: getEnvAsInt("JOBS_BACKOFF_BASE_SECONDS", 30),
+			BackoffMaxMinutes:  getEnvAsInt("JOBS_BACKOFF_MAX_MINUTES", 60),
+			TimeoutMinutes:     getEnvAsInt("JOBS_TIMEOUT_MINUTES", 120),
+			StaleSeconds:       getEnvAsInt("JOBS_STALE_SECONDS", 120),
+			RetentionDays:      getEnvAsInt("JOBS_RETENTION_DAYS", 14),
+			UploadDir:          getEnv("JOBS_UPLOAD_DIR", "uploads"),
+		},
+		Search: SearchConfig{
+			TSConfig:         getEnv("SEARCH_TS_CONFIG", "simple"),
+			PopularityWeight: getEnvAsInt("SEARCH_POPULARITY_WEIGHT", 20),
+			SuggestCacheSecs: getEnvAsInt("SEARCH_SUGGEST_CACHE_SECONDS", 60),
+		},
+		Feeds: FeedsConfig{
+			SitemapPageSize: getEnvAsInt("SITEMAP_PAGE_SIZE", 1000),
+			FeedSize:        getEnvAsInt("FEED_SIZE", 50),
+			CacheMinutes:    getEnvAsInt("FEED_CACHE_MINUTES", 60),
+		},
+		Related: RelatedConfig{
+			PerVideo:        getEnvAsInt("RELATED_PER_VIDEO", 30),
+			CoViewDays:      getEnvAsInt("RELATED_COVIEW_DAYS", 90),
+			RecencyHalfLife: getEnvAsInt("RELATED_RECENCY_HALF_LIFE_DAYS", 30),
+		},
+		Recommend: RecommendConfig{
+			HistoryDays:      getEnvAsInt("RECOMMEND_HISTORY_DAYS", 180),
+			HistorySize:      getEnvAsInt("RECOMMEND_HISTORY_SIZE", 50),
+			MinHistory:       getEnvAsInt("RECOMMEND_MIN_HISTORY", 3),
+			CompletedPercent: getEnvAsInt("RECOMMEND_COMPLETED_PERCENT", 90),
+			Neighbors:        getEnvAsInt("RECOMMEND_NEIGHBORS", 50),
+			MinViewers:       getEnvAsInt("RECOMMEND_MIN_VIEWERS", 2),
+			TrendingPercent:  getEnvAsInt("RECOMMEND_TRENDING_PERCENT", 20),
+			TrendingDays:     getEnvAsInt("RECOMMEND_TRENDING_DAYS", 7),
+		},
 	}
 
 	// Validate required configs
@@ -128,6 +373,9 @@ func LoadConfig() error {
 	if GlobalConfig.JWT.RefreshSecret == "" {
 		log.Fatal("JWT_REFRESH_SECRET is required")
 	}
+	if GlobalConfig.Related.RecencyHalfLife <= 0 {
+		log.Fatal("RELATED_RECENCY_HALF_LIFE_DAYS must be greater than 0")
+	}
 
 	return nil
 }
@@ -146,4 +394,4 @@ func getEnvAsInt(key string, fallback int) int {
 		}
 	}
 	return fallback
-}
\ No newline at end of file
+}
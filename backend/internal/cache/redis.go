@@ -49,6 +49,30 @@ func Delete(ctx context.Context, keys ...string) error {
 	return RedisClient.Del(ctx, keys...).Err()
 }
 
+// DeletePattern removes keys matching a glob pattern such as "feed:*". Keys are
+// found with SCAN, which does not block Redis the way KEYS does but still walks
+// the whole keyspace: use it for admin changes, not per-request counters.
+func DeletePattern(ctx context.Context, pattern string) error {
+	var keys []string
+	iter := RedisClient.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 100 {
+			if err := RedisClient.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return RedisClient.Del(ctx, keys...).Err()
+	}
+	return nil
+}
+
 // Exists checks if key exists in cache
 func Exists(ctx context.Context, key string) (bool, error) {
 	n, err := RedisClient.Exists(ctx, key).Result()
@@ -0,0 +1,45 @@
+package cron
+
+import (
+	"bobastream/internal/services"
+	"context"
+	"errors"
+	"log"
+)
+
+type FaststartJob struct {
+	faststartService *services.FaststartService
+	lock             *JobLock
+}
+
+func NewFaststartJob(faststartService *services.FaststartService) *FaststartJob {
+	return &FaststartJob{
+		faststartService: faststartService,
+		lock:             NewJobLock(),
+	}
+}
+
+// Run moves the MP4 index to the front of existing videos that are not optimized yet
+func (j *FaststartJob) Run() {
+	// ✅ Prevent overlapping runs
+	if !j.lock.TryLock() {
+		log.Println("⏭️  [CRON] Streaming optimization already running, skipping...")
+		return
+	}
+	defer j.lock.Unlock()
+
+	log.Println("🎞️  [CRON] Optimizing videos for streaming...")
+
+	summary, err := j.faststartService.OptimizeExisting(context.Background())
+	if errors.Is(err, services.ErrFaststartRunning) {
+		log.Println("⏭️  [CRON] Streaming optimization started by an admin, skipping...")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ [CRON] Streaming optimization interrupted: %v\n", err)
+		return
+	}
+
+	log.Printf("✅ [CRON] Checked %d videos: %d optimized, %d already optimized, %d failed\n",
+		summary.Candidates, summary.Optimized, summary.AlreadyOptimized, summary.Failed)
+}
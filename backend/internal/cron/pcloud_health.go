@@ -0,0 +1,40 @@
+package cron
+
+import (
+	"bobastream/internal/services"
+	"context"
+	"log"
+)
+
+type PCloudHealthJob struct {
+	healthService *services.PCloudHealthService
+	lock          *JobLock
+}
+
+func NewPCloudHealthJob(healthService *services.PCloudHealthService) *PCloudHealthJob {
+	return &PCloudHealthJob{
+		healthService: healthService,
+		lock:          NewJobLock(),
+	}
+}
+
+// Run probes every enabled pCloud account and updates quarantine state
+func (j *PCloudHealthJob) Run() {
+	// ✅ Prevent overlapping runs
+	if !j.lock.TryLock() {
+		log.Println("⏭️  [CRON] pCloud health check already running, skipping...")
+		return
+	}
+	defer j.lock.Unlock()
+
+	log.Println("🩺 [CRON] Checking pCloud account health...")
+
+	summary, err := j.healthService.CheckAll(context.Background())
+	if err != nil {
+		log.Printf("❌ [CRON] Failed to check pCloud account health: %v\n", err)
+		return
+	}
+
+	log.Printf("✅ [CRON] Checked %d pCloud accounts: %d healthy, %d degraded, %d unhealthy (%d quarantined, %d recovered)\n",
+		summary.Checked, summary.Healthy, summary.Degraded, summary.Unhealthy, summary.Quarantined, summary.Recovered)
+}
@@ -0,0 +1,41 @@
+package cron
+
+import (
+	"bobastream/config"
+	"bobastream/internal/services"
+	"context"
+	"log"
+)
+
+type PurgeDeletedVideosJob struct {
+	pcloudService *services.PCloudService
+	lock          *JobLock
+}
+
+func NewPurgeDeletedVideosJob(pcloudService *services.PCloudService) *PurgeDeletedVideosJob {
+	return &PurgeDeletedVideosJob{
+		pcloudService: pcloudService,
+		lock:          NewJobLock(),
+	}
+}
+
+// Run permanently removes videos whose trash retention has elapsed
+func (j *PurgeDeletedVideosJob) Run() {
+	// ✅ Prevent overlapping runs
+	if !j.lock.TryLock() {
+		log.Println("⏭️  [CRON] Purge deleted videos already running, skipping...")
+		return
+	}
+	defer j.lock.Unlock()
+
+	retentionDays := config.GlobalConfig.Trash.RetentionDays
+	log.Printf("🗑️  [CRON] Purging videos deleted more than %d days ago...\n", retentionDays)
+
+	purged, err := j.pcloudService.PurgeDeletedVideos(context.Background(), config.GlobalConfig.Trash.Retention())
+	if err != nil {
+		log.Printf("❌ [CRON] Failed to purge deleted videos: %v\n", err)
+		return
+	}
+
+	log.Printf("✅ [CRON] Purged %d deleted videos\n", purged)
+}
@@ -0,0 +1,41 @@
+package cron
+
+import (
+	"bobastream/config"
+	"bobastream/internal/services"
+	"context"
+	"log"
+)
+
+type PurgeJobsJob struct {
+	jobQueue *services.JobQueue
+	lock     *JobLock
+}
+
+func NewPurgeJobsJob(jobQueue *services.JobQueue) *PurgeJobsJob {
+	return &PurgeJobsJob{
+		jobQueue: jobQueue,
+		lock:     NewJobLock(),
+	}
+}
+
+// Run deletes finished background jobs past the retention period
+func (j *PurgeJobsJob) Run() {
+	// ✅ Prevent overlapping runs
+	if !j.lock.TryLock() {
+		log.Println("⏭️  [CRON] Purge jobs already running, skipping...")
+		return
+	}
+	defer j.lock.Unlock()
+
+	retentionDays := config.GlobalConfig.Jobs.RetentionDays
+	log.Printf("🗑️  [CRON] Purging jobs finished more than %d days ago...\n", retentionDays)
+
+	purged, err := j.jobQueue.PurgeFinished(context.Background())
+	if err != nil {
+		log.Printf("❌ [CRON] Failed to purge jobs: %v\n", err)
+		return
+	}
+
+	log.Printf("✅ [CRON] Purged %d finished jobs\n", purged)
+}
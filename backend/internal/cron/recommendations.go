@@ -0,0 +1,40 @@
+package cron
+
+import (
+	"bobastream/internal/services"
+	"log"
+	"time"
+)
+
+type RecommendationsJob struct {
+	recommendationService *services.RecommendationService
+	lock                  *JobLock
+}
+
+func NewRecommendationsJob(recommendationService *services.RecommendationService) *RecommendationsJob {
+	return &RecommendationsJob{
+		recommendationService: recommendationService,
+		lock:                  NewJobLock(),
+	}
+}
+
+// Run rebuilds the video similarity model behind personalized recommendations
+func (j *RecommendationsJob) Run() {
+	// ✅ Prevent overlapping runs
+	if !j.lock.TryLock() {
+		log.Println("⏭️  [CRON] Recommendation model rebuild already running, skipping...")
+		return
+	}
+	defer j.lock.Unlock()
+
+	log.Println("🧠 [CRON] Rebuilding recommendation model...")
+	started := time.Now()
+
+	stored, err := j.recommendationService.RebuildModel()
+	if err != nil {
+		log.Printf("❌ [CRON] Failed to rebuild recommendation model: %v\n", err)
+		return
+	}
+
+	log.Printf("✅ [CRON] Stored %d video similarities in %s\n", stored, time.Since(started).Round(time.Second))
+}
@@ -2,22 +2,23 @@ package cron
 
 import (
 	"bobastream/internal/services"
+	"context"
 	"log"
 )
 
 type RefreshLinksJob struct {
-	pcloudService *services.PCloudService
-	lock          *JobLock
+	linkRefreshService *services.LinkRefreshService
+	lock               *JobLock
 }
 
-func NewRefreshLinksJob(pcloudService *services.PCloudService) *RefreshLinksJob {
+func NewRefreshLinksJob(linkRefreshService *services.LinkRefreshService) *RefreshLinksJob {
 	return &RefreshLinksJob{
-		pcloudService: pcloudService,
-		lock:          NewJobLock(),
+		linkRefreshService: linkRefreshService,
+		lock:               NewJobLock(),
 	}
 }
 
-// Run refreshes expired pCloud video links
+// Run renews pCloud video links that expire within the refresh window
 func (j *RefreshLinksJob) Run() {
 	// ✅ Prevent overlapping runs
 	if !j.lock.TryLock() {
@@ -26,12 +27,14 @@ func (j *RefreshLinksJob) Run() {
 	}
 	defer j.lock.Unlock()
 
-	log.Println("🔄 [CRON] Starting refresh expired pCloud links...")
+	log.Println("🔄 [CRON] Starting refresh of expiring pCloud links...")
 
-	if err := j.pcloudService.RefreshExpiredLinks(); err != nil {
+	summary, err := j.linkRefreshService.RefreshLinks(context.Background())
+	if err != nil {
 		log.Printf("❌ [CRON] Failed to refresh links: %v\n", err)
 		return
 	}
 
-	log.Println("✅ [CRON] Successfully refreshed expired pCloud links")
-}
\ No newline at end of file
+	log.Printf("✅ [CRON] Link refresh done in %dms: %d candidates, %d refreshed, %d failed, %d skipped\n",
+		summary.DurationMS, summary.Candidates, summary.Refreshed, summary.Failed, summary.Skipped)
+}
@@ -0,0 +1,40 @@
+package cron
+
+import (
+	"bobastream/internal/services"
+	"log"
+	"time"
+)
+
+type RelatedVideosJob struct {
+	relatedVideoService *services.RelatedVideoService
+	lock                *JobLock
+}
+
+func NewRelatedVideosJob(relatedVideoService *services.RelatedVideoService) *RelatedVideosJob {
+	return &RelatedVideosJob{
+		relatedVideoService: relatedVideoService,
+		lock:                NewJobLock(),
+	}
+}
+
+// Run recomputes the related videos of every published video
+func (j *RelatedVideosJob) Run() {
+	// ✅ Prevent overlapping runs
+	if !j.lock.TryLock() {
+		log.Println("⏭️  [CRON] Related videos computation already running, skipping...")
+		return
+	}
+	defer j.lock.Unlock()
+
+	log.Println("🔗 [CRON] Computing related videos...")
+	started := time.Now()
+
+	stored, err := j.relatedVideoService.Rebuild()
+	if err != nil {
+		log.Printf("❌ [CRON] Failed to compute related videos: %v\n", err)
+		return
+	}
+
+	log.Printf("✅ [CRON] Stored %d related videos in %s\n", stored, time.Since(started).Round(time.Second))
+}
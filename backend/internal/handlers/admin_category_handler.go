@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminCategoryHandler struct {
+	categoryService *services.CategoryService
+}
+
+func NewAdminCategoryHandler(categoryService *services.CategoryService) *AdminCategoryHandler {
+	return &AdminCategoryHandler{categoryService: categoryService}
+}
+
+// GetAllCategories gets all categories, inactive ones included, with video counts (admin)
+func (h *AdminCategoryHandler) GetAllCategories(c *fiber.Ctx) error {
+	categories, err := h.categoryService.GetAllCategoriesWithCounts()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get categories")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"categories": categories,
+	}, "")
+}
+
+// GetCategory gets a category by ID (admin)
+func (h *AdminCategoryHandler) GetCategory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	category, err := h.categoryService.GetCategoryByID(id)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, category, "")
+}
+
+// CreateCategory creates a category, below parent_id if given; the slug is
+// derived from the name and unique among its siblings (admin)
+func (h *AdminCategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var req struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Icon        string  `json:"icon"`
+		ParentID    *string `json:"parent_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// ✅ SANITIZE INPUTS
+	name := utils.TruncateString(utils.SanitizeString(req.Name), 100)
+	if name == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Name is required")
+	}
+	description := utils.TruncateString(utils.SanitizeString(req.Description), 1000)
+	icon := utils.TruncateString(utils.SanitizeString(req.Icon), 50)
+
+	parentID, err := parseParentID(req.ParentID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	category, err := h.categoryService.CreateCategory(name, description, icon, parentID)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, category, "Category created successfully")
+}
+
+// UpdateCategory updates a category; a new name also changes the slug. A
+// parent_id moves it with its subcategories, "" moving it to the root (admin).
+func (h *AdminCategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	var req struct {
+		Name         string  `json:"name"`
+		Description  string  `json:"description"`
+		Icon         string  `json:"icon"`
+		DisplayOrder *int    `json:"display_order"`
+		ParentID     *string `json:"parent_id"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	category, err := h.categoryService.GetCategoryByID(id)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	var move *services.CategoryMove
+	if req.ParentID != nil {
+		parentID, err := parseParentID(req.ParentID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		move = &services.CategoryMove{ParentID: parentID}
+	}
+
+	displayOrder := category.DisplayOrder
+	if req.DisplayOrder != nil {
+		displayOrder = *req.DisplayOrder
+	}
+
+	// ✅ SANITIZE INPUTS (empty fields are left unchanged)
+	category, err = h.categoryService.UpdateCategory(
+		id,
+		utils.TruncateString(utils.SanitizeString(req.Name), 100),
+		utils.TruncateString(utils.SanitizeString(req.Description), 1000),
+		utils.TruncateString(utils.SanitizeString(req.Icon), 50),
+		displayOrder,
+		move,
+	)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, category, "Category updated successfully")
+}
+
+// DeleteCategory deletes a category (admin). Query videos=refuse (default)
+// fails while it has videos, videos=unset leaves them uncategorized and
+// videos=reassign&target_id=<id> moves them to another category.
+func (h *AdminCategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	action := services.CategoryVideoAction(c.Query("videos", string(services.CategoryVideosRefuse)))
+	var target *uuid.UUID
+	switch action {
+	case services.CategoryVideosRefuse, services.CategoryVideosUnset:
+	case services.CategoryVideosReassign:
+		targetID, err := uuid.Parse(c.Query("target_id"))
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid target_id")
+		}
+		target = &targetID
+	default:
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "videos must be refuse, unset or reassign")
+	}
+
+	moved, err := h.categoryService.DeleteCategory(id, action, target)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"videos_moved": moved,
+	}, "Category deleted successfully")
+}
+
+// ToggleActive shows or hides a category (admin)
+func (h *AdminCategoryHandler) ToggleActive(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	var req struct {
+		IsActive bool `json:"is_active"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.categoryService.ToggleActive(id, req.IsActive); err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Category status updated")
+}
+
+// ReorderCategories sets the display order from a list of category IDs;
+// unlisted categories keep their order (admin)
+func (h *AdminCategoryHandler) ReorderCategories(c *fiber.Ctx) error {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if len(req.IDs) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "ids is required")
+	}
+
+	ids := make([]uuid.UUID, len(req.IDs))
+	for i, value := range req.IDs {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID: "+value)
+		}
+		ids[i] = id
+	}
+
+	if err := h.categoryService.ReorderCategories(ids); err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	categories, err := h.categoryService.GetAllCategoriesWithCounts()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get categories")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"categories": categories,
+	}, "Categories reordered successfully")
+}
+
+// parseParentID parses an optional parent category ID; nil or "" is the root
+func parseParentID(value *string) (*uuid.UUID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		return nil, errors.New("invalid parent_id")
+	}
+	return &id, nil
+}
+
+// categoryErrorResponse maps category service errors to HTTP responses
+func categoryErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	case errors.Is(err, services.ErrCategoryNameTaken), errors.Is(err, services.ErrCategorySlugTaken):
+		return utils.ErrorResponse(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrCategoryInUse):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Category still has videos; delete with videos=unset or videos=reassign&target_id=<id>")
+	case errors.Is(err, services.ErrCategoryHasChildren):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Category still has subcategories; move or delete them first")
+	case errors.Is(err, services.ErrCategoryMoveToSelf), errors.Is(err, services.ErrCategoryNoTarget),
+		errors.Is(err, services.ErrCategoryInvalidOrder), errors.Is(err, services.ErrCategoryNoParent),
+		errors.Is(err, services.ErrCategoryCycle):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	default:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update category")
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminImportHandler struct {
+	importService *services.ImportService
+}
+
+func NewAdminImportHandler(importService *services.ImportService) *AdminImportHandler {
+	return &AdminImportHandler{importService: importService}
+}
+
+// CreateImport queues a server-side import from a remote URL (admin)
+func (h *AdminImportHandler) CreateImport(c *fiber.Ctx) error {
+	var req struct {
+		URL             string   `json:"url"`
+		Title           string   `json:"title"`
+		Description     string   `json:"description"`
+		ThumbnailURL    string   `json:"thumbnail_url"`
+		CategoryID      string   `json:"category_id"`
+		Tags            []string `json:"tags"`
+		DurationSeconds int      `json:"duration_seconds"`
+		Force           bool     `json:"force"` // Import even if duplicate
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if _, err := utils.ValidateRemoteURL(req.URL); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid source URL: "+err.Error())
+	}
+
+	// ✅ SANITIZE ALL USER INPUTS
+	title := utils.TruncateString(utils.SanitizeString(req.Title), 500)
+	if title == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Title is required")
+	}
+
+	var categoryID *uuid.UUID
+	if req.CategoryID != "" {
+		id, err := uuid.Parse(req.CategoryID)
+		if err == nil {
+			categoryID = &id
+		}
+	}
+
+	if req.DurationSeconds < 0 {
+		req.DurationSeconds = 0
+	}
+
+	job := &models.ImportJob{
+		SourceURL:       req.URL,
+		Title:           title,
+		Description:     utils.TruncateString(utils.SanitizeString(req.Description), 10000),
+		ThumbnailURL:    utils.SanitizeURL(req.ThumbnailURL),
+		CategoryID:      categoryID,
+		Tags:            utils.SanitizeTags(req.Tags),
+		DurationSeconds: req.DurationSeconds,
+		Force:           req.Force,
+	}
+	if userID, ok := c.Locals("user_id").(uuid.UUID); ok {
+		job.CreatedBy = &userID
+	}
+
+	if err := h.importService.CreateJob(job); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create import job")
+	}
+
+	c.Status(fiber.StatusAccepted)
+	return utils.SuccessResponse(c, fiber.Map{
+		"job": job,
+	}, "Import started")
+}
+
+// GetImports lists import jobs (admin)
+func (h *AdminImportHandler) GetImports(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	status := c.Query("status")
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	jobs, total, err := h.importService.GetJobs(status, page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get import jobs")
+	}
+
+	items := make([]fiber.Map, 0, len(jobs))
+	for i := range jobs {
+		items = append(items, importJobResponse(&jobs[i]))
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"jobs":  items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}, "")
+}
+
+// GetImport gets import job status and progress (admin)
+func (h *AdminImportHandler) GetImport(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid import job ID")
+	}
+
+	job, err := h.importService.GetJobByID(id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Import job not found")
+	}
+
+	return utils.SuccessResponse(c, importJobResponse(job), "")
+}
+
+// importJobResponse pairs a job with its progress percentage (null while the size is unknown)
+func importJobResponse(job *models.ImportJob) fiber.Map {
+	response := fiber.Map{
+		"job":      job,
+		"progress": nil,
+	}
+	if progress := job.Progress(); progress >= 0 {
+		response["progress"] = progress
+	}
+	return response
+}
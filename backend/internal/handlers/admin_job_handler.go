@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminJobHandler struct {
+	jobQueue *services.JobQueue
+}
+
+func NewAdminJobHandler(jobQueue *services.JobQueue) *AdminJobHandler {
+	return &AdminJobHandler{jobQueue: jobQueue}
+}
+
+// GetJobs lists background jobs with per-status counts (admin)
+func (h *AdminJobHandler) GetJobs(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	status := c.Query("status")
+	jobType := c.Query("type")
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	jobs, total, err := h.jobQueue.GetJobs(status, jobType, page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get jobs")
+	}
+
+	counts, err := h.jobQueue.CountByStatus()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to count jobs")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"jobs":   jobs,
+		"counts": counts,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	}, "")
+}
+
+// GetJob gets a job with its payload, result and last error (admin)
+func (h *AdminJobHandler) GetJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobQueue.GetJob(id)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, job, "")
+}
+
+// RetryJob requeues a dead or cancelled job (admin)
+func (h *AdminJobHandler) RetryJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobQueue.Retry(id)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, job, "Job queued for retry")
+}
+
+// CancelJob cancels a queued or running job (admin)
+func (h *AdminJobHandler) CancelJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobQueue.Cancel(id)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, job, "Job cancelled")
+}
+
+// jobErrorResponse maps job queue errors to HTTP responses
+func jobErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Job not found")
+	case errors.Is(err, services.ErrJobNotCancellable):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Job already finished")
+	case errors.Is(err, services.ErrJobNotRetryable):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Only dead jobs and cancelled jobs whose input was kept can be retried")
+	default:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update job")
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxPlaylistBatch caps the videos added or ordered in one request
+const maxPlaylistBatch = 500
+
+type AdminPlaylistHandler struct {
+	playlistService *services.PlaylistService
+}
+
+func NewAdminPlaylistHandler(playlistService *services.PlaylistService) *AdminPlaylistHandler {
+	return &AdminPlaylistHandler{playlistService: playlistService}
+}
+
+// playlistRequest is the body of create and update; omitted fields are left unchanged on update
+type playlistRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	CoverURL    *string `json:"cover_url"`
+	Kind        *string `json:"kind"`
+	Visibility  *string `json:"visibility"`
+}
+
+// apply sanitizes the request fields onto a playlist
+func (req playlistRequest) apply(playlist *models.Playlist) error {
+	// ✅ SANITIZE INPUTS
+	if req.Title != nil {
+		title := utils.TruncateString(utils.SanitizeString(*req.Title), 255)
+		if title == "" {
+			return errors.New("title cannot be empty")
+		}
+		playlist.Title = title
+	}
+	if req.Description != nil {
+		playlist.Description = utils.TruncateString(utils.SanitizeString(*req.Description), 5000)
+	}
+	if req.CoverURL != nil {
+		coverURL := utils.SanitizeURL(*req.CoverURL)
+		if coverURL == "" && *req.CoverURL != "" {
+			return errors.New("invalid cover_url")
+		}
+		playlist.CoverURL = coverURL
+	}
+	if req.Kind != nil {
+		playlist.Kind = *req.Kind
+	}
+	if req.Visibility != nil {
+		playlist.Visibility = *req.Visibility
+	}
+	return nil
+}
+
+// GetAllPlaylists gets playlists of any visibility (admin)
+func (h *AdminPlaylistHandler) GetAllPlaylists(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	playlists, total, err := h.playlistService.GetAllPlaylists(page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get playlists")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"playlists": playlists,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	}, "")
+}
+
+// GetPlaylist gets a playlist with all its items, including unpublished videos (admin)
+func (h *AdminPlaylistHandler) GetPlaylist(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid playlist ID")
+	}
+
+	playlist, err := h.playlistService.GetPlaylistWithItems(id)
+	if err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, playlist, "")
+}
+
+// CreatePlaylist creates a playlist or series (admin)
+func (h *AdminPlaylistHandler) CreatePlaylist(c *fiber.Ctx) error {
+	var req playlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Title == nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Title is required")
+	}
+
+	playlist := &models.Playlist{}
+	if err := req.apply(playlist); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.playlistService.CreatePlaylist(playlist); err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, playlist, "Playlist created successfully")
+}
+
+// UpdatePlaylist updates playlist details; the slug does not change (admin)
+func (h *AdminPlaylistHandler) UpdatePlaylist(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid playlist ID")
+	}
+
+	var req playlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	playlist, err := h.playlistService.GetPlaylistByID(id)
+	if err != nil {
+		return playlistErrorResponse(c, err)
+	}
+	if err := req.apply(playlist); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.playlistService.UpdatePlaylist(playlist); err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, playlist, "Playlist updated successfully")
+}
+
+// DeletePlaylist deletes a playlist; its videos are kept (admin)
+func (h *AdminPlaylistHandler) DeletePlaylist(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid playlist ID")
+	}
+
+	if err := h.playlistService.DeletePlaylist(id); err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Playlist deleted successfully")
+}
+
+// AddVideos appends videos to the end of a playlist (admin)
+func (h *AdminPlaylistHandler) AddVideos(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid playlist ID")
+	}
+
+	videoIDs, err := parseVideoIDs(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if len(videoIDs) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "video_ids is required")
+	}
+
+	playlist, err := h.playlistService.AddVideos(id, videoIDs)
+	if err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, playlist, "Videos added to playlist")
+}
+
+// RemoveVideo removes a video from a playlist (admin)
+func (h *AdminPlaylistHandler) RemoveVideo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid playlist ID")
+	}
+	videoID, err := uuid.Parse(c.Params("videoId"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid video ID")
+	}
+
+	playlist, err := h.playlistService.RemoveVideo(id, videoID)
+	if err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, playlist, "Video removed from playlist")
+}
+
+// ReorderVideos sets the order of a playlist; video_ids lists all its videos in the new order (admin)
+func (h *AdminPlaylistHandler) ReorderVideos(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid playlist ID")
+	}
+
+	videoIDs, err := parseVideoIDs(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	playlist, err := h.playlistService.ReorderVideos(id, videoIDs)
+	if err != nil {
+		return playlistErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, playlist, "Playlist reordered successfully")
+}
+
+// parseVideoIDs reads {"video_ids": [...]} from the body
+func parseVideoIDs(c *fiber.Ctx) ([]uuid.UUID, error) {
+	var req struct {
+		VideoIDs []string `json:"video_ids"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	if len(req.VideoIDs) > maxPlaylistBatch {
+		return nil, fmt.Errorf("at most %d videos can be sent at once", maxPlaylistBatch)
+	}
+
+	videoIDs := make([]uuid.UUID, len(req.VideoIDs))
+	for i, value := range req.VideoIDs {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid video ID %q", value)
+		}
+		videoIDs[i] = id
+	}
+	return videoIDs, nil
+}
+
+// playlistErrorResponse maps playlist service errors to HTTP responses
+func playlistErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPlaylistNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Playlist not found")
+	case errors.Is(err, services.ErrPlaylistItemNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Video is not in the playlist")
+	case errors.Is(err, services.ErrPlaylistVideoNotFound):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrPlaylistInvalidKind),
+		errors.Is(err, services.ErrPlaylistInvalidAccess),
+		errors.Is(err, services.ErrPlaylistOrderMismatch):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	default:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update playlist")
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminRecommendationHandler struct {
+	recommendationService *services.RecommendationService
+}
+
+func NewAdminRecommendationHandler(recommendationService *services.RecommendationService) *AdminRecommendationHandler {
+	return &AdminRecommendationHandler{recommendationService: recommendationService}
+}
+
+// ExplainRecommendation tells why ?video_id= is or is not recommended to the
+// viewer given by ?user_id= or ?session_id=: its position among the first
+// ?limit= recommendations and the history videos that scored it (admin)
+func (h *AdminRecommendationHandler) ExplainRecommendation(c *fiber.Ctx) error {
+	videoID, err := uuid.Parse(c.Query("video_id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid video ID")
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE LIMIT
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	viewer := services.Viewer{SessionID: c.Query("session_id")}
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid user ID")
+		}
+		viewer.UserID = &userID
+	}
+	if viewer.UserID == nil && viewer.SessionID == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "user_id or session_id is required")
+	}
+
+	explanation, err := h.recommendationService.Explain(viewer, videoID, limit)
+	if errors.Is(err, services.ErrVideoNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Video not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to explain recommendation")
+	}
+
+	return utils.SuccessResponse(c, explanation, "")
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminTagHandler struct {
+	tagService *services.TagService
+}
+
+func NewAdminTagHandler(tagService *services.TagService) *AdminTagHandler {
+	return &AdminTagHandler{tagService: tagService}
+}
+
+// GetAllTags lists tags with their aliases and video counts (admin)
+func (h *AdminTagHandler) GetAllTags(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	tags, total, err := h.tagService.GetAllTags(c.Query("q"), page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get tags")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"tags":  tags,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}, "")
+}
+
+// RenameTag changes a tag's name and slug; the old slug becomes an alias (admin)
+func (h *AdminTagHandler) RenameTag(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	tag, err := h.tagService.RenameTag(id, req.Name)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, tag, "Tag renamed successfully")
+}
+
+// MergeTag moves a tag's videos and aliases into the target tag and deletes it (admin)
+func (h *AdminTagHandler) MergeTag(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	var req struct {
+		TargetID string `json:"target_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	targetID, err := uuid.Parse(req.TargetID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid target tag ID")
+	}
+
+	tag, err := h.tagService.MergeTags(id, targetID)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, tag, "Tags merged successfully")
+}
+
+// AddAlias makes another spelling resolve to the tag (admin)
+func (h *AdminTagHandler) AddAlias(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	var req struct {
+		Alias string `json:"alias"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Alias == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Alias is required")
+	}
+
+	tag, err := h.tagService.AddAlias(id, req.Alias)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, tag, "Alias added successfully")
+}
+
+// DeleteAlias removes an alias from the tag (admin)
+func (h *AdminTagHandler) DeleteAlias(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	tag, err := h.tagService.DeleteAlias(id, c.Params("slug"))
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, tag, "Alias deleted successfully")
+}
+
+// DeleteTag removes a tag from all videos and deletes it (admin)
+func (h *AdminTagHandler) DeleteTag(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid tag ID")
+	}
+
+	if err := h.tagService.DeleteTag(id); err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, nil, "Tag deleted successfully")
+}
+
+// tagErrorResponse maps tag service errors to HTTP responses
+func tagErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Tag not found")
+	case errors.Is(err, services.ErrTagAliasNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Alias not found")
+	case errors.Is(err, services.ErrTagNameEmpty):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Tag name is required")
+	case errors.Is(err, services.ErrTagMergeSelf):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "A tag cannot be merged into itself")
+	case errors.Is(err, services.ErrTagSlugTaken):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Slug already belongs to another tag")
+	case errors.Is(err, services.ErrTagAliasExists):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Slug already resolves to this tag")
+	default:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update tag")
+	}
+}
@@ -1,37 +1,59 @@
 package handlers
 
 import (
+	"bobastream/config"
 	"bobastream/internal/models"
+	"bobastream/internal/pcloud"
 	"bobastream/internal/services"
 	"bobastream/internal/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type AdminVideoHandler struct {
-	videoService    *services.VideoService
-	pcloudService   *services.PCloudService
-	categoryService *services.CategoryService
+	videoService       *services.VideoService
+	pcloudService      *services.PCloudService
+	categoryService    *services.CategoryService
+	linkRefreshService *services.LinkRefreshService
+	faststartService   *services.FaststartService
+	uploadService      *services.UploadService
+	uploadValidator    *services.UploadValidator
+	revisionService    *services.VideoRevisionService
 }
 
 func NewAdminVideoHandler(
 	videoService *services.VideoService,
 	pcloudService *services.PCloudService,
 	categoryService *services.CategoryService,
+	linkRefreshService *services.LinkRefreshService,
+	faststartService *services.FaststartService,
+	uploadService *services.UploadService,
+	uploadValidator *services.UploadValidator,
+	revisionService *services.VideoRevisionService,
 ) *AdminVideoHandler {
 	return &AdminVideoHandler{
-		videoService:    videoService,
-		pcloudService:   pcloudService,
-		categoryService: categoryService,
+		videoService:       videoService,
+		pcloudService:      pcloudService,
+		categoryService:    categoryService,
+		linkRefreshService: linkRefreshService,
+		faststartService:   faststartService,
+		uploadService:      uploadService,
+		uploadValidator:    uploadValidator,
+		revisionService:    revisionService,
 	}
 }
 
-// UploadVideo uploads video to pCloud with auto-rotate (admin)
+// UploadVideo validates the file and queues its upload to pCloud (admin)
 func (h *AdminVideoHandler) UploadVideo(c *fiber.Ctx) error {
 	// Parse multipart form
 	file, err := c.FormFile("video")
@@ -46,6 +68,8 @@ func (h *AdminVideoHandler) UploadVideo(c *fiber.Ctx) error {
 	categoryIDStr := c.FormValue("category_id")
 	tagsStr := c.FormValue("tags") // comma-separated
 	durationStr := c.FormValue("duration_seconds")
+	checksum := strings.ToLower(strings.TrimSpace(c.FormValue("checksum"))) // optional client SHA-256
+	force := c.FormValue("force") == "true"                                 // upload even if duplicate
 
 	// Validate required fields
 	if title == "" {
@@ -58,7 +82,7 @@ func (h *AdminVideoHandler) UploadVideo(c *fiber.Ctx) error {
 	// ✅ TRUNCATE DESCRIPTION (max 10000 chars reasonable limit)
 	description = utils.TruncateString(description, 10000)
 
-	// Parse duration (admin manual input)
+	// Parse duration (admin manual input, used when the file headers have none)
 	durationSeconds := 0
 	if durationStr != "" {
 		durationSeconds, _ = strconv.Atoi(durationStr)
@@ -67,23 +91,19 @@ func (h *AdminVideoHandler) UploadVideo(c *fiber.Ctx) error {
 		}
 	}
 
-	// Open file
-	fileHandle, err := file.Open()
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to open file")
+	if checksum != "" && !isSHA256Hex(checksum) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Checksum must be a hex-encoded SHA-256")
 	}
-	defer fileHandle.Close()
 
-	// Upload to pCloud (auto-rotate based on storage)
-	credential, fileID, _, err := h.pcloudService.UploadFile(fileHandle, file.Filename, file.Size)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to upload to pCloud: "+err.Error())
-	}
-
-	// Get streaming link from pCloud
-	sourceURL, expiresAt, err := h.pcloudService.GetFileLink(fileID, credential.APIToken)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get pCloud link")
+	// ✅ PRE-UPLOAD DUPLICATE CHECK (client-computed checksum, no bytes sent to pCloud yet)
+	if checksum != "" && !force {
+		existing, err := h.videoService.FindDuplicate(checksum, "", file.Size)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to check for duplicates")
+		}
+		if existing != nil {
+			return duplicateResponse(c, existing)
+		}
 	}
 
 	// Parse category ID
@@ -98,58 +118,146 @@ func (h *AdminVideoHandler) UploadVideo(c *fiber.Ctx) error {
 	// ✅ SANITIZE TAGS
 	var tags []string
 	if tagsStr != "" {
-		rawTags := strings.Split(tagsStr, ",")
-		tags = utils.SanitizeStrings(rawTags)
-		
-		// ✅ LIMIT NUMBER OF TAGS (max 20)
-		if len(tags) > 20 {
-			tags = tags[:20]
+		tags = utils.SanitizeTags(strings.Split(tagsStr, ","))
+	}
+
+	// Open file
+	fileHandle, err := file.Open()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to open file")
+	}
+	defer fileHandle.Close()
+
+	// ✅ VALIDATE CONTENT (MAGIC BYTES, TYPE, SIZE, DURATION) BEFORE QUEUEING
+	if _, err := h.uploadValidator.Validate(fileHandle, file.Filename, file.Size); err != nil {
+		return uploadRejectionResponse(c, err)
+	}
+
+	// ✅ VERIFY CLIENT CHECKSUM AGAINST THE BYTES WE ACTUALLY RECEIVED
+	if checksum != "" {
+		sum := sha256.New()
+		if _, err := io.Copy(sum, io.NewSectionReader(fileHandle, 0, file.Size)); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
 		}
-		
-		// ✅ LIMIT TAG LENGTH (max 50 chars each)
-		for i := range tags {
-			tags[i] = utils.TruncateString(tags[i], 50)
+		if hex.EncodeToString(sum.Sum(nil)) != checksum {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Checksum mismatch: file was corrupted in transit")
 		}
 	}
 
-	// Create video record
-	video := &models.Video{
-		Title:              title,
-		Description:        description,
-		ThumbnailURL:       thumbnailURL,
-		SourceURL:          sourceURL,
-		SourceURLExpiresAt: &expiresAt,
-		DurationSeconds:    durationSeconds,
-		FileSizeMB:         float64(file.Size) / (1024 * 1024),
-		PCloudFileID:       fmt.Sprintf("%d", fileID),
-		PCloudCredentialID: credential.ID,
-		CategoryID:         categoryID,
-		Tags:               tags,
-		IsPublished:        true,
+	// Queue the pCloud upload; the admin polls /api/admin/jobs/:id for the result
+	var createdBy *uuid.UUID
+	if userID, ok := c.Locals("user_id").(uuid.UUID); ok {
+		createdBy = &userID
 	}
 
-	// Save video to database
-	if err := h.videoService.CreateVideo(video); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to save video")
+	job, err := h.uploadService.Enqueue(file, services.VideoUploadPayload{
+		Title:           title,
+		Description:     description,
+		ThumbnailURL:    thumbnailURL,
+		CategoryID:      categoryID,
+		Tags:            tags,
+		DurationSeconds: durationSeconds,
+		Force:           force,
+	}, createdBy)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to queue upload")
 	}
 
-	// Create wrapper link
-	wrapperLink := &models.WrapperLink{
-		VideoID: video.ID,
+	c.Status(fiber.StatusAccepted)
+	return utils.SuccessResponse(c, fiber.Map{
+		"job": job,
+	}, "Upload queued")
+}
+
+// CheckDuplicate checks a client-computed SHA-256 before uploading (admin)
+func (h *AdminVideoHandler) CheckDuplicate(c *fiber.Ctx) error {
+	var req struct {
+		Checksum string `json:"checksum"`
+		Size     int64  `json:"size"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
-	if err := h.videoService.CreateWrapperLink(wrapperLink); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create wrapper link")
+
+	checksum := strings.ToLower(strings.TrimSpace(req.Checksum))
+	if !isSHA256Hex(checksum) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Checksum must be a hex-encoded SHA-256")
+	}
+
+	existing, err := h.videoService.FindDuplicate(checksum, "", req.Size)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to check for duplicates")
+	}
+	if existing != nil {
+		return duplicateResponse(c, existing)
 	}
 
 	return utils.SuccessResponse(c, fiber.Map{
-		"video":        video,
-		"wrapper_link": wrapperLink,
-		"pcloud_account": fiber.Map{
-			"account_name":  credential.AccountName,
-			"storage_used":  credential.StorageUsedGB,
-			"storage_limit": credential.StorageLimitGB,
-		},
-	}, "Video uploaded successfully")
+		"duplicate": false,
+	}, "")
+}
+
+// duplicateResponse tells the admin which video already has this content
+func duplicateResponse(c *fiber.Ctx, existing *models.Video) error {
+	duplicate := fiber.Map{
+		"video_id":   existing.ID,
+		"title":      existing.Title,
+		"created_at": existing.CreatedAt,
+		"in_trash":   existing.DeletedAt.Valid,
+	}
+	if existing.WrapperLink != nil {
+		duplicate["wrapper_token"] = existing.WrapperLink.WrapperToken
+	}
+
+	return utils.ErrorResponseWithData(c, fiber.StatusConflict, "Duplicate video: this file was already uploaded", fiber.Map{
+		"duplicate": duplicate,
+		"override":  "Resend the upload with force=true to upload anyway",
+	})
+}
+
+// uploadRejectionResponse returns a validation failure with its error code
+func uploadRejectionResponse(c *fiber.Ctx, err error) error {
+	var rejection *services.UploadValidationError
+	if !errors.As(err, &rejection) {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	status := fiber.StatusBadRequest
+	switch rejection.Code {
+	case services.UploadErrFileTooLarge:
+		status = fiber.StatusRequestEntityTooLarge
+	case services.UploadErrExtensionNotAllowed, services.UploadErrDisguisedFile,
+		services.UploadErrUnrecognizedFormat, services.UploadErrTypeNotAllowed:
+		status = fiber.StatusUnsupportedMediaType
+	case services.UploadErrInvalidMedia, services.UploadErrNoVideoTrack, services.UploadErrDurationTooLong:
+		status = fiber.StatusUnprocessableEntity
+	}
+
+	return utils.ErrorResponseWithData(c, status, rejection.Message, rejection)
+}
+
+// pcloudErrorStatus maps pCloud client errors to the HTTP status returned to the admin
+func pcloudErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pcloud.ErrQuotaExceeded):
+		return fiber.StatusInsufficientStorage
+	case errors.Is(err, pcloud.ErrRateLimited):
+		return fiber.StatusServiceUnavailable
+	case errors.Is(err, pcloud.ErrAuthFailed), errors.Is(err, pcloud.ErrAccessDenied):
+		return fiber.StatusBadGateway
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// isSHA256Hex reports whether s looks like a hex-encoded SHA-256 digest
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
 }
 
 // GetAllVideos gets all videos (admin)
@@ -178,7 +286,8 @@ func (h *AdminVideoHandler) GetAllVideos(c *fiber.Ctx) error {
 	}, "")
 }
 
-// UpdateVideo updates video (admin)
+// UpdateVideo updates video metadata, recorded as a revision (admin). The body
+// carries the version the edit is based on; a stale version is rejected.
 func (h *AdminVideoHandler) UpdateVideo(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
@@ -197,11 +306,15 @@ func (h *AdminVideoHandler) UpdateVideo(c *fiber.Ctx) error {
 		CategoryID   *string  `json:"category_id"`
 		Tags         []string `json:"tags"`
 		IsPublished  *bool    `json:"is_published"`
+		Version      *int     `json:"version"` // The version the edit is based on
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
+	if req.Version == nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "version is required")
+	}
 
 	// ✅ SANITIZE AND UPDATE FIELDS
 	if req.Title != "" {
@@ -237,19 +350,7 @@ func (h *AdminVideoHandler) UpdateVideo(c *fiber.Ctx) error {
 
 	if req.Tags != nil {
 		// ✅ SANITIZE TAGS
-		sanitizedTags := utils.SanitizeStrings(req.Tags)
-		
-		// ✅ LIMIT NUMBER OF TAGS (max 20)
-		if len(sanitizedTags) > 20 {
-			sanitizedTags = sanitizedTags[:20]
-		}
-		
-		// ✅ LIMIT TAG LENGTH (max 50 chars each)
-		for i := range sanitizedTags {
-			sanitizedTags[i] = utils.TruncateString(sanitizedTags[i], 50)
-		}
-		
-		video.Tags = sanitizedTags
+		video.Tags = utils.SanitizeTags(req.Tags)
 	}
 
 	if req.IsPublished != nil {
@@ -260,8 +361,8 @@ func (h *AdminVideoHandler) UpdateVideo(c *fiber.Ctx) error {
 		}
 	}
 
-	if err := h.videoService.UpdateVideo(video); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update video")
+	if err := h.revisionService.EditVideo(video, *req.Version, editorID(c)); err != nil {
+		return revisionErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.Map{
@@ -269,6 +370,95 @@ func (h *AdminVideoHandler) UpdateVideo(c *fiber.Ctx) error {
 	}, "Video updated successfully")
 }
 
+// GetVideoRevisions lists the metadata revisions of a video, newest first (admin)
+func (h *AdminVideoHandler) GetVideoRevisions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid video ID")
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	revisions, total, err := h.revisionService.GetRevisions(id, page, limit)
+	if err != nil {
+		return revisionErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"revisions": revisions,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	}, "")
+}
+
+// RevertVideo restores the metadata a video had at an earlier version (admin).
+// The body carries the current version, like an edit.
+func (h *AdminVideoHandler) RevertVideo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid video ID")
+	}
+
+	toVersion, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid version")
+	}
+
+	var req struct {
+		Version *int `json:"version"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Version == nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "version is required")
+	}
+
+	video, err := h.revisionService.RevertVideo(id, toVersion, *req.Version, editorID(c))
+	if err != nil {
+		return revisionErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"video": video,
+	}, fmt.Sprintf("Video reverted to version %d", toVersion))
+}
+
+// editorID gets the signed-in admin, recorded as the editor of a revision
+func editorID(c *fiber.Ctx) *uuid.UUID {
+	if userID, ok := c.Locals("user_id").(uuid.UUID); ok {
+		return &userID
+	}
+	return nil
+}
+
+// revisionErrorResponse maps video edit and revision errors to HTTP responses
+func revisionErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrVideoNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Video not found")
+	case errors.Is(err, services.ErrVideoRevisionNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Revision not found")
+	case errors.Is(err, services.ErrVideoVersionConflict):
+		return utils.ErrorResponse(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrVideoRevisionIsCurrent):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	default:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update video")
+	}
+}
+
 // DeleteVideo deletes video (admin)
 func (h *AdminVideoHandler) DeleteVideo(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
@@ -280,7 +470,90 @@ func (h *AdminVideoHandler) DeleteVideo(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete video")
 	}
 
-	return utils.SuccessResponse(c, nil, "Video deleted successfully")
+	return utils.SuccessResponse(c, fiber.Map{
+		"purge_at": time.Now().Add(config.GlobalConfig.Trash.Retention()),
+	}, "Video moved to trash")
+}
+
+// GetTrashedVideos lists deleted videos that can still be restored (admin).
+// With ?purge_failed=true it lists only those the purge job failed to remove.
+func (h *AdminVideoHandler) GetTrashedVideos(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	purgeFailedOnly := c.Query("purge_failed") == "true"
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	videos, total, err := h.videoService.GetTrashedVideos(purgeFailedOnly, page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get trashed videos")
+	}
+
+	retention := config.GlobalConfig.Trash.Retention()
+	items := make([]fiber.Map, len(videos))
+	for i := range videos {
+		items[i] = fiber.Map{
+			"video":      videos[i],
+			"deleted_at": videos[i].DeletedAt.Time,
+			"purge_at":   videos[i].DeletedAt.Time.Add(retention),
+		}
+		if videos[i].PurgeFailures > 0 {
+			items[i]["purge_failures"] = videos[i].PurgeFailures
+			items[i]["purge_error"] = videos[i].PurgeError
+			items[i]["purge_failed_at"] = videos[i].PurgeFailedAt
+			items[i]["next_purge_retry_at"] = videos[i].PurgeRetryAt
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"videos":         items,
+		"total":          total,
+		"page":           page,
+		"limit":          limit,
+		"retention_days": config.GlobalConfig.Trash.RetentionDays,
+	}, "")
+}
+
+// RestoreVideo restores a video from trash (admin)
+func (h *AdminVideoHandler) RestoreVideo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid video ID")
+	}
+
+	if err := h.videoService.RestoreVideo(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "Video not found in trash")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to restore video")
+	}
+
+	return utils.SuccessResponse(c, nil, "Video restored successfully")
+}
+
+// PurgeVideo permanently deletes a trashed video and its pCloud file (admin)
+func (h *AdminVideoHandler) PurgeVideo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid video ID")
+	}
+
+	video, err := h.videoService.GetTrashedVideoByID(id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Video not found in trash")
+	}
+
+	if err := h.pcloudService.PurgeVideo(c.Context(), video); err != nil {
+		return utils.ErrorResponse(c, pcloudErrorStatus(err), "Failed to purge video: "+err.Error())
+	}
+
+	return utils.SuccessResponse(c, nil, "Video permanently deleted")
 }
 
 // RefreshVideoLink manually refreshes video pCloud link (admin)
@@ -303,9 +576,9 @@ func (h *AdminVideoHandler) RefreshVideoLink(c *fiber.Ctx) error {
 	fileID := int64(0)
 	fmt.Sscanf(video.PCloudFileID, "%d", &fileID)
 
-	newURL, expiresAt, err := h.pcloudService.GetFileLink(fileID, video.PCloudCredential.APIToken)
+	newURL, expiresAt, err := h.pcloudService.GetFileLink(c.Context(), video.PCloudCredential, fileID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to refresh link")
+		return utils.ErrorResponse(c, pcloudErrorStatus(err), "Failed to refresh link")
 	}
 
 	// Update video
@@ -317,4 +590,93 @@ func (h *AdminVideoHandler) RefreshVideoLink(c *fiber.Ctx) error {
 		"source_url":            newURL,
 		"source_url_expires_at": expiresAt,
 	}, "Link refreshed successfully")
-}
\ No newline at end of file
+}
+
+// RefreshLinks runs a proactive link refresh now and returns its summary (admin)
+func (h *AdminVideoHandler) RefreshLinks(c *fiber.Ctx) error {
+	summary, err := h.linkRefreshService.RefreshLinks(c.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrLinkRefreshRunning) {
+			return utils.ErrorResponse(c, fiber.StatusConflict, "Link refresh is already running")
+		}
+		if summary == nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to refresh links")
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"summary": summary,
+	}, "Link refresh completed")
+}
+
+// OptimizeStreaming starts moving the MP4 index to the front of existing videos in the background (admin)
+func (h *AdminVideoHandler) OptimizeStreaming(c *fiber.Ctx) error {
+	if err := h.faststartService.Start(); err != nil {
+		if errors.Is(err, services.ErrFaststartRunning) {
+			return utils.ErrorResponse(c, fiber.StatusConflict, "Streaming optimization is already running")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to start streaming optimization")
+	}
+
+	c.Status(fiber.StatusAccepted)
+	return utils.SuccessResponse(c, nil, "Streaming optimization started")
+}
+
+// GetStreamingOptimization reports whether optimization is running and how the last batch went (admin)
+func (h *AdminVideoHandler) GetStreamingOptimization(c *fiber.Ctx) error {
+	running, summary := h.faststartService.Status()
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"running":      running,
+		"last_summary": summary,
+	}, "")
+}
+
+// GetLinkFailures lists videos whose pCloud links fail to refresh (admin).
+// Pass permanent=true to only list videos past the failure threshold.
+func (h *AdminVideoHandler) GetLinkFailures(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	permanentOnly := c.Query("permanent") == "true"
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	videos, total, err := h.linkRefreshService.GetFailingVideos(permanentOnly, page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get link failures")
+	}
+
+	items := make([]fiber.Map, 0, len(videos))
+	for i := range videos {
+		video := &videos[i]
+
+		item := fiber.Map{
+			"video_id":              video.ID,
+			"title":                 video.Title,
+			"failures":              video.LinkRefreshFailures,
+			"last_error":            video.LinkRefreshError,
+			"last_failed_at":        video.LinkRefreshFailedAt,
+			"next_retry_at":         video.LinkRefreshRetryAt,
+			"source_url_expires_at": video.SourceURLExpiresAt,
+			"permanent":             h.linkRefreshService.IsPermanentlyFailing(video),
+		}
+		if video.PCloudCredential != nil {
+			item["pcloud_account"] = video.PCloudCredential.AccountName
+		}
+		items = append(items, item)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"videos":       items,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+		"max_failures": h.linkRefreshService.MaxFailures(),
+	}, "")
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	maxFilterCategories = 20
+	maxFilterTags       = 10
+)
+
+// listingQuery is the query spec shared by the feed, category and search listings
+type listingQuery struct {
+	Page   int
+	Limit  int
+	Filter services.VideoFilter
+	Sort   services.VideoSort
+}
+
+// parseListingQuery reads pagination, filters and sort from the query string:
+//
+//	page, limit                    pagination (invalid values fall back to defaults)
+//	category_id=<uuid>[,<uuid>]    any of the categories
+//	min_duration, max_duration     duration range in seconds
+//	duration=short,medium,long     any of the duration buckets
+//	published_after=2024-01-31     date or RFC 3339 time
+//	tags=a,b&tags_match=any|all    videos with any (default) or all of the tags
+//	sort=relevance|newest|oldest|views|likes
+func parseListingQuery(c *fiber.Ctx) (listingQuery, error) {
+	var q listingQuery
+	q.Page, _ = strconv.Atoi(c.Query("page", "1"))
+	q.Limit, _ = strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE PAGINATION
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 || q.Limit > 100 {
+		q.Limit = 20
+	}
+
+	for _, value := range splitList(c.Query("category_id")) {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid category_id %q", value)
+		}
+		q.Filter.CategoryIDs = append(q.Filter.CategoryIDs, id)
+	}
+	if len(q.Filter.CategoryIDs) > maxFilterCategories {
+		return q, fmt.Errorf("at most %d categories can be selected", maxFilterCategories)
+	}
+
+	var err error
+	if q.Filter.MinDuration, err = parseSeconds(c, "min_duration"); err != nil {
+		return q, err
+	}
+	if q.Filter.MaxDuration, err = parseSeconds(c, "max_duration"); err != nil {
+		return q, err
+	}
+	if q.Filter.MaxDuration > 0 && q.Filter.MinDuration > q.Filter.MaxDuration {
+		return q, fmt.Errorf("min_duration must not exceed max_duration")
+	}
+
+	for _, bucket := range splitList(c.Query("duration")) {
+		if !services.ValidDurationBucket(bucket) {
+			return q, fmt.Errorf("invalid duration %q (use short, medium or long)", bucket)
+		}
+		q.Filter.DurationBuckets = append(q.Filter.DurationBuckets, bucket)
+	}
+
+	if value := c.Query("published_after"); value != "" {
+		publishedAfter, err := parseDateTime(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid published_after %q (use YYYY-MM-DD or RFC 3339)", value)
+		}
+		q.Filter.PublishedAfter = &publishedAfter
+	}
+
+	// Tags match by slug, so any spelling of a tag or its aliases works
+	for _, tag := range utils.SanitizeStrings(splitList(c.Query("tags"))) {
+		q.Filter.Tags = append(q.Filter.Tags, services.TagSlug(tag))
+	}
+	if len(q.Filter.Tags) > maxFilterTags {
+		return q, fmt.Errorf("at most %d tags can be selected", maxFilterTags)
+	}
+	switch c.Query("tags_match", "any") {
+	case "any":
+	case "all":
+		q.Filter.MatchAllTags = true
+	default:
+		return q, fmt.Errorf("tags_match must be any or all")
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		if !services.ValidVideoSort(sort) {
+			return q, fmt.Errorf("invalid sort %q (use relevance, newest, oldest, views or likes)", sort)
+		}
+		q.Sort = services.VideoSort(sort)
+	}
+
+	return q, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseSeconds reads a non-negative number of seconds; missing means 0
+func parseSeconds(c *fiber.Ctx, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number of seconds", key)
+	}
+	return seconds, nil
+}
+
+// parseDateTime accepts a date (midnight UTC) or an RFC 3339 time
+func parseDateTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -2,19 +2,37 @@ package handlers
 
 import (
 	"bobastream/internal/models"
+	"bobastream/internal/pcloud"
 	"bobastream/internal/services"
 	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PCloudHandler struct {
-	pcloudService *services.PCloudService
+	pcloudService        *services.PCloudService
+	catalogImportService *services.CatalogImportService
+	healthService        *services.PCloudHealthService
+	accountSelector      *services.AccountSelector
 }
 
-func NewPCloudHandler(pcloudService *services.PCloudService) *PCloudHandler {
-	return &PCloudHandler{pcloudService: pcloudService}
+func NewPCloudHandler(
+	pcloudService *services.PCloudService,
+	catalogImportService *services.CatalogImportService,
+	healthService *services.PCloudHealthService,
+	accountSelector *services.AccountSelector,
+) *PCloudHandler {
+	return &PCloudHandler{
+		pcloudService:        pcloudService,
+		catalogImportService: catalogImportService,
+		healthService:        healthService,
+		accountSelector:      accountSelector,
+	}
 }
 
 // GetAllAccounts gets all pCloud accounts (admin)
@@ -49,21 +67,40 @@ func (h *PCloudHandler) GetAccountByID(c *fiber.Ctx) error {
 // CreateAccount creates new pCloud account (admin)
 func (h *PCloudHandler) CreateAccount(c *fiber.Ctx) error {
 	var req struct {
-		AccountName    string  `json:"account_name" validate:"required"`
-		APIToken       string  `json:"api_token" validate:"required"`
-		StorageLimitGB float64 `json:"storage_limit_gb" validate:"required"`
+		AccountName     string   `json:"account_name" validate:"required"`
+		APIToken        string   `json:"api_token" validate:"required"`
+		StorageLimitGB  float64  `json:"storage_limit_gb" validate:"required"`
+		Region          string   `json:"region"`
+		SelectionWeight *int     `json:"selection_weight"`
+		PinnedTags      []string `json:"pinned_tags"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	if req.Region == "" {
+		req.Region = pcloud.RegionUS
+	}
+	if !pcloud.ValidRegion(req.Region) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid region (must be 'us' or 'eu')")
+	}
+
 	credential := &models.PCloudCredential{
-		AccountName:    req.AccountName,
-		APIToken:       req.APIToken,
-		StorageLimitGB: req.StorageLimitGB,
-		StorageUsedGB:  0,
-		IsActive:       true,
+		AccountName:     req.AccountName,
+		APIToken:        req.APIToken,
+		StorageLimitGB:  req.StorageLimitGB,
+		StorageUsedGB:   0,
+		Region:          req.Region,
+		IsActive:        true,
+		SelectionWeight: 1,
+		PinnedTags:      pinnedTags(req.PinnedTags),
+	}
+	if req.SelectionWeight != nil {
+		if *req.SelectionWeight < 1 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Selection weight must be at least 1")
+		}
+		credential.SelectionWeight = *req.SelectionWeight
 	}
 
 	if err := h.pcloudService.CreateCredential(credential); err != nil {
@@ -88,35 +125,62 @@ func (h *PCloudHandler) UpdateAccount(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		AccountName    string   `json:"account_name"`
-		APIToken       string   `json:"api_token"`
-		StorageLimitGB *float64 `json:"storage_limit_gb"`
-		StorageUsedGB  *float64 `json:"storage_used_gb"`
-		IsActive       *bool    `json:"is_active"`
+		AccountName     string    `json:"account_name"`
+		APIToken        string    `json:"api_token"`
+		StorageLimitGB  *float64  `json:"storage_limit_gb"`
+		StorageUsedGB   *float64  `json:"storage_used_gb"`
+		IsActive        *bool     `json:"is_active"`
+		Region          string    `json:"region"`
+		SelectionWeight *int      `json:"selection_weight"`
+		PinnedTags      *[]string `json:"pinned_tags"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	// Update fields
+	// Update fields; only the columns set here are written
+	var columns []string
 	if req.AccountName != "" {
 		account.AccountName = req.AccountName
+		columns = append(columns, "account_name")
 	}
 	if req.APIToken != "" {
 		account.APIToken = req.APIToken
+		columns = append(columns, "api_token")
 	}
 	if req.StorageLimitGB != nil {
 		account.StorageLimitGB = *req.StorageLimitGB
+		columns = append(columns, "storage_limit_gb")
 	}
 	if req.StorageUsedGB != nil {
 		account.StorageUsedGB = *req.StorageUsedGB
+		columns = append(columns, "storage_used_gb")
 	}
 	if req.IsActive != nil {
 		account.IsActive = *req.IsActive
+		columns = append(columns, "is_active")
+	}
+	if req.Region != "" {
+		if !pcloud.ValidRegion(req.Region) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid region (must be 'us' or 'eu')")
+		}
+		account.Region = req.Region
+		columns = append(columns, "region")
+	}
+	if req.SelectionWeight != nil {
+		if *req.SelectionWeight < 1 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Selection weight must be at least 1")
+		}
+		account.SelectionWeight = *req.SelectionWeight
+		columns = append(columns, "selection_weight")
+	}
+	if req.PinnedTags != nil {
+		account.PinnedTags = pinnedTags(*req.PinnedTags)
+		columns = append(columns, "pinned_tags")
 	}
 
-	if err := h.pcloudService.UpdateCredential(account); err != nil {
+	if err := h.pcloudService.UpdateCredential(account, columns...); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update account")
 	}
 
@@ -159,4 +223,211 @@ func (h *PCloudHandler) ToggleActive(c *fiber.Ctx) error {
 	}
 
 	return utils.SuccessResponse(c, nil, "Account status updated")
-}
\ No newline at end of file
+}
+
+// ImportCatalog creates videos for video files already stored in an account folder (admin).
+// Imports at most limit files per request; call again to continue, imported files are skipped.
+func (h *PCloudHandler) ImportCatalog(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid account ID")
+	}
+
+	if _, err := h.pcloudService.GetCredentialByID(id); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Account not found")
+	}
+
+	var req struct {
+		FolderID         int64  `json:"folder_id"`
+		Path             string `json:"path"`
+		Recursive        bool   `json:"recursive"`
+		DryRun           bool   `json:"dry_run"`
+		CreateCategories bool   `json:"create_categories"`
+		Publish          bool   `json:"publish"` // Imported videos are drafts unless set
+		Limit            int    `json:"limit"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// ✅ VALIDATE LIMIT (each import probes the file over the network)
+	if req.Limit < 1 || req.Limit > 500 {
+		req.Limit = 100
+	}
+
+	result, err := h.catalogImportService.Import(c.Context(), services.CatalogImportOptions{
+		CredentialID:     id,
+		FolderID:         req.FolderID,
+		FolderPath:       req.Path,
+		Recursive:        req.Recursive,
+		DryRun:           req.DryRun,
+		CreateCategories: req.CreateCategories,
+		Publish:          req.Publish,
+		Limit:            req.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, pcloud.ErrNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "Folder not found")
+		}
+		if result == nil {
+			return utils.ErrorResponse(c, pcloudErrorStatus(err), "Failed to import catalog: "+err.Error())
+		}
+	}
+
+	message := "Catalog imported"
+	if req.DryRun {
+		message = "Dry run: nothing was imported"
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"result": result,
+	}, message)
+}
+
+// GetAccountHealth gets current health and recent health check history (admin)
+func (h *PCloudHandler) GetAccountHealth(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid account ID")
+	}
+
+	account, err := h.pcloudService.GetCredentialByID(id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Account not found")
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	history, err := h.healthService.GetHistory(id, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get health history")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"health":  accountHealth(account),
+		"history": history,
+	}, "")
+}
+
+// CheckAccountHealth probes an account now (admin)
+func (h *PCloudHandler) CheckAccountHealth(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid account ID")
+	}
+
+	account, err := h.pcloudService.GetCredentialByID(id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Account not found")
+	}
+
+	check, err := h.healthService.CheckAccount(c.Context(), account)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to check account health")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"health": accountHealth(account),
+		"check":  check,
+	}, "")
+}
+
+// accountHealth is the health summary of an account
+func accountHealth(account *models.PCloudCredential) fiber.Map {
+	return fiber.Map{
+		"status":         account.HealthStatus,
+		"error":          account.HealthError,
+		"latency_ms":     account.HealthLatencyMS,
+		"checked_at":     account.HealthCheckedAt,
+		"failures":       account.HealthFailures,
+		"successes":      account.HealthSuccesses,
+		"quarantined":    account.IsQuarantined(),
+		"quarantined_at": account.QuarantinedAt,
+		"in_rotation":    account.IsActive && !account.IsQuarantined(),
+	}
+}
+
+// pinnedTags normalizes the category slugs and tags an account is pinned to
+func pinnedTags(raw []string) pq.StringArray {
+	tags := utils.SanitizeTags(raw)
+	for i := range tags {
+		tags[i] = strings.ToLower(tags[i])
+	}
+	return pq.StringArray(tags)
+}
+
+// GetSelectionStrategy gets the upload account selection strategy (admin)
+func (h *PCloudHandler) GetSelectionStrategy(c *fiber.Ctx) error {
+	strategy, source, err := h.accountSelector.Strategy()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get selection strategy")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"strategy":   strategy,
+		"source":     source,
+		"default":    h.accountSelector.DefaultStrategy(),
+		"strategies": services.SelectionStrategies,
+	}, "")
+}
+
+// SetSelectionStrategy overrides the configured selection strategy; an empty strategy restores it (admin)
+func (h *PCloudHandler) SetSelectionStrategy(c *fiber.Ctx) error {
+	var req struct {
+		Strategy string `json:"strategy"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if req.Strategy != "" && !services.ValidSelectionStrategy(req.Strategy) {
+		return utils.ErrorResponseWithData(c, fiber.StatusBadRequest, "Invalid selection strategy", fiber.Map{
+			"strategies": services.SelectionStrategies,
+		})
+	}
+
+	if err := h.accountSelector.SetStrategy(req.Strategy); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to update selection strategy")
+	}
+
+	return h.GetSelectionStrategy(c)
+}
+
+// PreviewSelection shows which account the next upload would land on (admin)
+func (h *PCloudHandler) PreviewSelection(c *fiber.Ctx) error {
+	sizeBytes, _ := strconv.ParseInt(c.Query("size_bytes", "0"), 10, 64)
+	if sizeBytes < 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid size_bytes")
+	}
+
+	placement := services.UploadPlacement{SizeBytes: sizeBytes}
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+		}
+		placement.CategoryID = &categoryID
+	}
+	if tagsStr := c.Query("tags"); tagsStr != "" {
+		placement.Tags = utils.SanitizeTags(strings.Split(tagsStr, ","))
+	}
+
+	selection, err := h.accountSelector.Preview(placement)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, err.Error())
+	}
+
+	message := ""
+	if selection.Account == nil {
+		message = "No account can take this upload"
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"selection": selection,
+	}, message)
+}
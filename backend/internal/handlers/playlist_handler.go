@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PlaylistHandler struct {
+	playlistService *services.PlaylistService
+}
+
+func NewPlaylistHandler(playlistService *services.PlaylistService) *PlaylistHandler {
+	return &PlaylistHandler{playlistService: playlistService}
+}
+
+// GetPlaylists gets the listed playlists and series
+func (h *PlaylistHandler) GetPlaylists(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE PAGINATION PARAMS
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	playlists, total, err := h.playlistService.GetPublicPlaylists(page, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get playlists")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"playlists": playlists,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	}, "")
+}
+
+// GetPlaylist gets a public or unlisted playlist with its published videos in order
+func (h *PlaylistHandler) GetPlaylist(c *fiber.Ctx) error {
+	playlist, err := h.playlistService.GetPublicPlaylist(c.Params("slug"))
+	if errors.Is(err, services.ErrPlaylistNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Playlist not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get playlist")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"playlist": playlist,
+	}, "")
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RecommendationHandler struct {
+	recommendationService *services.RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService *services.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService: recommendationService}
+}
+
+// GetRecommended recommends videos from the viewer's history: the logged-in
+// user's, or the ?session_id= of an anonymous viewer. Viewers without enough
+// history get trending videos ("personalized": false).
+func (h *RecommendationHandler) GetRecommended(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE LIMIT
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	viewer := services.Viewer{SessionID: c.Query("session_id")}
+	if uid, ok := c.Locals("user_id").(uuid.UUID); ok {
+		viewer.UserID = &uid
+	}
+
+	videos, personalized, err := h.recommendationService.GetRecommendations(viewer, limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get recommendations")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"videos":       videos,
+		"personalized": personalized,
+	}, "")
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"bobastream/internal/models"
 	"bobastream/internal/services"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"time"
@@ -12,19 +16,23 @@ import (
 )
 
 type StreamHandler struct {
-	videoService *services.VideoService
+	videoService    *services.VideoService
+	playlistService *services.PlaylistService
 }
 
-func NewStreamHandler(videoService *services.VideoService) *StreamHandler {
-	return &StreamHandler{videoService: videoService}
+func NewStreamHandler(videoService *services.VideoService, playlistService *services.PlaylistService) *StreamHandler {
+	return &StreamHandler{
+		videoService:    videoService,
+		playlistService: playlistService,
+	}
 }
 
-// ShowPlayer shows video player page with thumbnail
+// ShowPlayer shows video player page with thumbnail. The video is found by its
+// watch slug or wrapper token; old slugs of renamed videos redirect permanently.
+// With ?playlist=<playlist slug> it links the previous and next items and can
+// autoplay the next one.
 func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
-	token := c.Params("token")
-
-	// Get video by wrapper token
-	video, err := h.videoService.GetVideoByWrapperToken(token)
+	video, redirect, err := h.videoService.ResolveWatchPath(c.Params("slug"))
 	if err != nil {
 		return c.Status(404).SendString(`
 			<!DOCTYPE html>
@@ -39,6 +47,26 @@ func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
 		`)
 	}
 
+	if redirect != "" {
+		if query := c.Request().URI().QueryString(); len(query) > 0 {
+			redirect += "?" + string(query)
+		}
+		return c.Redirect(redirect, fiber.StatusMovedPermanently)
+	}
+	token := video.WrapperLink.WrapperToken
+
+	// A stale playlist link still plays the video, just without navigation
+	var playlistPanel string
+	if slug := c.Query("playlist"); slug != "" {
+		playback, err := h.playlistService.GetPlaybackContext(slug, video.ID)
+		if err != nil && !errors.Is(err, services.ErrPlaylistNotFound) && !errors.Is(err, services.ErrPlaylistItemNotFound) {
+			fmt.Printf("⚠️  WARNING: Failed to load playlist %s: %v\n", slug, err)
+		}
+		if playback != nil {
+			playlistPanel = playlistPanelHTML(playback)
+		}
+	}
+
 	// Build player HTML
 	html := fmt.Sprintf(`
 <!DOCTYPE html>
@@ -47,7 +75,7 @@ func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>%s - BOBA STREAM</title>
-    <style>
+%s    <style>
         * { margin: 0; padding: 0; box-sizing: border-box; }
         body {
             background: #0f0f0f;
@@ -205,6 +233,57 @@ func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
         .skip-btn.active {
             display: inline-block;
         }
+        .playlist-panel {
+            background: rgba(255,255,255,0.05);
+            border-radius: 12px;
+            padding: 16px;
+            margin-bottom: 24px;
+        }
+        .playlist-header {
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+            gap: 12px;
+            margin-bottom: 12px;
+        }
+        .playlist-title {
+            font-weight: 600;
+        }
+        .playlist-position, .autoplay-toggle {
+            color: #aaa;
+            font-size: 14px;
+        }
+        .playlist-nav {
+            display: flex;
+            justify-content: space-between;
+            gap: 12px;
+        }
+        .playlist-nav a {
+            color: #4a9eff;
+            text-decoration: none;
+            font-size: 14px;
+        }
+        .up-next {
+            display: none;
+            margin-top: 12px;
+            color: #ddd;
+            font-size: 14px;
+        }
+        .up-next.active {
+            display: flex;
+            align-items: center;
+            gap: 12px;
+        }
+        .up-next button, .up-next a {
+            padding: 6px 16px;
+            border-radius: 8px;
+            border: none;
+            background: rgba(255,255,255,0.1);
+            color: white;
+            cursor: pointer;
+            font-size: 14px;
+            text-decoration: none;
+        }
     </style>
 </head>
 <body>
@@ -228,6 +307,7 @@ func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
                 <button id="like-btn" class="btn btn-like">❤️ Like</button>
             </div>
             <div class="description">%s</div>
+            %s
         </div>
     </div>
 
@@ -265,6 +345,11 @@ func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
             await showAd();
         });
 
+        // Arriving from autoplay-next starts playback right away
+        if (new URLSearchParams(location.search).get('autoplay') === '1') {
+            showAd();
+        }
+
         // Show ad
         async function showAd() {
             try {
@@ -387,13 +472,133 @@ func (h *StreamHandler) ShowPlayer(c *fiber.Ctx) error {
     </script>
 </body>
 </html>
-	`, video.Title, video.ThumbnailURL, token, video.Title, video.ViewCount, video.LikeCount, video.Description, 
+	`, video.Title, watchMetaHTML(newWatchMeta(video)), video.ThumbnailURL, token, video.Title, video.ViewCount, video.LikeCount, video.Description, playlistPanel,
 		video.DurationSeconds, video.ID.String(), video.ID.String(), video.ID.String(), video.ID.String())
 
 	c.Set("Content-Type", "text/html; charset=utf-8")
 	return c.SendString(html)
 }
 
+// ShowEmbed shows the bare player that link preview cards load in an iframe.
+// Old slugs redirect like the watch page.
+func (h *StreamHandler) ShowEmbed(c *fiber.Ctx) error {
+	video, redirect, err := h.videoService.ResolveWatchPath(c.Params("slug"))
+	if err != nil {
+		return c.Status(404).SendString("Video not found")
+	}
+	if redirect != "" {
+		return c.Redirect(video.WrapperLink.EmbedPath(), fiber.StatusMovedPermanently)
+	}
+
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s - BOBA STREAM</title>
+    <link rel="canonical" href="%s">
+    <style>
+        html, body { margin: 0; height: 100%%; background: #000; overflow: hidden; }
+        video { width: 100%%; height: 100%%; object-fit: contain; }
+    </style>
+</head>
+<body>
+    <video controls controlsList="nodownload" preload="none" poster="%s">
+        <source src="/stream/%s" type="%s">
+    </video>
+</body>
+</html>
+`, video.Title, html.EscapeString(newWatchMeta(video).WatchURL), video.ThumbnailURL,
+		video.WrapperLink.WrapperToken, services.ContainerMIMEType(video.Container))
+
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	return c.SendString(page)
+}
+
+// playlistPanelHTML renders the playlist navigation of the watch page. Titles
+// are stored HTML-escaped, like the video title above it.
+func playlistPanelHTML(playback *services.PlaylistContext) string {
+	position := fmt.Sprintf("%d / %d", playback.Position, playback.Total)
+	if playback.Kind == models.PlaylistKindSeries {
+		position = fmt.Sprintf("Episode %d of %d", playback.Position, playback.Total)
+	}
+
+	var previous, next, nextURL string
+	if url := playlistWatchURL(playback.Previous, playback.Slug); url != "" {
+		previous = fmt.Sprintf(`<a href="%s">← %s</a>`, url, playback.Previous.Video.Title)
+	}
+	if url := playlistWatchURL(playback.Next, playback.Slug); url != "" {
+		next = fmt.Sprintf(`<a href="%s">%s →</a>`, url, playback.Next.Video.Title)
+		nextURL = url + "&autoplay=1"
+	}
+
+	panel := fmt.Sprintf(`
+            <div class="playlist-panel">
+                <div class="playlist-header">
+                    <span class="playlist-title">📺 %s</span>
+                    <span class="playlist-position">%s</span>
+                </div>
+                <div class="playlist-nav"><span>%s</span><span>%s</span></div>`, playback.Title, position, previous, next)
+	if nextURL == "" {
+		return panel + `
+            </div>`
+	}
+
+	// Autoplay-next: a countdown once the video ends, unless switched off
+	nextJSON, _ := json.Marshal(nextURL)
+	return panel + fmt.Sprintf(`
+                <label class="autoplay-toggle"><input type="checkbox" id="autoplay-toggle"> Autoplay next</label>
+                <div id="up-next" class="up-next">
+                    <span>Up next in <span id="up-next-count">5</span>s</span>
+                    <a href="%s">Play now</a>
+                    <button id="up-next-cancel" type="button">Cancel</button>
+                </div>
+            </div>
+            <script>
+                (() => {
+                    const nextUrl = %s;
+                    const toggle = document.getElementById('autoplay-toggle');
+                    const upNext = document.getElementById('up-next');
+                    const count = document.getElementById('up-next-count');
+                    let timer = null;
+
+                    toggle.checked = localStorage.getItem('autoplay_next') !== 'off';
+                    toggle.addEventListener('change', () => {
+                        localStorage.setItem('autoplay_next', toggle.checked ? 'on' : 'off');
+                    });
+
+                    document.getElementById('video').addEventListener('ended', () => {
+                        if (!toggle.checked) return;
+                        let seconds = 5;
+                        count.textContent = seconds;
+                        upNext.classList.add('active');
+                        timer = setInterval(() => {
+                            seconds--;
+                            count.textContent = seconds;
+                            if (seconds <= 0) {
+                                clearInterval(timer);
+                                location.href = nextUrl;
+                            }
+                        }, 1000);
+                    });
+
+                    document.getElementById('up-next-cancel').addEventListener('click', () => {
+                        clearInterval(timer);
+                        upNext.classList.remove('active');
+                    });
+                })();
+            </script>`, html.EscapeString(nextURL), nextJSON)
+}
+
+// playlistWatchURL links a playlist item's watch page within the playlist, or
+// returns "" if the item has no watch link
+func playlistWatchURL(item *services.PlaylistNavItem, slug string) string {
+	if item == nil || item.Video == nil || item.Video.WrapperLink == nil {
+		return ""
+	}
+	return item.Video.WrapperLink.WatchPath() + "?playlist=" + slug
+}
+
 // ✅ FIXED: StreamVideo with context cancellation to prevent goroutine leak
 func (h *StreamHandler) StreamVideo(c *fiber.Ctx) error {
 	token := c.Params("token")
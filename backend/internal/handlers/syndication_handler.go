@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SyndicationHandler struct {
+	syndicationService *services.SyndicationService
+}
+
+func NewSyndicationHandler(syndicationService *services.SyndicationService) *SyndicationHandler {
+	return &SyndicationHandler{
+		syndicationService: syndicationService,
+	}
+}
+
+// GetSitemapIndex serves the sitemap index
+func (h *SyndicationHandler) GetSitemapIndex(c *fiber.Ctx) error {
+	body, err := h.syndicationService.SitemapIndex()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to build sitemap")
+	}
+	return sendXML(c, "application/xml", body)
+}
+
+// GetSitemapPage serves a child sitemap of videos
+func (h *SyndicationHandler) GetSitemapPage(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Params("page"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Sitemap not found")
+	}
+
+	body, err := h.syndicationService.SitemapPage(page)
+	if errors.Is(err, services.ErrSitemapPageNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Sitemap not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to build sitemap")
+	}
+	return sendXML(c, "application/xml", body)
+}
+
+// GetRSSFeed serves the RSS 2.0 feed of the latest videos, optionally of a
+// ?category= slug path or a ?tag=
+func (h *SyndicationHandler) GetRSSFeed(c *fiber.Ctx) error {
+	return h.feed(c, services.FeedRSS, "application/rss+xml")
+}
+
+// GetAtomFeed serves the Atom feed of the latest videos, optionally of a
+// ?category= slug path or a ?tag=
+func (h *SyndicationHandler) GetAtomFeed(c *fiber.Ctx) error {
+	return h.feed(c, services.FeedAtom, "application/atom+xml")
+}
+
+func (h *SyndicationHandler) feed(c *fiber.Ctx, format services.FeedFormat, contentType string) error {
+	scope := services.FeedScope{
+		CategorySlug: c.Query("category"),
+		TagSlug:      c.Query("tag"),
+	}
+	if scope.CategorySlug != "" && scope.TagSlug != "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Use either category or tag, not both")
+	}
+
+	body, err := h.syndicationService.Feed(format, scope)
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	case errors.Is(err, services.ErrTagNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Tag not found")
+	case err != nil:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to build feed")
+	}
+	return sendXML(c, contentType, body)
+}
+
+func sendXML(c *fiber.Ctx, contentType string, body []byte) error {
+	c.Set(fiber.HeaderContentType, contentType+"; charset=utf-8")
+	return c.Send(body)
+}
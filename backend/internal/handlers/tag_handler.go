@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bobastream/internal/services"
+	"bobastream/internal/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TagHandler struct {
+	tagService   *services.TagService
+	videoService *services.VideoService
+}
+
+func NewTagHandler(tagService *services.TagService, videoService *services.VideoService) *TagHandler {
+	return &TagHandler{
+		tagService:   tagService,
+		videoService: videoService,
+	}
+}
+
+// GetPopularTags gets the tags with the most published videos
+func (h *TagHandler) GetPopularTags(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// ✅ VALIDATE LIMIT
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	tags, err := h.tagService.GetPopularTags(limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get tags")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"tags": tags,
+	}, "")
+}
+
+// GetTagVideos gets the published videos of a tag page. Old slugs and aliases
+// resolve to the tag; the response carries its canonical slug.
+func (h *TagHandler) GetTagVideos(c *fiber.Ctx) error {
+	tag, err := h.tagService.GetTagBySlug(c.Params("slug"))
+	if errors.Is(err, services.ErrTagNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Tag not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get tag")
+	}
+
+	q, err := parseListingQuery(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	q.Filter.Tags = []string{tag.Slug}
+	q.Filter.MatchAllTags = false
+
+	videos, total, err := h.videoService.ListVideos(q.Filter, q.Sort, q.Page, q.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get videos")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"tag":    tag,
+		"videos": videos,
+		"total":  total,
+		"page":   q.Page,
+		"limit":  q.Limit,
+	}, "")
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"bobastream/internal/models"
 	"bobastream/internal/services"
 	"bobastream/internal/utils"
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -12,32 +15,41 @@ import (
 type VideoHandler struct {
 	videoService    *services.VideoService
 	categoryService *services.CategoryService
+	searchService   *services.SearchService
+	playlistService *services.PlaylistService
+	relatedService  *services.RelatedVideoService
 }
 
 func NewVideoHandler(
 	videoService *services.VideoService,
 	categoryService *services.CategoryService,
+	searchService *services.SearchService,
+	playlistService *services.PlaylistService,
+	relatedService *services.RelatedVideoService,
 ) *VideoHandler {
 	return &VideoHandler{
 		videoService:    videoService,
 		categoryService: categoryService,
+		searchService:   searchService,
+		playlistService: playlistService,
+		relatedService:  relatedService,
 	}
 }
 
-// GetFeed gets video feed
+// GetFeed gets video feed; filters or a sort switch from the scored feed to a plain listing
 func (h *VideoHandler) GetFeed(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-
-	// ✅ VALIDATE PAGINATION
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+	q, err := parseListingQuery(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
-	videos, total, err := h.videoService.GetFeedVideos(page, limit)
+	var videos []models.Video
+	var total int64
+	if q.Filter.IsEmpty() && q.Sort == services.SortDefault {
+		videos, total, err = h.videoService.GetFeedVideos(q.Page, q.Limit)
+	} else {
+		videos, total, err = h.videoService.ListVideos(q.Filter, q.Sort, q.Page, q.Limit)
+	}
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get videos")
 	}
@@ -45,12 +57,14 @@ func (h *VideoHandler) GetFeed(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, fiber.Map{
 		"videos": videos,
 		"total":  total,
-		"page":   page,
-		"limit":  limit,
+		"page":   q.Page,
+		"limit":  q.Limit,
 	}, "")
 }
 
-// GetVideoByID gets video by ID
+// GetVideoByID gets video by ID with the breadcrumbs of its category. With
+// ?playlist=<slug> the response also carries the previous and next items of
+// that playlist.
 func (h *VideoHandler) GetVideoByID(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
@@ -62,12 +76,34 @@ func (h *VideoHandler) GetVideoByID(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Video not found")
 	}
 
-	return utils.SuccessResponse(c, fiber.Map{
+	response := fiber.Map{
 		"video": video,
-	}, "")
+	}
+
+	if video.Category != nil {
+		breadcrumbs, err := h.categoryService.GetBreadcrumbs(video.Category)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get category breadcrumbs")
+		}
+		response["breadcrumbs"] = breadcrumbs
+	}
+
+	// A stale or foreign playlist link still plays the video, just without navigation
+	if slug := c.Query("playlist"); slug != "" {
+		playback, err := h.playlistService.GetPlaybackContext(slug, id)
+		switch {
+		case err == nil:
+			response["playlist"] = playback
+		case errors.Is(err, services.ErrPlaylistNotFound), errors.Is(err, services.ErrPlaylistItemNotFound):
+		default:
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get playlist")
+		}
+	}
+
+	return utils.SuccessResponse(c, response, "")
 }
 
-// GetRelatedVideos gets related videos
+// GetRelatedVideos gets the videos related to a video by tags, co-views, category and recency
 func (h *VideoHandler) GetRelatedVideos(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
@@ -81,7 +117,10 @@ func (h *VideoHandler) GetRelatedVideos(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	videos, err := h.videoService.GetRelatedVideos(id, limit)
+	videos, err := h.relatedService.GetRelatedVideos(id, limit)
+	if errors.Is(err, services.ErrVideoNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Video not found")
+	}
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get related videos")
 	}
@@ -91,67 +130,77 @@ func (h *VideoHandler) GetRelatedVideos(c *fiber.Ctx) error {
 	}, "")
 }
 
-// ✅ FIXED: SearchVideos with keyword validation and sanitization
+// SearchVideos runs full-text search; results carry a rank and highlighted
+// snippets, with match counts per category and duration bucket as facets
 func (h *VideoHandler) SearchVideos(c *fiber.Ctx) error {
-	keyword := c.Query("q", "")
-	categoryIDStr := c.Query("category_id", "")
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-
-	// ✅ VALIDATE AND SANITIZE KEYWORD
-	if len(keyword) > 100 {
-		keyword = keyword[:100]
+	q, err := parseListingQuery(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
-	keyword = utils.SanitizeString(keyword)
 
-	// ✅ VALIDATE PAGINATION
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+	// ✅ VALIDATE KEYWORD (bound as a query parameter and escaped in highlights,
+	// so it is not HTML-escaped here: quotes are part of the search syntax)
+	keyword := strings.TrimSpace(c.Query("q", ""))
+	if runes := []rune(keyword); len(runes) > 100 {
+		keyword = string(runes[:100])
 	}
 
-	var categoryID *uuid.UUID
-	if categoryIDStr != "" {
-		id, err := uuid.Parse(categoryIDStr)
-		if err == nil {
-			categoryID = &id
-		}
+	videos, total, err := h.searchService.Search(keyword, q.Filter, q.Sort, q.Page, q.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to search videos")
 	}
 
-	videos, total, err := h.videoService.SearchVideos(keyword, categoryID, page, limit)
+	facets, err := h.searchService.Facets(keyword, q.Filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to search videos")
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to count search facets")
 	}
 
 	return utils.SuccessResponse(c, fiber.Map{
 		"videos": videos,
+		"facets": facets,
 		"total":  total,
-		"page":   page,
-		"limit":  limit,
+		"page":   q.Page,
+		"limit":  q.Limit,
 	}, "")
 }
 
-// GetVideosByCategory gets videos by category
+// SuggestSearch gets autocomplete suggestions for a partially typed query
+func (h *VideoHandler) SuggestSearch(c *fiber.Ctx) error {
+	suggestions, err := h.searchService.Suggest(c.Query("q", ""))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestions")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"suggestions": suggestions,
+	}, "")
+}
+
+// GetVideosByCategory gets videos by category; with ?include_descendants=true
+// videos of its active subcategories are listed too
 func (h *VideoHandler) GetVideosByCategory(c *fiber.Ctx) error {
 	categoryID, err := uuid.Parse(c.Params("categoryId"))
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
 	}
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-
-	// ✅ VALIDATE PAGINATION
-	if page < 1 {
-		page = 1
+	q, err := parseListingQuery(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+
+	categoryIDs := []uuid.UUID{categoryID}
+	if c.Query("include_descendants") == "true" {
+		categoryIDs, err = h.categoryService.GetSubtreeIDs(categoryID)
+		if errors.Is(err, services.ErrCategoryNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+		}
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get subcategories")
+		}
 	}
 
-	videos, total, err := h.videoService.GetVideosByCategory(categoryID, page, limit)
+	videos, total, err := h.videoService.GetVideosByCategory(categoryIDs, q.Filter, q.Sort, q.Page, q.Limit)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get videos")
 	}
@@ -159,14 +208,14 @@ func (h *VideoHandler) GetVideosByCategory(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, fiber.Map{
 		"videos": videos,
 		"total":  total,
-		"page":   page,
-		"limit":  limit,
+		"page":   q.Page,
+		"limit":  q.Limit,
 	}, "")
 }
 
-// GetCategories gets all active categories
+// GetCategories gets all active categories with their number of published videos
 func (h *VideoHandler) GetCategories(c *fiber.Ctx) error {
-	categories, err := h.categoryService.GetActiveCategories()
+	categories, err := h.categoryService.GetActiveCategoriesWithCounts()
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get categories")
 	}
@@ -176,6 +225,18 @@ func (h *VideoHandler) GetCategories(c *fiber.Ctx) error {
 	}, "")
 }
 
+// GetCategoryTree gets the active categories nested under their parents
+func (h *VideoHandler) GetCategoryTree(c *fiber.Ctx) error {
+	tree, err := h.categoryService.GetCategoryTree()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get category tree")
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"categories": tree,
+	}, "")
+}
+
 // TrackView tracks video view with watch duration
 func (h *VideoHandler) TrackView(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
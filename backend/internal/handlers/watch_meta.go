@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bobastream/config"
+	"bobastream/internal/models"
+	"bobastream/internal/services"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxMetaDescription keeps link preview descriptions to what previews show
+const maxMetaDescription = 200
+
+// Player size announced to preview cards when the video's own is unknown
+const (
+	defaultPlayerWidth  = 1280
+	defaultPlayerHeight = 720
+)
+
+// watchMeta is what link previews and search engines read about a watch page.
+// Text fields are plain (unescaped); watchMetaHTML escapes them.
+type watchMeta struct {
+	Title       string
+	Description string
+	WatchURL    string
+	EmbedURL    string
+	StreamURL   string
+	Thumbnail   string
+	ContentType string
+	Width       int
+	Height      int
+	Duration    int // Seconds
+	Published   time.Time
+	Views       int
+	Tags        []string
+}
+
+// newWatchMeta collects the page metadata of a video with a wrapper link.
+// Stored text is HTML-escaped by the input sanitizer, so it is unescaped here
+// and escaped once for its context when rendered.
+func newWatchMeta(video *models.Video) watchMeta {
+	publicURL := config.GlobalConfig.App.PublicURL
+
+	description := html.UnescapeString(video.Description)
+	if description == "" {
+		description = html.UnescapeString(video.Title)
+	}
+	if runes := []rune(description); len(runes) > maxMetaDescription {
+		description = strings.TrimSpace(string(runes[:maxMetaDescription-1])) + "…"
+	}
+
+	meta := watchMeta{
+		Title:       html.UnescapeString(video.Title),
+		Description: description,
+		WatchURL:    publicURL + video.WrapperLink.WatchPath(),
+		EmbedURL:    publicURL + video.WrapperLink.EmbedPath(),
+		StreamURL:   publicURL + "/stream/" + video.WrapperLink.WrapperToken,
+		Thumbnail:   absoluteURL(publicURL, html.UnescapeString(video.ThumbnailURL)),
+		ContentType: services.ContainerMIMEType(video.Container),
+		Width:       video.Width,
+		Height:      video.Height,
+		Duration:    video.DurationSeconds,
+		Published:   video.CreatedAt,
+		Views:       video.ViewCount,
+	}
+	if video.PublishedAt != nil {
+		meta.Published = *video.PublishedAt
+	}
+	if meta.Width == 0 || meta.Height == 0 {
+		meta.Width, meta.Height = defaultPlayerWidth, defaultPlayerHeight
+	}
+	for _, tag := range video.Tags {
+		meta.Tags = append(meta.Tags, html.UnescapeString(tag))
+	}
+	return meta
+}
+
+// watchMetaHTML renders the canonical link, OpenGraph video tags, the Twitter
+// player card and schema.org VideoObject JSON-LD for the page head
+func watchMetaHTML(meta watchMeta) string {
+	var b strings.Builder
+
+	tag := func(attr, key, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "    <meta %s=\"%s\" content=\"%s\">\n", attr, key, html.EscapeString(value))
+	}
+	width, height := strconv.Itoa(meta.Width), strconv.Itoa(meta.Height)
+
+	fmt.Fprintf(&b, "    <link rel=\"canonical\" href=\"%s\">\n", html.EscapeString(meta.WatchURL))
+	tag("name", "description", meta.Description)
+
+	// OpenGraph
+	tag("property", "og:site_name", "BOBA STREAM")
+	tag("property", "og:type", "video.other")
+	tag("property", "og:title", meta.Title)
+	tag("property", "og:description", meta.Description)
+	tag("property", "og:url", meta.WatchURL)
+	tag("property", "og:image", meta.Thumbnail)
+	tag("property", "og:video", meta.StreamURL)
+	if strings.HasPrefix(meta.StreamURL, "https://") {
+		tag("property", "og:video:secure_url", meta.StreamURL)
+	}
+	tag("property", "og:video:type", meta.ContentType)
+	tag("property", "og:video:width", width)
+	tag("property", "og:video:height", height)
+	if meta.Duration > 0 {
+		tag("property", "video:duration", strconv.Itoa(meta.Duration))
+	}
+	tag("property", "video:release_date", meta.Published.UTC().Format(time.RFC3339))
+	for _, name := range meta.Tags {
+		tag("property", "video:tag", name)
+	}
+
+	// Twitter player card; it plays the embed page in an iframe
+	tag("name", "twitter:card", "player")
+	tag("name", "twitter:title", meta.Title)
+	tag("name", "twitter:description", meta.Description)
+	tag("name", "twitter:image", meta.Thumbnail)
+	tag("name", "twitter:player", meta.EmbedURL)
+	tag("name", "twitter:player:width", width)
+	tag("name", "twitter:player:height", height)
+	tag("name", "twitter:player:stream", meta.StreamURL)
+	tag("name", "twitter:player:stream:content_type", meta.ContentType)
+
+	fmt.Fprintf(&b, "    <script type=\"application/ld+json\">%s</script>\n", videoObjectJSON(meta))
+	return b.String()
+}
+
+// videoObjectJSON renders a schema.org VideoObject. encoding/json escapes <, >
+// and &, so the output cannot close the script element it is embedded in.
+func videoObjectJSON(meta watchMeta) []byte {
+	object := map[string]interface{}{
+		"@context":       "https://schema.org",
+		"@type":          "VideoObject",
+		"name":           meta.Title,
+		"description":    meta.Description,
+		"uploadDate":     meta.Published.UTC().Format(time.RFC3339),
+		"contentUrl":     meta.StreamURL,
+		"embedUrl":       meta.EmbedURL,
+		"url":            meta.WatchURL,
+		"encodingFormat": meta.ContentType,
+		"interactionStatistic": map[string]interface{}{
+			"@type":                "InteractionCounter",
+			"interactionType":      map[string]string{"@type": "WatchAction"},
+			"userInteractionCount": meta.Views,
+		},
+	}
+	if meta.Thumbnail != "" {
+		object["thumbnailUrl"] = []string{meta.Thumbnail}
+	}
+	if meta.Duration > 0 {
+		object["duration"] = isoDuration(meta.Duration)
+	}
+	if len(meta.Tags) > 0 {
+		object["keywords"] = strings.Join(meta.Tags, ", ")
+	}
+
+	encoded, _ := json.Marshal(object)
+	return encoded
+}
+
+// isoDuration formats seconds as an ISO 8601 duration, e.g. PT1H2M3S
+func isoDuration(seconds int) string {
+	d := "PT"
+	if h := seconds / 3600; h > 0 {
+		d += strconv.Itoa(h) + "H"
+	}
+	if m := seconds % 3600 / 60; m > 0 {
+		d += strconv.Itoa(m) + "M"
+	}
+	if s := seconds % 60; s > 0 || d == "PT" {
+		d += strconv.Itoa(s) + "S"
+	}
+	return d
+}
+
+// absoluteURL resolves a site-relative thumbnail path against the public URL;
+// previews cannot load relative images
+func absoluteURL(publicURL, ref string) string {
+	if strings.HasPrefix(ref, "/") && !strings.HasPrefix(ref, "//") {
+		return publicURL + ref
+	}
+	return ref
+}
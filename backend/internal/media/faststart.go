@@ -0,0 +1,218 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrFaststartUnsupported is returned for MP4s whose moov cannot be relocated
+// (compressed or fragmented movies, or 32-bit chunk offsets that would overflow)
+var ErrFaststartUnsupported = errors.New("media: moov cannot be relocated")
+
+// IsFaststart reports whether an MP4/MOV file has its moov box before the
+// media data, so playback can start without fetching the end of the file
+func IsFaststart(r io.ReaderAt, size int64) (bool, error) {
+	boxes, err := topLevelBoxes(r, size)
+	if err != nil {
+		return false, err
+	}
+
+	for _, b := range boxes {
+		switch b.typ {
+		case "moov":
+			return true, nil
+		case "mdat", "moof":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%w: no %q box", ErrMalformed, "moov")
+}
+
+// WriteFaststart writes a copy of an MP4/MOV file to w with the moov box moved
+// in front of the media data and chunk offsets adjusted. Media data is streamed,
+// only the moov box is held in memory. The output has the same size as the input.
+func WriteFaststart(w io.Writer, r io.ReaderAt, size int64) error {
+	boxes, err := topLevelBoxes(r, size)
+	if err != nil {
+		return err
+	}
+
+	moovIndex, firstMdat := -1, -1
+	for i, b := range boxes {
+		switch b.typ {
+		case "moov":
+			moovIndex = i
+		case "mdat":
+			if firstMdat < 0 {
+				firstMdat = i
+			}
+		case "moof":
+			return fmt.Errorf("%w: fragmented movie", ErrFaststartUnsupported)
+		}
+	}
+	if moovIndex < 0 {
+		return fmt.Errorf("%w: no %q box", ErrMalformed, "moov")
+	}
+
+	// New layout: everything before the first mdat, moov, then the rest
+	order := make([]box, 0, len(boxes))
+	for i, b := range boxes {
+		if i != moovIndex && (firstMdat < 0 || i < firstMdat) {
+			order = append(order, b)
+		}
+	}
+	order = append(order, boxes[moovIndex])
+	for i, b := range boxes {
+		if i != moovIndex && firstMdat >= 0 && i >= firstMdat {
+			order = append(order, b)
+		}
+	}
+
+	newOffsets := make(map[int64]int64, len(order)) // Original box offset -> new offset
+	var position int64
+	for _, b := range order {
+		newOffsets[b.offset] = position
+		position += b.size
+	}
+
+	moov := boxes[moovIndex]
+	if moov.size > maxBoxBytes {
+		return fmt.Errorf("%w: box %q too large", ErrMalformed, moov.typ)
+	}
+	moovData := make([]byte, moov.size)
+	if _, err := r.ReadAt(moovData, moov.offset); err != nil {
+		return err
+	}
+	// A size of 0 means "to the end of the file", which stops being true once moved
+	if binary.BigEndian.Uint32(moovData[0:4]) == 0 {
+		if moov.size > math.MaxUint32 {
+			return fmt.Errorf("%w: box %q too large", ErrMalformed, moov.typ)
+		}
+		binary.BigEndian.PutUint32(moovData[0:4], uint32(moov.size))
+	}
+
+	relocate := func(offset uint64) (uint64, error) {
+		for _, b := range boxes {
+			if int64(offset) >= b.offset && int64(offset) < b.offset+b.size {
+				return uint64(newOffsets[b.offset] + int64(offset) - b.offset), nil
+			}
+		}
+		return 0, fmt.Errorf("%w: chunk offset %d outside the file", ErrMalformed, offset)
+	}
+	if err := patchChunkOffsets(moovData, relocate); err != nil {
+		return err
+	}
+
+	for _, b := range order {
+		if b.offset == moov.offset {
+			if _, err := w.Write(moovData); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(r, b.offset, b.size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// topLevelBoxes lists the top-level boxes of an MP4/MOV file
+func topLevelBoxes(r io.ReaderAt, size int64) ([]box, error) {
+	first, err := readBox(r, 0, size)
+	if err != nil || !topLevelTypes[first.typ] {
+		return nil, ErrUnsupportedFormat
+	}
+
+	var boxes []box
+	for offset := int64(0); offset < size; {
+		b, err := readBox(r, offset, size)
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, b)
+		offset += b.size
+	}
+	return boxes, nil
+}
+
+// patchChunkOffsets rewrites every stco/co64 entry of an in-memory moov box
+func patchChunkOffsets(moov []byte, relocate func(uint64) (uint64, error)) error {
+	r := bytes.NewReader(moov)
+	root, err := readBox(r, 0, int64(len(moov)))
+	if err != nil {
+		return err
+	}
+	if _, err := findBox(r, root.payloadOffset(), root.size, "cmov"); err == nil {
+		return fmt.Errorf("%w: compressed movie", ErrFaststartUnsupported)
+	}
+
+	traks, err := childBoxes(r, root, "trak")
+	if err != nil {
+		return err
+	}
+	for _, trak := range traks {
+		stbl, err := findPath(r, trak, "mdia", "minf", "stbl")
+		if err != nil {
+			continue // Tracks without a sample table carry no media data
+		}
+
+		for _, typ := range []string{"stco", "co64"} {
+			tables, err := childBoxes(r, stbl, typ)
+			if err != nil {
+				return err
+			}
+			for _, table := range tables {
+				payload := moov[table.payloadOffset() : table.offset+table.size]
+				if err := patchOffsetTable(payload, typ == "co64", relocate); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// patchOffsetTable rewrites one stco (32-bit) or co64 (64-bit) payload in place
+func patchOffsetTable(payload []byte, wide bool, relocate func(uint64) (uint64, error)) error {
+	// version(1) flags(3) entry_count(4), then the offsets
+	if len(payload) < 8 {
+		return fmt.Errorf("%w: short chunk offset table", ErrMalformed)
+	}
+	count := int(binary.BigEndian.Uint32(payload[4:8]))
+
+	entrySize := 4
+	if wide {
+		entrySize = 8
+	}
+	if count > (len(payload)-8)/entrySize {
+		return fmt.Errorf("%w: chunk offset table overflows its box", ErrMalformed)
+	}
+
+	for i := 0; i < count; i++ {
+		entry := payload[8+i*entrySize : 8+(i+1)*entrySize]
+
+		if wide {
+			offset, err := relocate(binary.BigEndian.Uint64(entry))
+			if err != nil {
+				return err
+			}
+			binary.BigEndian.PutUint64(entry, offset)
+			continue
+		}
+
+		offset, err := relocate(uint64(binary.BigEndian.Uint32(entry)))
+		if err != nil {
+			return err
+		}
+		if offset > math.MaxUint32 {
+			return fmt.Errorf("%w: chunk offset exceeds 32 bits", ErrFaststartUnsupported)
+		}
+		binary.BigEndian.PutUint32(entry, uint32(offset))
+	}
+	return nil
+}
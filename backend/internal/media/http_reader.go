@@ -0,0 +1,100 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// readAheadBytes is the minimum range fetched per request; probing reads many
+// small headers that usually sit close together
+const readAheadBytes = 64 << 10
+
+// HTTPReaderAt reads a remote file with HTTP range requests
+type HTTPReaderAt struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+	size   int64
+
+	mu       sync.Mutex
+	bufStart int64
+	buf      []byte
+}
+
+// NewHTTPReaderAt returns a ReaderAt over url, whose total size must be known
+func NewHTTPReaderAt(ctx context.Context, client *http.Client, url string, size int64) *HTTPReaderAt {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPReaderAt{ctx: ctx, client: client, url: url, size: size}
+}
+
+// Size returns the remote file size
+func (h *HTTPReaderAt) Size() int64 {
+	return h.size
+}
+
+// ReadAt implements io.ReaderAt
+func (h *HTTPReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("media: negative offset %d", off)
+	}
+	if off >= h.size {
+		return 0, io.EOF
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Serve from the read-ahead buffer when possible
+	if off >= h.bufStart && off+int64(len(p)) <= h.bufStart+int64(len(h.buf)) {
+		return copy(p, h.buf[off-h.bufStart:]), nil
+	}
+
+	length := int64(len(p))
+	if length < readAheadBytes {
+		length = readAheadBytes
+	}
+	if off+length > h.size {
+		length = h.size - off
+	}
+
+	data, err := h.fetch(off, length)
+	if err != nil {
+		return 0, err
+	}
+	h.bufStart, h.buf = off, data
+
+	n := copy(p, data)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (h *HTTPReaderAt) fetch(off, length int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+length-1))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("media: range request returned %s", resp.Status)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,391 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// EBML element IDs used by WebM/Matroska probing
+const (
+	ebmlHeaderID    = 0x1A45DFA3
+	ebmlDocTypeID   = 0x4282
+	segmentID       = 0x18538067
+	seekHeadID      = 0x114D9B74
+	seekID          = 0x4DBB
+	seekIDID        = 0x53AB
+	seekPositionID  = 0x53AC
+	segmentInfoID   = 0x1549A966
+	timecodeScaleID = 0x2AD7B1
+	durationID      = 0x4489
+	tracksID        = 0x1654AE6B
+	trackEntryID    = 0xAE
+	trackTypeID     = 0x83
+	codecIDID       = 0x86
+	videoID         = 0xE0
+	pixelWidthID    = 0xB0
+	pixelHeightID   = 0xBA
+	clusterID       = 0x1F43B675
+)
+
+// Matroska track types
+const (
+	trackTypeVideo = 1
+	trackTypeAudio = 2
+)
+
+// matroskaCodecs maps CodecID values to codec names
+var matroskaCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_AV1":            "av1",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_MPEG/L3":        "mp3",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_FLAC":           "flac",
+}
+
+// element is an EBML element; size is -1 when unknown (live streams)
+type element struct {
+	id         uint32
+	offset     int64
+	dataOffset int64
+	size       int64
+}
+
+// end returns the end of the element, bounded by limit for unknown sizes
+func (e element) end(limit int64) int64 {
+	if e.size < 0 {
+		return limit
+	}
+	return e.dataOffset + e.size
+}
+
+// isMatroska checks for the EBML header magic
+func isMatroska(r io.ReaderAt, size int64) bool {
+	var magic [4]byte
+	if size < 4 {
+		return false
+	}
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return false
+	}
+	return binary.BigEndian.Uint32(magic[:]) == ebmlHeaderID
+}
+
+// probeMatroska reads duration and track metadata from a WebM/Matroska file
+func probeMatroska(r io.ReaderAt, size int64) (*Info, error) {
+	header, err := readElement(r, 0, size)
+	if err != nil || header.id != ebmlHeaderID {
+		return nil, ErrUnsupportedFormat
+	}
+
+	info := &Info{Container: "matroska"}
+	headerData, err := readElementData(r, header, size)
+	if err != nil {
+		return nil, err
+	}
+	if docType, ok := findElement(headerData, ebmlDocTypeID); ok && string(docType) == "webm" {
+		info.Container = "webm"
+	}
+
+	segment, err := readElement(r, header.end(size), size)
+	if err != nil {
+		return nil, err
+	}
+	if segment.id != segmentID {
+		return nil, fmt.Errorf("%w: no Segment element", ErrMalformed)
+	}
+
+	segmentInfo, tracks, err := findSegmentChildren(r, segment, size)
+	if err != nil {
+		return nil, err
+	}
+	if segmentInfo == nil || tracks == nil {
+		return nil, fmt.Errorf("%w: missing Info or Tracks element", ErrMalformed)
+	}
+
+	parseSegmentInfo(segmentInfo, info)
+	if err := parseTracks(tracks, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// findSegmentChildren loads the Info and Tracks elements of a segment. They
+// normally precede the first Cluster; otherwise the SeekHead says where they are.
+func findSegmentChildren(r io.ReaderAt, segment element, size int64) (segmentInfo, tracks []byte, err error) {
+	segmentEnd := segment.end(size)
+	seeks := map[uint32]int64{}
+
+	load := func(e element) ([]byte, error) {
+		return readElementData(r, e, segmentEnd)
+	}
+
+	for offset := segment.dataOffset; offset < segmentEnd && (segmentInfo == nil || tracks == nil); {
+		e, err := readElement(r, offset, segmentEnd)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch e.id {
+		case segmentInfoID:
+			if segmentInfo, err = load(e); err != nil {
+				return nil, nil, err
+			}
+		case tracksID:
+			if tracks, err = load(e); err != nil {
+				return nil, nil, err
+			}
+		case seekHeadID:
+			data, err := load(e)
+			if err != nil {
+				return nil, nil, err
+			}
+			parseSeekHead(data, seeks)
+		}
+
+		// Media data starts: jump to whatever is still missing instead of scanning clusters
+		if e.id == clusterID || e.size < 0 {
+			break
+		}
+		offset = e.end(segmentEnd)
+	}
+
+	for _, missing := range []struct {
+		id   uint32
+		data *[]byte
+	}{{segmentInfoID, &segmentInfo}, {tracksID, &tracks}} {
+		position, ok := seeks[missing.id]
+		if *missing.data != nil || !ok {
+			continue
+		}
+		e, err := readElement(r, segment.dataOffset+position, segmentEnd)
+		if err != nil || e.id != missing.id {
+			continue
+		}
+		if *missing.data, err = load(e); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return segmentInfo, tracks, nil
+}
+
+// parseSeekHead collects element positions (relative to the segment data)
+func parseSeekHead(data []byte, seeks map[uint32]int64) {
+	r := bytes.NewReader(data)
+	size := int64(len(data))
+	for offset := int64(0); offset < size; {
+		e, err := readElement(r, offset, size)
+		if err != nil || e.size < 0 {
+			return
+		}
+		if e.id == seekID {
+			entry := data[e.dataOffset:e.end(size)]
+			id, okID := findElement(entry, seekIDID)
+			position, okPos := findElement(entry, seekPositionID)
+			if okID && okPos {
+				seeks[uint32(readUint(id))] = int64(readUint(position))
+			}
+		}
+		offset = e.end(size)
+	}
+}
+
+// parseSegmentInfo reads the duration from the Segment Info element
+func parseSegmentInfo(data []byte, info *Info) {
+	scale := uint64(1000000) // Default TimecodeScale: 1ms in nanoseconds
+	if raw, ok := findElement(data, timecodeScaleID); ok {
+		if v := readUint(raw); v > 0 {
+			scale = v
+		}
+	}
+
+	// Recordings without seeking (e.g. MediaRecorder) may have no Duration
+	if raw, ok := findElement(data, durationID); ok {
+		ticks := readFloat(raw)
+		info.Duration = time.Duration(ticks * float64(scale))
+	}
+}
+
+// parseTracks reads the first video and audio track
+func parseTracks(data []byte, info *Info) error {
+	r := bytes.NewReader(data)
+	size := int64(len(data))
+
+	for offset := int64(0); offset < size; {
+		e, err := readElement(r, offset, size)
+		if err != nil {
+			return err
+		}
+		if e.size < 0 {
+			return fmt.Errorf("%w: track entry of unknown size", ErrMalformed)
+		}
+
+		if e.id == trackEntryID {
+			entry := data[e.dataOffset:e.end(size)]
+			trackType, _ := findElement(entry, trackTypeID)
+			codecID, _ := findElement(entry, codecIDID)
+
+			switch readUint(trackType) {
+			case trackTypeVideo:
+				if info.VideoCodec == "" {
+					info.VideoCodec = matroskaCodec(string(codecID))
+					if video, ok := findElement(entry, videoID); ok {
+						width, _ := findElement(video, pixelWidthID)
+						height, _ := findElement(video, pixelHeightID)
+						info.Width = int(readUint(width))
+						info.Height = int(readUint(height))
+					}
+				}
+			case trackTypeAudio:
+				if info.AudioCodec == "" {
+					info.AudioCodec = matroskaCodec(string(codecID))
+				}
+			}
+		}
+		offset = e.end(size)
+	}
+
+	return nil
+}
+
+// matroskaCodec maps a CodecID to a codec name, e.g. "A_AAC/MPEG4/LC" to "aac"
+func matroskaCodec(codecID string) string {
+	codecID = strings.TrimRight(codecID, "\x00")
+	if name, ok := matroskaCodecs[codecID]; ok {
+		return name
+	}
+	if strings.HasPrefix(codecID, "A_AAC") {
+		return "aac"
+	}
+	if i := strings.IndexByte(codecID, '_'); i >= 0 {
+		codecID = codecID[i+1:]
+	}
+	return strings.ToLower(codecID)
+}
+
+// findElement returns the data of the first direct child with the given ID
+func findElement(data []byte, id uint32) ([]byte, bool) {
+	r := bytes.NewReader(data)
+	size := int64(len(data))
+	for offset := int64(0); offset < size; {
+		e, err := readElement(r, offset, size)
+		if err != nil || e.size < 0 {
+			return nil, false
+		}
+		if e.id == id {
+			return data[e.dataOffset:e.end(size)], true
+		}
+		offset = e.end(size)
+	}
+	return nil, false
+}
+
+// readElement reads the element header at offset; end bounds the enclosing element
+func readElement(r io.ReaderAt, offset, end int64) (element, error) {
+	id, idLen, err := readVint(r, offset, end, true)
+	if err != nil {
+		return element{}, err
+	}
+	size, sizeLen, err := readVint(r, offset+int64(idLen), end, false)
+	if err != nil {
+		return element{}, err
+	}
+
+	e := element{
+		id:         uint32(id),
+		offset:     offset,
+		dataOffset: offset + int64(idLen+sizeLen),
+		size:       int64(size),
+	}
+
+	// All value bits set means unknown size
+	if size == 1<<(7*uint(sizeLen))-1 {
+		e.size = -1
+	} else if size > math.MaxInt64 || e.dataOffset+e.size > end {
+		return element{}, fmt.Errorf("%w: element %x has invalid size %d", ErrMalformed, e.id, size)
+	}
+	return e, nil
+}
+
+// readElementData loads an element's data into memory
+func readElementData(r io.ReaderAt, e element, limit int64) ([]byte, error) {
+	size := e.end(limit) - e.dataOffset
+	if size > maxBoxBytes {
+		return nil, fmt.Errorf("%w: element %x too large", ErrMalformed, e.id)
+	}
+	buf := make([]byte, size)
+	if _, err := r.ReadAt(buf, e.dataOffset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readVint reads an EBML variable-length integer. IDs keep their length marker bit.
+func readVint(r io.ReaderAt, offset, end int64, keepMarker bool) (uint64, int, error) {
+	var buf [8]byte
+	if offset >= end {
+		return 0, 0, fmt.Errorf("%w: truncated element header", ErrMalformed)
+	}
+	if _, err := r.ReadAt(buf[:1], offset); err != nil {
+		return 0, 0, err
+	}
+
+	length := 1
+	for mask := byte(0x80); length <= 8 && buf[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || (keepMarker && length > 4) {
+		return 0, 0, fmt.Errorf("%w: invalid variable-length integer", ErrMalformed)
+	}
+	if offset+int64(length) > end {
+		return 0, 0, fmt.Errorf("%w: truncated element header", ErrMalformed)
+	}
+	if length > 1 {
+		if _, err := r.ReadAt(buf[1:length], offset+1); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	value := uint64(buf[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	for _, b := range buf[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, nil
+}
+
+// readUint decodes a big-endian unsigned integer element
+func readUint(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// readFloat decodes a 4 or 8 byte float element
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	default:
+		return 0
+	}
+}
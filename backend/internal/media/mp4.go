@@ -0,0 +1,293 @@
+// Package media reads technical metadata (duration, dimensions, codecs) from
+// MP4/MOV and WebM/Matroska files without decoding them. Readers only need
+// random access (io.ReaderAt), so files stored on pCloud can be probed over
+// HTTP range requests.
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedFormat is returned for containers the prober cannot parse
+var ErrUnsupportedFormat = errors.New("media: unsupported container format")
+
+// ErrMalformed is returned when a container is truncated or inconsistent
+var ErrMalformed = errors.New("media: malformed file")
+
+// maxBoxBytes caps how much of a single metadata box is read into memory
+const maxBoxBytes = 64 << 20
+
+// box is an ISO-BMFF (MP4/MOV) box
+type box struct {
+	typ        string
+	offset     int64 // Start of the box header
+	size       int64 // Header plus payload
+	headerSize int64
+}
+
+func (b box) payloadOffset() int64 { return b.offset + b.headerSize }
+func (b box) payloadSize() int64   { return b.size - b.headerSize }
+
+// topLevelTypes are box types a QuickTime/MP4 file may start with
+var topLevelTypes = map[string]bool{
+	"ftyp": true, "moov": true, "mdat": true, "free": true,
+	"skip": true, "wide": true, "pnot": true, "uuid": true,
+}
+
+// mp4Codecs maps sample entry types to codec names
+var mp4Codecs = map[string]string{
+	"avc1": "h264", "avc3": "h264",
+	"hvc1": "hevc", "hev1": "hevc",
+	"av01": "av1", "vp08": "vp8", "vp09": "vp9",
+	"mp4v": "mpeg4", "apcn": "prores", "apch": "prores", "apcs": "prores", "apco": "prores", "ap4h": "prores",
+	"mp4a": "aac", ".mp3": "mp3", "ac-3": "ac3", "ec-3": "eac3",
+	"Opus": "opus", "fLaC": "flac", "alac": "alac",
+	"sowt": "pcm", "twos": "pcm", "lpcm": "pcm", "ipcm": "pcm",
+}
+
+// mp4Track is what probing needs from one trak box
+type mp4Track struct {
+	handler string // vide, soun, ...
+	codec   string
+	width   int
+	height  int
+}
+
+// probeMP4 reads duration and track metadata from an MP4/MOV file
+func probeMP4(r io.ReaderAt, size int64) (*Info, error) {
+	first, err := readBox(r, 0, size)
+	if err != nil || !topLevelTypes[first.typ] {
+		return nil, ErrUnsupportedFormat
+	}
+
+	info := &Info{Container: "mp4"}
+	if first.typ == "ftyp" {
+		if brand, err := readPayload(r, first); err == nil && len(brand) >= 4 && string(brand[:4]) == "qt  " {
+			info.Container = "mov"
+		}
+	} else {
+		info.Container = "mov" // Only old QuickTime files lack ftyp
+	}
+
+	moov, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return nil, err
+	}
+	mvhd, err := findBox(r, moov.payloadOffset(), moov.offset+moov.size, "mvhd")
+	if err != nil {
+		return nil, err
+	}
+
+	timescale, units, err := parseMVHD(r, mvhd)
+	if err != nil {
+		return nil, err
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("%w: zero timescale", ErrMalformed)
+	}
+	info.Duration = time.Duration(float64(units) / float64(timescale) * float64(time.Second))
+
+	traks, err := childBoxes(r, moov, "trak")
+	if err != nil {
+		return nil, err
+	}
+	for _, trak := range traks {
+		track, err := parseTrak(r, trak)
+		if err != nil {
+			continue // A broken auxiliary track does not make the file unplayable
+		}
+
+		switch {
+		case track.handler == "vide" && info.VideoCodec == "":
+			info.VideoCodec = track.codec
+			info.Width, info.Height = track.width, track.height
+		case track.handler == "soun" && info.AudioCodec == "":
+			info.AudioCodec = track.codec
+		}
+	}
+
+	return info, nil
+}
+
+// parseTrak reads the handler, codec and dimensions of a track
+func parseTrak(r io.ReaderAt, trak box) (mp4Track, error) {
+	var track mp4Track
+
+	mdia, err := findBox(r, trak.payloadOffset(), trak.offset+trak.size, "mdia")
+	if err != nil {
+		return track, err
+	}
+	hdlr, err := findBox(r, mdia.payloadOffset(), mdia.offset+mdia.size, "hdlr")
+	if err != nil {
+		return track, err
+	}
+	buf, err := readPayload(r, hdlr)
+	if err != nil {
+		return track, err
+	}
+	// version(1) flags(3) pre_defined(4) handler_type(4)
+	if len(buf) < 12 {
+		return track, fmt.Errorf("%w: short hdlr", ErrMalformed)
+	}
+	track.handler = string(buf[8:12])
+
+	stsd, err := findPath(r, mdia, "minf", "stbl", "stsd")
+	if err != nil {
+		return track, err
+	}
+	// version(1) flags(3) entry_count(4), then the first sample entry box
+	entry, err := readBox(r, stsd.payloadOffset()+8, stsd.offset+stsd.size)
+	if err != nil {
+		return track, err
+	}
+	track.codec = codecName(entry.typ)
+
+	if track.handler == "vide" {
+		// SampleEntry reserved(6) data_reference_index(2), VisualSampleEntry
+		// pre_defined(2) reserved(2) pre_defined(12) width(2) height(2)
+		var dims [4]byte
+		if entry.payloadSize() >= 28 {
+			if _, err := r.ReadAt(dims[:], entry.payloadOffset()+24); err != nil {
+				return track, err
+			}
+			track.width = int(binary.BigEndian.Uint16(dims[0:2]))
+			track.height = int(binary.BigEndian.Uint16(dims[2:4]))
+		}
+	}
+
+	return track, nil
+}
+
+// codecName maps a sample entry type to a codec name, falling back to the type itself
+func codecName(typ string) string {
+	if name, ok := mp4Codecs[typ]; ok {
+		return name
+	}
+	return strings.TrimSpace(typ)
+}
+
+// childBoxes returns the direct children of parent with type typ
+func childBoxes(r io.ReaderAt, parent box, typ string) ([]box, error) {
+	var boxes []box
+	end := parent.offset + parent.size
+	for offset := parent.payloadOffset(); offset < end; {
+		b, err := readBox(r, offset, end)
+		if err != nil {
+			return nil, err
+		}
+		if b.typ == typ {
+			boxes = append(boxes, b)
+		}
+		offset += b.size
+	}
+	return boxes, nil
+}
+
+// findPath descends through nested boxes, e.g. findPath(r, mdia, "minf", "stbl")
+func findPath(r io.ReaderAt, parent box, path ...string) (box, error) {
+	current := parent
+	for _, typ := range path {
+		next, err := findBox(r, current.payloadOffset(), current.offset+current.size, typ)
+		if err != nil {
+			return box{}, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// readBox reads the box header at offset; end bounds the enclosing container
+func readBox(r io.ReaderAt, offset, end int64) (box, error) {
+	var hdr [16]byte
+	if end-offset < 8 {
+		return box{}, fmt.Errorf("%w: truncated box header", ErrMalformed)
+	}
+	if _, err := r.ReadAt(hdr[:8], offset); err != nil {
+		return box{}, err
+	}
+
+	b := box{
+		typ:        string(hdr[4:8]),
+		offset:     offset,
+		size:       int64(binary.BigEndian.Uint32(hdr[0:4])),
+		headerSize: 8,
+	}
+
+	switch b.size {
+	case 0: // Box extends to the end of its container
+		b.size = end - offset
+	case 1: // 64-bit size follows the type
+		if end-offset < 16 {
+			return box{}, fmt.Errorf("%w: truncated box header", ErrMalformed)
+		}
+		if _, err := r.ReadAt(hdr[8:16], offset+8); err != nil {
+			return box{}, err
+		}
+		b.size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+		b.headerSize = 16
+	}
+
+	if b.size < b.headerSize || offset+b.size > end {
+		return box{}, fmt.Errorf("%w: box %q has invalid size %d", ErrMalformed, b.typ, b.size)
+	}
+	return b, nil
+}
+
+// findBox scans sibling boxes in [start, end) for the first box of type typ
+func findBox(r io.ReaderAt, start, end int64, typ string) (box, error) {
+	for offset := start; offset < end; {
+		b, err := readBox(r, offset, end)
+		if err != nil {
+			return box{}, err
+		}
+		if b.typ == typ {
+			return b, nil
+		}
+		offset += b.size
+	}
+	return box{}, fmt.Errorf("%w: no %q box", ErrMalformed, typ)
+}
+
+// readPayload loads a (small) box payload into memory
+func readPayload(r io.ReaderAt, b box) ([]byte, error) {
+	if b.payloadSize() > maxBoxBytes {
+		return nil, fmt.Errorf("%w: box %q too large", ErrMalformed, b.typ)
+	}
+	buf := make([]byte, b.payloadSize())
+	if _, err := r.ReadAt(buf, b.payloadOffset()); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// parseMVHD returns the movie timescale and duration in timescale units
+func parseMVHD(r io.ReaderAt, b box) (uint32, uint64, error) {
+	buf, err := readPayload(r, b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(buf) < 1 {
+		return 0, 0, fmt.Errorf("%w: empty mvhd", ErrMalformed)
+	}
+
+	// version(1) flags(3), then creation/modification times, timescale, duration
+	switch buf[0] {
+	case 0:
+		if len(buf) < 20 {
+			return 0, 0, fmt.Errorf("%w: short mvhd", ErrMalformed)
+		}
+		return binary.BigEndian.Uint32(buf[12:16]), uint64(binary.BigEndian.Uint32(buf[16:20])), nil
+	case 1:
+		if len(buf) < 32 {
+			return 0, 0, fmt.Errorf("%w: short mvhd", ErrMalformed)
+		}
+		return binary.BigEndian.Uint32(buf[20:24]), binary.BigEndian.Uint64(buf[24:32]), nil
+	default:
+		return 0, 0, fmt.Errorf("%w: unknown mvhd version %d", ErrMalformed, buf[0])
+	}
+}
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNoVideoTrack is returned by Validate for files without a playable video track
+var ErrNoVideoTrack = errors.New("media: no playable video track")
+
+// Info is the technical metadata of a video file
+type Info struct {
+	Container  string        `json:"container"` // mp4, mov, webm or matroska
+	Duration   time.Duration `json:"duration"`
+	Width      int           `json:"width,omitempty"`
+	Height     int           `json:"height,omitempty"`
+	VideoCodec string        `json:"video_codec,omitempty"` // e.g. h264, hevc, vp9, av1
+	AudioCodec string        `json:"audio_codec,omitempty"` // e.g. aac, opus; empty when silent
+	Bitrate    int64         `json:"bitrate"`               // Overall bits per second
+}
+
+// DurationSeconds returns the duration rounded to whole seconds
+func (i *Info) DurationSeconds() int {
+	return int(i.Duration.Round(time.Second) / time.Second)
+}
+
+// BitrateKbps returns the overall bitrate in kilobits per second
+func (i *Info) BitrateKbps() int {
+	return int(i.Bitrate / 1000)
+}
+
+// Validate checks that the file has a video track with known dimensions
+func (i *Info) Validate() error {
+	if i.VideoCodec == "" || i.Width <= 0 || i.Height <= 0 {
+		return ErrNoVideoTrack
+	}
+	return nil
+}
+
+// Probe reads container headers of an MP4/MOV or WebM/Matroska file.
+// Only metadata is read, so probing a remote file costs a few range requests.
+func Probe(r io.ReaderAt, size int64) (*Info, error) {
+	var (
+		info *Info
+		err  error
+	)
+
+	switch {
+	case isMatroska(r, size):
+		info, err = probeMatroska(r, size)
+	default:
+		info, err = probeMP4(r, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if seconds := info.Duration.Seconds(); seconds > 0 {
+		info.Bitrate = int64(float64(size) * 8 / seconds)
+	}
+	return info, nil
+}
+
+// Duration returns the playback duration of an MP4/MOV or WebM/Matroska file
+func Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	info, err := Probe(r, size)
+	if err != nil {
+		return 0, err
+	}
+	return info.Duration, nil
+}
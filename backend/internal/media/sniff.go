@@ -0,0 +1,197 @@
+package media
+
+import (
+	"bytes"
+	"io"
+)
+
+// SniffLen is how many leading bytes Sniff looks at (tar headers end at 262)
+const SniffLen = 512
+
+// Kind is the broad class of a sniffed file
+type Kind string
+
+const (
+	KindVideo      Kind = "video"
+	KindAudio      Kind = "audio"
+	KindImage      Kind = "image"
+	KindArchive    Kind = "archive"
+	KindExecutable Kind = "executable"
+	KindDocument   Kind = "document"
+	KindUnknown    Kind = "unknown"
+)
+
+// Signature is the file type recognised from leading magic bytes
+type Signature struct {
+	Kind Kind   `json:"kind"`
+	MIME string `json:"mime,omitempty"`
+}
+
+// magic is a fixed byte pattern at an offset
+type magic struct {
+	offset  int
+	pattern string
+	sig     Signature
+}
+
+// magics lists non-ISO-BMFF signatures; more specific patterns come first
+var magics = []magic{
+	// Video containers
+	{0, "FLV\x01", Signature{KindVideo, "video/x-flv"}},
+	{0, "\x30\x26\xB2\x75\x8E\x66\xCF\x11", Signature{KindVideo, "video/x-ms-asf"}},
+	{0, "\x00\x00\x01\xBA", Signature{KindVideo, "video/mpeg"}},
+	{0, "\x00\x00\x01\xB3", Signature{KindVideo, "video/mpeg"}},
+
+	// Executables and scripts
+	{0, "MZ", Signature{KindExecutable, "application/vnd.microsoft.portable-executable"}},
+	{0, "\x7FELF", Signature{KindExecutable, "application/x-elf"}},
+	{0, "\xFE\xED\xFA\xCE", Signature{KindExecutable, "application/x-mach-binary"}},
+	{0, "\xFE\xED\xFA\xCF", Signature{KindExecutable, "application/x-mach-binary"}},
+	{0, "\xCE\xFA\xED\xFE", Signature{KindExecutable, "application/x-mach-binary"}},
+	{0, "\xCF\xFA\xED\xFE", Signature{KindExecutable, "application/x-mach-binary"}},
+	{0, "\xCA\xFE\xBA\xBE", Signature{KindExecutable, "application/x-mach-binary"}}, // Also Java classes
+	{0, "\x00asm", Signature{KindExecutable, "application/wasm"}},
+	{0, "#!", Signature{KindExecutable, "text/x-shellscript"}},
+
+	// Archives
+	{0, "PK\x03\x04", Signature{KindArchive, "application/zip"}},
+	{0, "PK\x05\x06", Signature{KindArchive, "application/zip"}},
+	{0, "Rar!\x1A\x07", Signature{KindArchive, "application/vnd.rar"}},
+	{0, "7z\xBC\xAF\x27\x1C", Signature{KindArchive, "application/x-7z-compressed"}},
+	{0, "\x1F\x8B", Signature{KindArchive, "application/gzip"}},
+	{0, "BZh", Signature{KindArchive, "application/x-bzip2"}},
+	{0, "\xFD7zXZ\x00", Signature{KindArchive, "application/x-xz"}},
+	{0, "\x28\xB5\x2F\xFD", Signature{KindArchive, "application/zstd"}},
+	{0, "MSCF", Signature{KindArchive, "application/vnd.ms-cab-compressed"}},
+	{257, "ustar", Signature{KindArchive, "application/x-tar"}},
+
+	// Images
+	{0, "\xFF\xD8\xFF", Signature{KindImage, "image/jpeg"}},
+	{0, "\x89PNG\r\n\x1A\n", Signature{KindImage, "image/png"}},
+	{0, "GIF87a", Signature{KindImage, "image/gif"}},
+	{0, "GIF89a", Signature{KindImage, "image/gif"}},
+	{0, "II*\x00", Signature{KindImage, "image/tiff"}},
+	{0, "MM\x00*", Signature{KindImage, "image/tiff"}},
+	{0, "\x00\x00\x01\x00", Signature{KindImage, "image/x-icon"}},
+	{0, "BM", Signature{KindImage, "image/bmp"}},
+
+	// Documents and audio
+	{0, "%PDF-", Signature{KindDocument, "application/pdf"}},
+	{0, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1", Signature{KindDocument, "application/x-ole-storage"}},
+	{0, "{\\rtf", Signature{KindDocument, "application/rtf"}},
+	{0, "ID3", Signature{KindAudio, "audio/mpeg"}},
+	{0, "fLaC", Signature{KindAudio, "audio/flac"}},
+	{0, "OggS", Signature{KindAudio, "audio/ogg"}}, // Ogg video is rare; treated as audio
+}
+
+// riffTypes maps the RIFF form type to a signature
+var riffTypes = map[string]Signature{
+	"AVI ": {KindVideo, "video/x-msvideo"},
+	"WEBP": {KindImage, "image/webp"},
+	"WAVE": {KindAudio, "audio/wav"},
+}
+
+// ftypBrands maps ISO-BMFF major brands that are not plain MP4 video
+var ftypBrands = map[string]Signature{
+	"qt  ": {KindVideo, "video/quicktime"},
+	"3gp4": {KindVideo, "video/3gpp"},
+	"3gp5": {KindVideo, "video/3gpp"},
+	"3gp6": {KindVideo, "video/3gpp"},
+	"3g2a": {KindVideo, "video/3gpp2"},
+	"M4A ": {KindAudio, "audio/mp4"},
+	"M4B ": {KindAudio, "audio/mp4"},
+	"avif": {KindImage, "image/avif"},
+	"avis": {KindImage, "image/avif"},
+	"heic": {KindImage, "image/heic"},
+	"heix": {KindImage, "image/heic"},
+	"mif1": {KindImage, "image/heif"},
+	"msf1": {KindImage, "image/heif"},
+	"crx ": {KindImage, "image/x-canon-cr3"},
+}
+
+// Sniff identifies a file from its leading bytes. Only the signature is
+// checked; use Probe to confirm a video container is actually playable.
+func Sniff(r io.ReaderAt, size int64) Signature {
+	n := int64(SniffLen)
+	if size < n {
+		n = size
+	}
+	header := make([]byte, n)
+	if n > 0 {
+		if read, err := r.ReadAt(header, 0); err != nil && read < len(header) {
+			header = header[:read]
+		}
+	}
+	return SniffBytes(header)
+}
+
+// SniffBytes identifies a file from its first bytes (up to SniffLen)
+func SniffBytes(header []byte) Signature {
+	if sig, ok := sniffISOBMFF(header); ok {
+		return sig
+	}
+	if len(header) >= 4 && string(header[:4]) == "\x1A\x45\xDF\xA3" {
+		if bytes.Contains(header[:min(len(header), 64)], []byte("webm")) {
+			return Signature{KindVideo, "video/webm"}
+		}
+		return Signature{KindVideo, "video/x-matroska"}
+	}
+	if len(header) >= 12 && string(header[:4]) == "RIFF" {
+		if sig, ok := riffTypes[string(header[8:12])]; ok {
+			return sig
+		}
+	}
+	// MPEG transport stream: sync byte every 188 bytes
+	if len(header) > 188 && header[0] == 0x47 && header[188] == 0x47 {
+		return Signature{KindVideo, "video/mp2t"}
+	}
+
+	for _, m := range magics {
+		end := m.offset + len(m.pattern)
+		if len(header) >= end && string(header[m.offset:end]) == m.pattern {
+			return m.sig
+		}
+	}
+
+	if looksLikeMarkup(header) {
+		return Signature{KindDocument, "text/html"}
+	}
+	return Signature{Kind: KindUnknown}
+}
+
+// sniffISOBMFF recognises MP4/MOV family files by their first box
+func sniffISOBMFF(header []byte) (Signature, bool) {
+	if len(header) < 8 {
+		return Signature{}, false
+	}
+
+	switch typ := string(header[4:8]); {
+	case typ == "ftyp" && len(header) >= 12:
+		if sig, ok := ftypBrands[string(header[8:12])]; ok {
+			return sig, true
+		}
+		if string(header[8:11]) == "3gp" {
+			return Signature{KindVideo, "video/3gpp"}, true
+		}
+		return Signature{KindVideo, "video/mp4"}, true
+	case typ == "moov" || typ == "mdat" || typ == "wide" || typ == "free" || typ == "skip" || typ == "pnot":
+		// Old QuickTime files start without ftyp
+		return Signature{KindVideo, "video/quicktime"}, true
+	}
+	return Signature{}, false
+}
+
+// looksLikeMarkup detects HTML/XML/SVG, e.g. an error page saved as .mp4
+func looksLikeMarkup(header []byte) bool {
+	trimmed := bytes.TrimLeft(header, " \t\r\n\xEF\xBB\xBF")
+	if len(trimmed) == 0 || trimmed[0] != '<' {
+		return false
+	}
+	lower := bytes.ToLower(trimmed[:min(len(trimmed), 16)])
+	for _, prefix := range []string{"<!doctype", "<html", "<?xml", "<svg", "<head", "<body", "<script"} {
+		if bytes.HasPrefix(lower, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
@@ -35,6 +35,28 @@ func AuthRequired() fiber.Handler {
 		c.Locals("user_email", claims.Email)
 		c.Locals("user_role", claims.Role)
 
+		return c.Next()
+	}
+}
+
+// OptionalAuth stores the user of a valid bearer token like AuthRequired, but
+// lets requests without one (or with an invalid one) through anonymously
+func OptionalAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parts := strings.Split(c.Get("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return c.Next()
+		}
+
+		claims, err := utils.ValidateAccessToken(parts[1])
+		if err != nil {
+			return c.Next()
+		}
+
+		c.Locals("user_id", claims.UserID)
+		c.Locals("user_email", claims.Email)
+		c.Locals("user_role", claims.Role)
+
 		return c.Next()
 	}
 }
\ No newline at end of file
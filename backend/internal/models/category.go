@@ -1,16 +1,23 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Category is a node in the category tree. Path lists the IDs from the root
+// down to the category itself, separated by "/"; names and slugs are unique
+// among siblings.
 type Category struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Name         string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
-	Slug         string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"slug"`
+	ParentID     *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"`
+	Path         string         `gorm:"type:text;not null" json:"path"`
+	Depth        int            `gorm:"not null;default:0" json:"depth"`
+	Name         string         `gorm:"type:varchar(100);not null" json:"name"`
+	Slug         string         `gorm:"type:varchar(100);not null" json:"slug"`
 	Description  string         `gorm:"type:text" json:"description,omitempty"`
 	Icon         string         `gorm:"type:varchar(50)" json:"icon,omitempty"`
 	IsActive     bool           `gorm:"default:true;index" json:"is_active"`
@@ -31,5 +38,34 @@ func (c *Category) BeforeCreate(tx *gorm.DB) error {
 	if c.ID == uuid.Nil {
 		c.ID = uuid.New()
 	}
+	if c.Path == "" {
+		c.Path, c.Depth = c.ID.String(), 0
+		if c.ParentID != nil {
+			var parent Category
+			err := tx.Session(&gorm.Session{NewDB: true}).
+				Select("path", "depth").
+				First(&parent, "id = ?", *c.ParentID).Error
+			if err != nil {
+				return err
+			}
+			c.Path, c.Depth = parent.ChildPath(c.ID), parent.Depth+1
+		}
+	}
 	return nil
+}
+
+// ChildPath is the path of a child category with the given ID
+func (c *Category) ChildPath(id uuid.UUID) string {
+	return c.Path + "/" + id.String()
+}
+
+// AncestorIDs gets the IDs on the path from the root down to the category, itself included
+func (c *Category) AncestorIDs() []uuid.UUID {
+	var ids []uuid.UUID
+	for _, part := range strings.Split(c.Path, "/") {
+		if id, err := uuid.Parse(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusPending     ImportStatus = "pending"
+	ImportStatusDownloading ImportStatus = "downloading" // Streaming from the remote host into pCloud
+	ImportStatusProcessing  ImportStatus = "processing"  // Creating the video and wrapper link
+	ImportStatusCompleted   ImportStatus = "completed"
+	ImportStatusFailed      ImportStatus = "failed"
+)
+
+// ImportJob is a server-side import of a video from a remote URL
+type ImportJob struct {
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	SourceURL       string         `gorm:"type:text;not null" json:"source_url"`
+	Status          ImportStatus   `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Title           string         `gorm:"type:varchar(500);not null" json:"title"`
+	Description     string         `gorm:"type:text" json:"description"`
+	ThumbnailURL    string         `gorm:"type:text" json:"thumbnail_url"`
+	CategoryID      *uuid.UUID     `gorm:"type:uuid" json:"category_id,omitempty"`
+	Tags            pq.StringArray `gorm:"type:text[]" json:"tags"`
+	DurationSeconds int            `json:"duration_seconds"`
+	Force           bool           `gorm:"default:false" json:"force"` // Import even if duplicate
+	BytesTotal      int64          `gorm:"default:0" json:"bytes_total"`
+	BytesDone       int64          `gorm:"default:0" json:"bytes_done"`
+	Error           string         `gorm:"type:text" json:"error,omitempty"`
+	VideoID         *uuid.UUID     `gorm:"type:uuid" json:"video_id,omitempty"`
+	JobID           *uuid.UUID     `gorm:"type:uuid" json:"job_id,omitempty"` // Queue job that runs the import
+	CreatedBy       *uuid.UUID     `gorm:"type:uuid" json:"created_by,omitempty"`
+	StartedAt       *time.Time     `json:"started_at,omitempty"`
+	CompletedAt     *time.Time     `json:"completed_at,omitempty"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+func (j *ImportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// Progress returns the completed fraction in percent, or -1 if the size is unknown
+func (j *ImportJob) Progress() float64 {
+	if j.BytesTotal <= 0 {
+		return -1
+	}
+	return float64(j.BytesDone) / float64(j.BytesTotal) * 100
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued" // Waiting for run_at, including retries in backoff
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusDead      JobStatus = "dead" // Out of attempts or failed permanently; retry by hand
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// JobType selects the handler that runs a job
+type JobType string
+
+const (
+	JobTypeVideoUpload JobType = "video_upload"
+	JobTypeVideoImport JobType = "video_import"
+)
+
+// Job is a unit of background work in the Postgres job queue
+type Job struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Type        JobType         `gorm:"type:varchar(50);not null;index" json:"type"`
+	Status      JobStatus       `gorm:"type:varchar(20);not null;default:'queued';index" json:"status"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Result      json.RawMessage `gorm:"type:jsonb" json:"result,omitempty"`
+	Attempts    int             `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int             `gorm:"not null" json:"max_attempts"`
+	RunAt       time.Time       `gorm:"not null" json:"run_at"` // Earliest time a worker may claim the job
+	LockedBy    string          `gorm:"type:varchar(100)" json:"locked_by,omitempty"`
+	HeartbeatAt *time.Time      `json:"heartbeat_at,omitempty"` // Refreshed by the worker while running
+	LastError   string          `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedBy   *uuid.UUID      `gorm:"type:uuid" json:"created_by,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// Finished reports whether the job will not run again without an admin retry
+func (j *Job) Finished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusDead || j.Status == JobStatusCancelled
+}
@@ -4,9 +4,19 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+type HealthStatus string
+
+const (
+	HealthStatusUnknown   HealthStatus = "unknown"
+	HealthStatusHealthy   HealthStatus = "healthy"
+	HealthStatusDegraded  HealthStatus = "degraded"  // Working, but slow or nearly full
+	HealthStatusUnhealthy HealthStatus = "unhealthy" // Auth, quota or API failure
+)
+
 type PCloudCredential struct {
 	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
 	AccountName     string         `gorm:"type:varchar(100);not null" json:"account_name"`
@@ -16,7 +26,18 @@ type PCloudCredential struct {
 	TokenExpiresAt  *time.Time     `json:"token_expires_at,omitempty"`
 	StorageUsedGB   float64        `gorm:"type:decimal(10,2);default:0" json:"storage_used_gb"`
 	StorageLimitGB  float64        `gorm:"type:decimal(10,2);not null" json:"storage_limit_gb"`
+	Region          string         `gorm:"type:varchar(8);not null;default:'us'" json:"region"` // API host: us or eu
 	IsActive        bool           `gorm:"default:true;index" json:"is_active"`
+	HealthStatus    HealthStatus   `gorm:"type:varchar(20);not null;default:'unknown'" json:"health_status"`
+	HealthError     string         `gorm:"type:text" json:"health_error,omitempty"`
+	HealthLatencyMS *int           `json:"health_latency_ms,omitempty"`
+	HealthCheckedAt *time.Time     `json:"health_checked_at,omitempty"`
+	HealthFailures  int            `gorm:"default:0" json:"health_failures"`            // Consecutive failed checks
+	HealthSuccesses int            `gorm:"default:0" json:"health_successes"`           // Consecutive passed checks
+	QuarantinedAt   *time.Time     `gorm:"index" json:"quarantined_at,omitempty"`       // Out of upload rotation while set
+	SelectionWeight int            `gorm:"not null;default:1" json:"selection_weight"`  // Share of uploads under the weighted strategy
+	PinnedTags      pq.StringArray `gorm:"type:text[];default:'{}'" json:"pinned_tags"` // Category slugs or tags reserved for this account
+	LastSelectedAt  *time.Time     `json:"last_selected_at,omitempty"`                  // Last upload (round robin)
 	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -29,6 +50,11 @@ func (PCloudCredential) TableName() string {
 	return "pcloud_credentials"
 }
 
+// IsQuarantined reports whether health checks removed the account from upload rotation
+func (p *PCloudCredential) IsQuarantined() bool {
+	return p.QuarantinedAt != nil
+}
+
 func (p *PCloudCredential) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PCloudHealthCheck is one health probe of a pCloud account
+type PCloudHealthCheck struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CredentialID   uuid.UUID    `gorm:"type:uuid;not null;index" json:"credential_id"`
+	Status         HealthStatus `gorm:"type:varchar(20);not null" json:"status"`
+	LatencyMS      int          `gorm:"default:0" json:"latency_ms"`
+	QuotaBytes     *int64       `json:"quota_bytes,omitempty"`
+	UsedQuotaBytes *int64       `json:"used_quota_bytes,omitempty"`
+	ErrorCode      *int         `json:"error_code,omitempty"` // pCloud result code
+	Error          string       `gorm:"type:text" json:"error,omitempty"`
+	CheckedAt      time.Time    `gorm:"autoCreateTime" json:"checked_at"`
+}
+
+func (PCloudHealthCheck) TableName() string {
+	return "pcloud_health_checks"
+}
+
+func (h *PCloudHealthCheck) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
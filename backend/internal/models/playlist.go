@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Playlist kinds: a series numbers its items as episodes
+const (
+	PlaylistKindPlaylist = "playlist"
+	PlaylistKindSeries   = "series"
+)
+
+// Playlist visibilities: unlisted playlists open by link but are not listed,
+// private ones are visible to admins only
+const (
+	PlaylistPublic   = "public"
+	PlaylistUnlisted = "unlisted"
+	PlaylistPrivate  = "private"
+)
+
+// Playlist is an ordered group of videos, e.g. the episodes of a series
+type Playlist struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
+	Slug        string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	CoverURL    string         `gorm:"type:text" json:"cover_url,omitempty"`
+	Kind        string         `gorm:"type:varchar(20);not null;default:playlist" json:"kind"`
+	Visibility  string         `gorm:"type:varchar(20);not null;default:public" json:"visibility"`
+	ItemCount   int64          `gorm:"-" json:"item_count"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Items []PlaylistItem `gorm:"foreignKey:PlaylistID" json:"items,omitempty"`
+}
+
+func (Playlist) TableName() string {
+	return "playlists"
+}
+
+func (p *Playlist) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// PlaylistItem places a video in a playlist; positions start at 0
+type PlaylistItem struct {
+	PlaylistID uuid.UUID `gorm:"type:uuid;primary_key" json:"playlist_id"`
+	VideoID    uuid.UUID `gorm:"type:uuid;primary_key" json:"video_id"`
+	Position   int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Video *Video `gorm:"foreignKey:VideoID" json:"video,omitempty"`
+}
+
+func (PlaylistItem) TableName() string {
+	return "playlist_items"
+}
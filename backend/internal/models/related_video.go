@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RelatedVideo is a precomputed related video; Rank 1 is the most related
+type RelatedVideo struct {
+	VideoID        uuid.UUID `gorm:"type:uuid;primary_key" json:"video_id"`
+	RelatedVideoID uuid.UUID `gorm:"type:uuid;primary_key" json:"related_video_id"`
+	Rank           int       `gorm:"not null" json:"rank"`
+	Score          float64   `gorm:"not null" json:"score"`
+	ComputedAt     time.Time `gorm:"autoCreateTime" json:"computed_at"`
+
+	// Relationships
+	RelatedVideo *Video `gorm:"foreignKey:RelatedVideoID" json:"video,omitempty"`
+}
+
+func (RelatedVideo) TableName() string {
+	return "related_videos"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SearchQuery counts how often a normalized search query was submitted
+type SearchQuery struct {
+	Query          string    `gorm:"type:varchar(100);primary_key" json:"query"`
+	SearchCount    int       `gorm:"not null;default:1" json:"search_count"`
+	ResultCount    int       `gorm:"not null;default:0" json:"result_count"`
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
+
+func (SearchQuery) TableName() string {
+	return "search_queries"
+}
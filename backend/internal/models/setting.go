@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Setting is a runtime setting changed by admins, overriding its config default
+type Setting struct {
+	Key       string    `gorm:"type:varchar(100);primary_key" json:"key"`
+	Value     string    `gorm:"type:text;not null" json:"value"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Setting) TableName() string {
+	return "settings"
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tag is a canonical video tag; Video.Tags holds a copy of the names for display and search
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Slug      string    `gorm:"type:varchar(120);uniqueIndex;not null" json:"slug"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Aliases []TagAlias `gorm:"foreignKey:TagID" json:"aliases,omitempty"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// TagAlias maps another spelling's slug (e.g. a translation or a tag's old
+// slug) to a canonical tag
+type TagAlias struct {
+	Slug      string    `gorm:"type:varchar(120);primary_key" json:"slug"`
+	TagID     uuid.UUID `gorm:"type:uuid;not null;index" json:"tag_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (TagAlias) TableName() string {
+	return "tag_aliases"
+}
+
+// VideoTag links a video to a tag; Position keeps the order the tags were given in
+type VideoTag struct {
+	VideoID  uuid.UUID `gorm:"type:uuid;primary_key" json:"video_id"`
+	TagID    uuid.UUID `gorm:"type:uuid;primary_key" json:"tag_id"`
+	Position int       `gorm:"not null;default:0" json:"position"`
+}
+
+func (VideoTag) TableName() string {
+	return "video_tags"
+}
@@ -17,6 +17,17 @@ type Video struct {
 	SourceURLExpiresAt  *time.Time     `json:"-"`
 	DurationSeconds     int            `json:"duration_seconds"`
 	FileSizeMB          float64        `gorm:"type:decimal(10,2)" json:"file_size_mb"`
+	FileSizeBytes       int64          `json:"file_size_bytes,omitempty"`
+	Container           string         `gorm:"type:varchar(16)" json:"container,omitempty"`
+	Width               int            `json:"width,omitempty"`
+	Height              int            `json:"height,omitempty"`
+	VideoCodec          string         `gorm:"type:varchar(32)" json:"video_codec,omitempty"`
+	AudioCodec          string         `gorm:"type:varchar(32)" json:"audio_codec,omitempty"`
+	BitrateKbps         int            `json:"bitrate_kbps,omitempty"`
+	StreamingOptimized  *bool          `json:"streaming_optimized"` // Playback starts without fetching the file end; nil until checked
+	StreamingError      *string        `gorm:"type:text" json:"-"`  // Why optimizing failed; not retried while set
+	ContentHash         string         `gorm:"type:varchar(64);index" json:"content_hash,omitempty"`
+	ContentSHA256       string         `gorm:"column:content_sha256;type:varchar(64);index" json:"content_sha256,omitempty"`
 	PCloudFileID        string         `gorm:"type:varchar(255)" json:"-"`
 	PCloudCredentialID  uuid.UUID      `gorm:"type:uuid;not null" json:"-"`
 	CategoryID          *uuid.UUID     `gorm:"type:uuid" json:"category_id,omitempty"`
@@ -27,6 +38,15 @@ type Video struct {
 	CreatedAt           time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt           time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	PublishedAt         *time.Time     `json:"published_at"`
+	Version             int            `gorm:"<-:create;default:1" json:"version"` // Bumped by each metadata edit; Save leaves it alone
+	LinkRefreshFailures int            `gorm:"default:0" json:"-"` // Consecutive failed link refreshes
+	LinkRefreshError    string         `gorm:"type:text" json:"-"`
+	LinkRefreshFailedAt *time.Time     `json:"-"`
+	LinkRefreshRetryAt  *time.Time     `json:"-"` // Backoff: not refreshed again before this
+	PurgeFailures       int            `gorm:"default:0" json:"-"` // Consecutive failed purges from trash
+	PurgeError          string         `gorm:"type:text" json:"-"`
+	PurgeFailedAt       *time.Time     `json:"-"`
+	PurgeRetryAt        *time.Time     `json:"-"` // Backoff: not purged again before this
 	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
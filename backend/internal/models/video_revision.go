@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VideoFieldChange is one metadata field changed by a revision, with the
+// values before and after as JSON
+type VideoFieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// VideoRevision records a metadata edit of a video. Version is the video
+// version the edit produced; RevertedTo is set when the edit was a revert.
+type VideoRevision struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	VideoID    uuid.UUID       `gorm:"type:uuid;not null" json:"video_id"`
+	Version    int             `gorm:"not null" json:"version"`
+	EditorID   *uuid.UUID      `gorm:"type:uuid" json:"editor_id,omitempty"`
+	Changes    json.RawMessage `gorm:"type:jsonb;not null" json:"changes"` // []VideoFieldChange
+	RevertedTo *int            `json:"reverted_to,omitempty"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Editor *User `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+}
+
+func (VideoRevision) TableName() string {
+	return "video_revisions"
+}
+
+func (r *VideoRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
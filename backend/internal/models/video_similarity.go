@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VideoSimilarity is how alike two videos are by their viewers, from 0 to 1
+type VideoSimilarity struct {
+	VideoID        uuid.UUID `gorm:"type:uuid;primary_key" json:"video_id"`
+	SimilarVideoID uuid.UUID `gorm:"type:uuid;primary_key" json:"similar_video_id"`
+	Score          float64   `gorm:"not null" json:"score"`
+	Viewers        int       `gorm:"not null" json:"viewers"` // Viewers of both videos
+	ComputedAt     time.Time `gorm:"autoCreateTime" json:"computed_at"`
+}
+
+func (VideoSimilarity) TableName() string {
+	return "video_similarities"
+}
@@ -30,4 +30,32 @@ func (w *WrapperLink) BeforeCreate(tx *gorm.DB) error {
 		w.WrapperToken = uuid.New().String()
 	}
 	return nil
+}
+
+// WatchPath is the public watch page path, by slug when the link has one
+func (w *WrapperLink) WatchPath() string {
+	return "/watch/" + w.pathRef()
+}
+
+// EmbedPath is the path of the bare player embedded by link previews
+func (w *WrapperLink) EmbedPath() string {
+	return "/embed/" + w.pathRef()
+}
+
+func (w *WrapperLink) pathRef() string {
+	if w.Slug != "" {
+		return w.Slug
+	}
+	return w.WrapperToken
+}
+
+// WrapperLinkSlug is a previous slug of a wrapper link, kept so old watch URLs redirect
+type WrapperLinkSlug struct {
+	Slug          string    `gorm:"type:varchar(500);primary_key" json:"slug"`
+	WrapperLinkID uuid.UUID `gorm:"type:uuid;not null;index" json:"wrapper_link_id"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (WrapperLinkSlug) TableName() string {
+	return "wrapper_link_slugs"
 }
\ No newline at end of file
@@ -0,0 +1,358 @@
+package pcloud
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the client settings
+type Config struct {
+	USBaseURL      string        // API host for US accounts, e.g. https://api.pcloud.com
+	EUBaseURL      string        // API host for EU accounts, e.g. https://eapi.pcloud.com
+	Timeout        time.Duration // Per-attempt timeout for regular API calls
+	UploadTimeout  time.Duration // Per-attempt timeout for uploads
+	MaxAttempts    int           // Total attempts per call, including the first
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	HTTPClient     *http.Client // Optional; defaults to a client on the shared transport
+}
+
+// Client is a typed pCloud API client. It is safe for concurrent use.
+//
+// Every parameter, including the auth token, is sent in the request body so
+// tokens never show up in URLs, access logs or wrapped *url.Error messages.
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+	retry      retryPolicy
+}
+
+// NewClient creates a pCloud client on the shared transport
+func NewClient(cfg Config) *Client {
+	if cfg.USBaseURL == "" {
+		cfg.USBaseURL = "https://api.pcloud.com"
+	}
+	if cfg.EUBaseURL == "" {
+		cfg.EUBaseURL = "https://eapi.pcloud.com"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.UploadTimeout <= 0 {
+		cfg.UploadTimeout = 30 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = 500 * time.Millisecond
+	}
+	if cfg.RetryMaxDelay <= 0 {
+		cfg.RetryMaxDelay = 10 * time.Second
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: sharedTransport}
+	}
+
+	return &Client{
+		cfg:        cfg,
+		httpClient: httpClient,
+		retry: retryPolicy{
+			maxAttempts: cfg.MaxAttempts,
+			baseDelay:   cfg.RetryBaseDelay,
+			maxDelay:    cfg.RetryMaxDelay,
+		},
+	}
+}
+
+// baseURL picks the API host for an account's region
+func (c *Client) baseURL(auth Auth) string {
+	if auth.Region == RegionEU {
+		return strings.TrimRight(c.cfg.EUBaseURL, "/")
+	}
+	return strings.TrimRight(c.cfg.USBaseURL, "/")
+}
+
+// result is the envelope shared by every pCloud response
+type result struct {
+	Result int    `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (r result) err(method string) error {
+	if r.Result == 0 {
+		return nil
+	}
+	return &APIError{Method: method, Code: r.Result, Message: r.Error}
+}
+
+// call performs a form-encoded API call with retries and decodes the response into out
+func (c *Client) call(ctx context.Context, auth Auth, method string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("auth", auth.Token)
+	body := params.Encode()
+
+	return c.retry.run(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL(auth)+"/"+method, strings.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return c.do(req, method, out)
+	})
+}
+
+// do sends one request and decodes the JSON envelope
+func (c *Client) do(req *http.Request, method string, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		apiErr := &APIError{Method: method, HTTPStatus: resp.StatusCode}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return &attemptError{err: err, retryable: true}
+	}
+
+	var envelope result
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("pcloud: %s returned invalid JSON: %w", method, err)
+	}
+	if err := envelope.err(method); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("pcloud: %s returned unexpected JSON: %w", method, err)
+	}
+	return nil
+}
+
+// UploadFile streams body into folderID as filename.
+//
+// The upload is retried only when body implements io.Seeker, since a plain
+// reader cannot be replayed.
+func (c *Client) UploadFile(ctx context.Context, auth Auth, folderID int64, filename string, body io.Reader, opts UploadOptions) (*UploadedFile, error) {
+	seeker, canRetry := body.(io.Seeker)
+
+	policy := c.retry
+	if !canRetry {
+		policy.maxAttempts = 1
+	}
+
+	var uploaded *UploadedFile
+	attempt := 0
+	err := policy.run(ctx, func(ctx context.Context) error {
+		attempt++
+		if attempt > 1 {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+
+		file, err := c.uploadOnce(ctx, auth, folderID, filename, body, opts)
+		if err != nil {
+			return err
+		}
+		uploaded = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uploaded, nil
+}
+
+// uploadOnce performs a single streaming multipart upload
+func (c *Client) uploadOnce(ctx context.Context, auth Auth, folderID int64, filename string, body io.Reader, opts UploadOptions) (*UploadedFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.UploadTimeout)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	hasher := sha256.New()
+
+	// ✅ Stream the body through a pipe so large files never sit in memory
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := func() error {
+			// Parameters must precede the file part
+			if err := writer.WriteField("auth", auth.Token); err != nil {
+				return err
+			}
+			if err := writer.WriteField("folderid", strconv.FormatInt(folderID, 10)); err != nil {
+				return err
+			}
+			if err := writer.WriteField("nopartial", "1"); err != nil {
+				return err
+			}
+
+			part, err := writer.CreateFormFile("file", filename)
+			if err != nil {
+				return err
+			}
+
+			src := io.TeeReader(body, hasher)
+			if opts.OnProgress != nil {
+				src = &progressReader{r: src, onProgress: opts.OnProgress}
+			}
+			if _, err := io.Copy(part, src); err != nil {
+				return err
+			}
+			return writer.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL(auth)+"/uploadfile", pr)
+	if err != nil {
+		pr.CloseWithError(err)
+		<-done
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var resp struct {
+		Metadata []Metadata `json:"metadata"`
+	}
+	err = c.do(req, "uploadfile", &resp)
+
+	// Unblock and wait for the writer so body is no longer read when we return
+	pr.CloseWithError(errors.New("pcloud: upload finished"))
+	<-done
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Metadata) == 0 {
+		return nil, errors.New("pcloud: uploadfile returned no file metadata")
+	}
+
+	return &UploadedFile{
+		Metadata: resp.Metadata[0],
+		SHA256:   hex.EncodeToString(hasher.Sum(nil)),
+	}, nil
+}
+
+// GetFileLink gets a temporary direct download link for a file
+func (c *Client) GetFileLink(ctx context.Context, auth Auth, fileID int64) (*FileLink, error) {
+	var resp struct {
+		Hosts   []string `json:"hosts"`
+		Path    string   `json:"path"`
+		Expires string   `json:"expires"`
+	}
+
+	params := url.Values{"fileid": {strconv.FormatInt(fileID, 10)}}
+	if err := c.call(ctx, auth, "getfilelink", params, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Hosts) == 0 {
+		return nil, errors.New("pcloud: getfilelink returned no hosts")
+	}
+
+	// pCloud returns "Thu, 01 Jan 2026 00:00:00 +0000"
+	expiresAt, _ := time.Parse(time.RFC1123Z, resp.Expires)
+	if expiresAt.IsZero() {
+		// Default to 24 hours from now if parse fails
+		expiresAt = time.Now().Add(24 * time.Hour)
+	}
+
+	// Content hosts speak the same scheme as the API (plain HTTP only against a fake server)
+	scheme := "https"
+	if strings.HasPrefix(c.baseURL(auth), "http://") {
+		scheme = "http"
+	}
+
+	return &FileLink{
+		URL:       fmt.Sprintf("%s://%s%s", scheme, resp.Hosts[0], resp.Path),
+		Hosts:     resp.Hosts,
+		Path:      resp.Path,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ListFolder lists a folder by ID (0 is the root) or by opts.Path
+func (c *Client) ListFolder(ctx context.Context, auth Auth, folderID int64, opts ListFolderOptions) (*Metadata, error) {
+	params := url.Values{}
+	if opts.Path != "" {
+		params.Set("path", opts.Path)
+	} else {
+		params.Set("folderid", strconv.FormatInt(folderID, 10))
+	}
+	if opts.Recursive {
+		params.Set("recursive", "1")
+	}
+
+	var resp struct {
+		Metadata Metadata `json:"metadata"`
+	}
+	if err := c.call(ctx, auth, "listfolder", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
+
+// DeleteFile deletes a file
+func (c *Client) DeleteFile(ctx context.Context, auth Auth, fileID int64) error {
+	params := url.Values{"fileid": {strconv.FormatInt(fileID, 10)}}
+	return c.call(ctx, auth, "deletefile", params, nil)
+}
+
+// UserInfo gets the account details and quota behind a token
+func (c *Client) UserInfo(ctx context.Context, auth Auth) (*UserInfo, error) {
+	var info UserInfo
+	if err := c.call(ctx, auth, "userinfo", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// progressReader reports the running byte count as the body is read
+type progressReader struct {
+	r          io.Reader
+	sent       int64
+	onProgress func(sent int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.onProgress(p.sent)
+	}
+	return n, err
+}
@@ -0,0 +1,82 @@
+package pcloud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors matched with errors.Is against an *APIError
+var (
+	ErrAuthFailed    = errors.New("pcloud: authentication failed")
+	ErrQuotaExceeded = errors.New("pcloud: storage quota exceeded")
+	ErrRateLimited   = errors.New("pcloud: rate limited")
+	ErrNotFound      = errors.New("pcloud: file or folder not found")
+	ErrAccessDenied  = errors.New("pcloud: access denied")
+)
+
+// pCloud API result codes (https://docs.pcloud.com/errors/)
+const (
+	CodeLoginRequired      = 1000
+	CodeLoginFailed        = 2000
+	CodeInvalidPath        = 2002
+	CodeAccessDenied       = 2003
+	CodeFolderNotFound     = 2005
+	CodeOverQuota          = 2008
+	CodeFileNotFound       = 2009
+	CodeInvalidAccessToken = 2094
+	CodeTooManyLogins      = 4000
+	CodeInternalError      = 5000
+	CodeInternalUploadErr  = 5001
+)
+
+// APIError is a failed pCloud call, either a non-zero "result" in the JSON body
+// or a non-2xx HTTP status
+type APIError struct {
+	Method     string
+	Code       int
+	Message    string
+	HTTPStatus int
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("pcloud: %s failed with HTTP %d", e.Method, e.HTTPStatus)
+	}
+	return fmt.Sprintf("pcloud: %s failed (%d): %s", e.Method, e.Code, e.Message)
+}
+
+// Is maps pCloud result codes onto the package sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrAuthFailed:
+		return e.Code == CodeLoginRequired || e.Code == CodeLoginFailed || e.Code == CodeInvalidAccessToken ||
+			e.HTTPStatus == http.StatusUnauthorized
+	case ErrQuotaExceeded:
+		return e.Code == CodeOverQuota
+	case ErrRateLimited:
+		return e.Code == CodeTooManyLogins || e.HTTPStatus == http.StatusTooManyRequests
+	case ErrNotFound:
+		return e.Code == CodeInvalidPath || e.Code == CodeFolderNotFound || e.Code == CodeFileNotFound
+	case ErrAccessDenied:
+		return e.Code == CodeAccessDenied || e.HTTPStatus == http.StatusForbidden
+	}
+	return false
+}
+
+// Retryable reports whether repeating the same call may succeed
+func (e *APIError) Retryable() bool {
+	if errors.Is(e, ErrRateLimited) {
+		return true
+	}
+	if e.Code == CodeInternalError || e.Code == CodeInternalUploadErr {
+		return true
+	}
+	return e.Code == 0 && e.HTTPStatus >= 500
+}
+
+func (e *APIError) retryAfter() time.Duration {
+	return e.RetryAfter
+}
@@ -0,0 +1,84 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// retryPolicy is exponential backoff with full jitter
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// delay returns how long to wait before attempt n (1-based retry number)
+func (p retryPolicy) delay(n int) time.Duration {
+	backoff := p.baseDelay << uint(n-1)
+	if backoff <= 0 || backoff > p.maxDelay {
+		backoff = p.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryAfter lets the server push the next attempt further out
+type retryAfter interface {
+	retryAfter() time.Duration
+}
+
+// run calls fn until it succeeds, fails permanently, attempts run out or ctx ends
+func (p retryPolicy) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil || attempt >= p.maxAttempts || !isRetryable(ctx, err) {
+			return err
+		}
+
+		wait := p.delay(attempt)
+		var ra retryAfter
+		if errors.As(err, &ra) && ra.retryAfter() > wait {
+			wait = ra.retryAfter()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable classifies an attempt error
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var attemptErr *attemptError
+	return errors.As(err, &attemptErr) && attemptErr.retryable
+}
+
+// attemptError marks transport-level failures that are safe to repeat
+type attemptError struct {
+	err       error
+	retryable bool
+}
+
+func (e *attemptError) Error() string { return e.err.Error() }
+func (e *attemptError) Unwrap() error { return e.err }
@@ -0,0 +1,25 @@
+package pcloud
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// sharedTransport is reused by every Client so connections to the pCloud API
+// hosts are pooled across accounts and requests
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   20,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+	// pCloud answers an upload only after the whole body is stored
+	ResponseHeaderTimeout: 2 * time.Minute,
+}
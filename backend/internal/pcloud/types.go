@@ -0,0 +1,71 @@
+package pcloud
+
+import "time"
+
+// Regions a pCloud account can live in; each has its own API host
+const (
+	RegionUS = "us"
+	RegionEU = "eu"
+)
+
+// ValidRegion reports whether region is a known pCloud data region
+func ValidRegion(region string) bool {
+	return region == RegionUS || region == RegionEU
+}
+
+// Auth identifies the pCloud account a call is made for
+type Auth struct {
+	Token  string
+	Region string
+}
+
+// Metadata describes a file or folder
+type Metadata struct {
+	Name           string     `json:"name"`
+	Path           string     `json:"path,omitempty"`
+	IsFolder       bool       `json:"isfolder"`
+	FileID         int64      `json:"fileid,omitempty"`
+	FolderID       int64      `json:"folderid,omitempty"`
+	ParentFolderID int64      `json:"parentfolderid,omitempty"`
+	Size           int64      `json:"size,omitempty"`
+	Hash           uint64     `json:"hash,omitempty"`
+	ContentType    string     `json:"contenttype,omitempty"`
+	Created        string     `json:"created,omitempty"`
+	Modified       string     `json:"modified,omitempty"`
+	Contents       []Metadata `json:"contents,omitempty"`
+}
+
+// UploadedFile is the result of a successful upload
+type UploadedFile struct {
+	Metadata
+	SHA256 string // Hex SHA-256 of the bytes sent in the successful attempt
+}
+
+// FileLink is a temporary direct download link
+type FileLink struct {
+	URL       string
+	Hosts     []string
+	Path      string
+	ExpiresAt time.Time
+}
+
+// UserInfo describes the account behind a token
+type UserInfo struct {
+	Email     string `json:"email"`
+	UserID    int64  `json:"userid"`
+	Premium   bool   `json:"premium"`
+	Quota     int64  `json:"quota"`
+	UsedQuota int64  `json:"usedquota"`
+}
+
+// UploadOptions tunes a single upload
+type UploadOptions struct {
+	// OnProgress is called with the total bytes sent so far in the current attempt
+	OnProgress func(sent int64)
+}
+
+// ListFolderOptions tunes a folder listing
+type ListFolderOptions struct {
+	Path      string // List by path (e.g. "/Movies") instead of folder ID when set
+	Recursive bool   // Include the whole subtree in Contents
+}
@@ -2,11 +2,35 @@ package repositories
 
 import (
 	"bobastream/internal/models"
+	"errors"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrCategoryNoParent = errors.New("parent category not found")
+	ErrCategoryCycle    = errors.New("a category cannot be moved below itself")
+)
+
+// categoryMoveLock is the advisory lock key serializing category moves: a move
+// reads the paths another move may be rewriting
+const categoryMoveLock = 0x63617465676f7279 // "category"
+
+// CategoryMove gives a category a new parent, or makes it a root category when
+// ParentID is nil
+type CategoryMove struct {
+	ParentID *uuid.UUID
+}
+
+// CategoryCount is a category with the number of videos in it
+type CategoryCount struct {
+	models.Category
+	VideoCount int64 `json:"video_count"`
+}
+
 type CategoryRepository struct {
 	db *gorm.DB
 }
@@ -30,19 +54,47 @@ func (r *CategoryRepository) FindByID(id uuid.UUID) (*models.Category, error) {
 	return &category, nil
 }
 
-// FindBySlug finds category by slug
-func (r *CategoryRepository) FindBySlug(slug string) (*models.Category, error) {
+// FindBySlug finds a category by its slug among the children of parentID
+// (root categories when nil)
+func (r *CategoryRepository) FindBySlug(parentID *uuid.UUID, slug string) (*models.Category, error) {
 	var category models.Category
-	err := r.db.Where("slug = ?", slug).First(&category).Error
+	err := r.children(parentID).Where("slug = ?", slug).First(&category).Error
 	if err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
 
-// Update updates category
-func (r *CategoryRepository) Update(category *models.Category) error {
-	return r.db.Save(category).Error
+// FindByIDs finds categories by ID, shallowest first
+func (r *CategoryRepository) FindByIDs(ids []uuid.UUID) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Where("id IN ?", ids).Order("depth ASC").Find(&categories).Error
+	return categories, err
+}
+
+// children scopes a query to the children of parentID, or to root categories when nil
+func (r *CategoryRepository) children(parentID *uuid.UUID) *gorm.DB {
+	if parentID == nil {
+		return r.db.Model(&models.Category{}).Where("parent_id IS NULL")
+	}
+	return r.db.Model(&models.Category{}).Where("parent_id = ?", *parentID)
+}
+
+// Update saves the editable fields of a category (name, slug, description,
+// icon and display order) and, given a move, its new parent in the same
+// transaction. Its tree position is only ever changed by a move.
+func (r *CategoryRepository) Update(category *models.Category, move *CategoryMove) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if move != nil {
+			if err := moveCategory(tx, category, move.ParentID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Category{}).Where("id = ?", category.ID).
+			Select("name", "slug", "description", "icon", "display_order").
+			Updates(category).Error
+	})
 }
 
 // Delete soft deletes category
@@ -50,10 +102,10 @@ func (r *CategoryRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Category{}, "id = ?", id).Error
 }
 
-// GetAll gets all categories
+// GetAll gets all categories, siblings in display order
 func (r *CategoryRepository) GetAll() ([]models.Category, error) {
 	var categories []models.Category
-	err := r.db.Order("display_order ASC, name ASC").Find(&categories).Error
+	err := r.db.Order("depth ASC, display_order ASC, name ASC").Find(&categories).Error
 	return categories, err
 }
 
@@ -61,7 +113,7 @@ func (r *CategoryRepository) GetAll() ([]models.Category, error) {
 func (r *CategoryRepository) GetActive() ([]models.Category, error) {
 	var categories []models.Category
 	err := r.db.Where("is_active = ?", true).
-		Order("display_order ASC, name ASC").
+		Order("depth ASC, display_order ASC, name ASC").
 		Find(&categories).Error
 	return categories, err
 }
@@ -72,22 +124,225 @@ func (r *CategoryRepository) ToggleActive(id uuid.UUID, isActive bool) error {
 		Update("is_active", isActive).Error
 }
 
-// SlugExists checks if slug exists
-func (r *CategoryRepository) SlugExists(slug string) (bool, error) {
+// SlugExists checks if slug exists among the children of parentID
+func (r *CategoryRepository) SlugExists(parentID *uuid.UUID, slug string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Category{}).Where("slug = ?", slug).Count(&count).Error
+	err := r.children(parentID).Where("slug = ?", slug).Count(&count).Error
 	return count > 0, err
 }
 
-// NameExists checks if name exists
-func (r *CategoryRepository) NameExists(name string) (bool, error) {
+// NameExists checks if name exists among the children of parentID
+func (r *CategoryRepository) NameExists(parentID *uuid.UUID, name string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Category{}).Where("name = ?", name).Count(&count).Error
+	err := r.children(parentID).Where("name = ?", name).Count(&count).Error
 	return count > 0, err
 }
 
+// CountChildren counts the direct subcategories of a category
+func (r *CategoryRepository) CountChildren(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.children(&id).Count(&count).Error
+	return count, err
+}
+
+// SubtreeIDs gets the IDs of a category and its active descendants. A
+// descendant below an inactive category is left out with it.
+func (r *CategoryRepository) SubtreeIDs(category *models.Category) ([]uuid.UUID, error) {
+	var descendants []models.Category
+	err := r.db.Select("id", "path").
+		Where("path LIKE ?", category.Path+"/%").
+		Where("is_active = ?", true).
+		Find(&descendants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool, len(descendants))
+	for _, descendant := range descendants {
+		active[descendant.ID.String()] = true
+	}
+
+	// Every category between this one and the descendant must be active too
+	ids := []uuid.UUID{category.ID}
+	for _, descendant := range descendants {
+		visible := true
+		for _, id := range strings.Split(strings.TrimPrefix(descendant.Path, category.Path+"/"), "/") {
+			if !active[id] {
+				visible = false
+				break
+			}
+		}
+		if visible {
+			ids = append(ids, descendant.ID)
+		}
+	}
+	return ids, nil
+}
+
+// moveCategory gives a category a new parent (nil for root), rewriting the
+// paths and depths of its whole subtree. Moves are serialized and the category
+// and parent rows locked, so the cycle check runs on current paths.
+func moveCategory(tx *gorm.DB, category *models.Category, parentID *uuid.UUID) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", categoryMoveLock).Error; err != nil {
+		return err
+	}
+
+	ids := []uuid.UUID{category.ID}
+	if parentID != nil {
+		ids = append(ids, *parentID)
+	}
+	var locked []models.Category
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&locked).Error
+	if err != nil {
+		return err
+	}
+
+	var current, parent *models.Category
+	for i := range locked {
+		if locked[i].ID == category.ID {
+			current = &locked[i]
+		}
+		if parentID != nil && locked[i].ID == *parentID {
+			parent = &locked[i]
+		}
+	}
+	if current == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if parentID != nil && parent == nil {
+		return ErrCategoryNoParent
+	}
+
+	newPath, newDepth := current.ID.String(), 0
+	if parent != nil {
+		if parent.ID == current.ID || strings.HasPrefix(parent.Path, current.Path+"/") {
+			return ErrCategoryCycle
+		}
+		newPath, newDepth = parent.ChildPath(current.ID), parent.Depth+1
+	}
+
+	err = tx.Model(&models.Category{}).Where("id = ?", current.ID).
+		Update("parent_id", parentID).Error
+	if err != nil {
+		return err
+	}
+
+	// Deleted descendants move too, so their paths stay consistent
+	err = tx.Unscoped().Model(&models.Category{}).
+		Where("path = ? OR path LIKE ?", current.Path, current.Path+"/%").
+		UpdateColumns(map[string]interface{}{
+			"path":  gorm.Expr("? || substr(path, ?)", newPath, len(current.Path)+1),
+			"depth": gorm.Expr("depth + ?", newDepth-current.Depth),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	category.ParentID, category.Path, category.Depth = parentID, newPath, newDepth
+	return nil
+}
+
 // UpdateDisplayOrder updates display order
 func (r *CategoryRepository) UpdateDisplayOrder(id uuid.UUID, order int) error {
 	return r.db.Model(&models.Category{}).Where("id = ?", id).
 		Update("display_order", order).Error
+}
+
+// SuggestByName gets active categories whose name contains the lowercase
+// prefix, names starting with it first. Uses the trigram index on names.
+func (r *CategoryRepository) SuggestByName(prefix string, limit int) ([]models.Category, error) {
+	var categories []models.Category
+	name := "lower(video_search_unescape(name))"
+
+	err := r.db.Where("is_active = ?", true).
+		Where(name+" LIKE ?", "%"+escapeLike(prefix)+"%").
+		Clauses(startsWithFirst(name, prefix, "display_order ASC")).
+		Limit(limit).
+		Find(&categories).Error
+
+	return categories, err
+}
+
+// WithVideoCounts pairs categories with their number of non-trashed videos,
+// or only published ones
+func (r *CategoryRepository) WithVideoCounts(categories []models.Category, publishedOnly bool) ([]CategoryCount, error) {
+	var rows []struct {
+		CategoryID uuid.UUID
+		Count      int64
+	}
+	query := r.db.Model(&models.Video{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IS NOT NULL")
+	if publishedOnly {
+		query = query.Where("is_published = ?", true)
+	}
+	if err := query.Group("category_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+
+	result := make([]CategoryCount, len(categories))
+	for i, category := range categories {
+		result[i] = CategoryCount{Category: category, VideoCount: counts[category.ID]}
+	}
+	return result, nil
+}
+
+// CountVideos counts the videos in a category, including unpublished and trashed ones
+func (r *CategoryRepository) CountVideos(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Video{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// DeleteAndMoveVideos moves every video of a category (trashed ones too) to
+// the target category, or leaves them uncategorized when target is nil, then
+// soft deletes the category. Returns the number of videos moved.
+func (r *CategoryRepository) DeleteAndMoveVideos(id uuid.UUID, target *uuid.UUID) (int64, error) {
+	var categoryID interface{} // NULL unless a target is given
+	if target != nil {
+		categoryID = *target
+	}
+
+	var moved int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Video{}).
+			Where("category_id = ?", id).
+			UpdateColumn("category_id", categoryID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		return tx.Delete(&models.Category{}, "id = ?", id).Error
+	})
+	return moved, err
+}
+
+// Reorder sets the display order of categories to their index in ids
+func (r *CategoryRepository) Reorder(ids []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for order, id := range ids {
+			err := tx.Model(&models.Category{}).Where("id = ?", id).
+				Update("display_order", order).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CountExisting counts how many of the IDs belong to non-deleted categories
+func (r *CategoryRepository) CountExisting(ids []uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).Where("id IN ?", ids).Count(&count).Error
+	return count, err
 }
\ No newline at end of file
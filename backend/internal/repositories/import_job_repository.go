@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+// Create creates a new import job
+func (r *ImportJobRepository) Create(job *models.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// FindByID finds import job by ID
+func (r *ImportJobRepository) FindByID(id uuid.UUID) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.First(&job, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetAll gets import jobs with pagination, optionally filtered by status
+func (r *ImportJobRepository) GetAll(status string, page, limit int) ([]models.ImportJob, int64, error) {
+	var jobs []models.ImportJob
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.ImportJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+// SetJobID links the job to the queue job that runs it
+func (r *ImportJobRepository) SetJobID(id, jobID uuid.UUID) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Update("job_id", jobID).Error
+}
+
+// MarkStarted moves a job to downloading and records the expected size
+func (r *ImportJobRepository) MarkStarted(id uuid.UUID, bytesTotal int64) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.ImportStatusDownloading,
+			"bytes_total":  bytesTotal,
+			"bytes_done":   0,
+			"error":        "",
+			"started_at":   time.Now(),
+			"completed_at": nil, // Set by an earlier attempt retried by an admin
+		}).Error
+}
+
+// UpdateProgress records how many bytes have been sent to pCloud
+func (r *ImportJobRepository) UpdateProgress(id uuid.UUID, bytesDone int64) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Update("bytes_done", bytesDone).Error
+}
+
+// UpdateStatus sets job status
+func (r *ImportJobRepository) UpdateStatus(id uuid.UUID, status models.ImportStatus) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// MarkCompleted links the created video and finishes the job
+func (r *ImportJobRepository) MarkCompleted(id, videoID uuid.UUID, bytesDone int64) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.ImportStatusCompleted,
+			"video_id":     videoID,
+			"bytes_done":   bytesDone,
+			"error":        "",
+			"completed_at": time.Now(),
+		}).Error
+}
+
+// MarkRetrying puts a job back to pending after a failed attempt
+func (r *ImportJobRepository) MarkRetrying(id uuid.UUID, message string) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status": models.ImportStatusPending,
+			"error":  message,
+		}).Error
+}
+
+// MarkFailed finishes the job with an error message
+func (r *ImportJobRepository) MarkFailed(id uuid.UUID, message string) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.ImportStatusFailed,
+			"error":        message,
+			"completed_at": time.Now(),
+		}).Error
+}
+
+// FailUnfinished fails the job unless it already completed or failed
+func (r *ImportJobRepository) FailUnfinished(id uuid.UUID, message string) error {
+	return r.db.Model(&models.ImportJob{}).
+		Where("id = ? AND status IN ?", id, []models.ImportStatus{
+			models.ImportStatusPending,
+			models.ImportStatusDownloading,
+			models.ImportStatusProcessing,
+		}).
+		Updates(map[string]interface{}{
+			"status":       models.ImportStatusFailed,
+			"error":        message,
+			"completed_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Create creates a new job
+func (r *JobRepository) Create(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+// FindByID finds job by ID
+func (r *JobRepository) FindByID(id uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	err := r.db.First(&job, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetAll gets jobs with pagination, optionally filtered by status and type
+func (r *JobRepository) GetAll(status, jobType string, page, limit int) ([]models.Job, int64, error) {
+	var jobs []models.Job
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.Job{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+// CountByStatus counts jobs per status
+func (r *JobRepository) CountByStatus() (map[models.JobStatus]int64, error) {
+	var rows []struct {
+		Status models.JobStatus
+		Count  int64
+	}
+
+	err := r.db.Model(&models.Job{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.JobStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// Claim locks the oldest due job of the given types for a worker.
+// SKIP LOCKED lets concurrent workers claim different jobs without waiting.
+// Returns nil when no job is due.
+func (r *JobRepository) Claim(types []models.JobType, workerID string) (*models.Job, error) {
+	var job models.Job
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ? AND type IN ?", models.JobStatusQueued, time.Now(), types).
+			Order("run_at ASC").
+			Take(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedBy = workerID
+		job.HeartbeatAt = &now
+		job.StartedAt = &now
+
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"locked_by":    workerID,
+			"heartbeat_at": now,
+			"started_at":   now,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// running scopes an update to a job still held by the given worker
+func (r *JobRepository) running(id uuid.UUID, workerID string) *gorm.DB {
+	return r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, models.JobStatusRunning, workerID)
+}
+
+// Heartbeat marks a running job as alive. Returns false once the worker lost
+// the job (cancelled by an admin or requeued as stale).
+func (r *JobRepository) Heartbeat(id uuid.UUID, workerID string) (bool, error) {
+	result := r.running(id, workerID).Update("heartbeat_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkCompleted finishes a job with its result
+func (r *JobRepository) MarkCompleted(id uuid.UUID, workerID string, result []byte) (bool, error) {
+	update := r.running(id, workerID).Updates(map[string]interface{}{
+		"status":       models.JobStatusCompleted,
+		"result":       result,
+		"last_error":   "",
+		"locked_by":    nil,
+		"completed_at": time.Now(),
+	})
+	return update.RowsAffected > 0, update.Error
+}
+
+// MarkRetry puts a failed job back in the queue to run again at runAt
+func (r *JobRepository) MarkRetry(id uuid.UUID, workerID, message string, runAt time.Time) (bool, error) {
+	update := r.running(id, workerID).Updates(map[string]interface{}{
+		"status":     models.JobStatusQueued,
+		"last_error": message,
+		"locked_by":  nil,
+		"run_at":     runAt,
+	})
+	return update.RowsAffected > 0, update.Error
+}
+
+// MarkDead moves a failed job to the dead-letter state
+func (r *JobRepository) MarkDead(id uuid.UUID, workerID, message string) (bool, error) {
+	update := r.running(id, workerID).Updates(map[string]interface{}{
+		"status":       models.JobStatusDead,
+		"last_error":   message,
+		"locked_by":    nil,
+		"completed_at": time.Now(),
+	})
+	return update.RowsAffected > 0, update.Error
+}
+
+// Release returns an interrupted job to the queue without using up an attempt
+func (r *JobRepository) Release(id uuid.UUID, workerID string) (bool, error) {
+	update := r.running(id, workerID).Updates(map[string]interface{}{
+		"status":    models.JobStatusQueued,
+		"attempts":  gorm.Expr("GREATEST(attempts - 1, 0)"),
+		"locked_by": nil,
+		"run_at":    time.Now(),
+	})
+	return update.RowsAffected > 0, update.Error
+}
+
+// RequeueStale recovers running jobs whose worker stopped sending heartbeats.
+// Jobs out of attempts go to the dead-letter state instead.
+func (r *JobRepository) RequeueStale(heartbeatBefore time.Time, message string) (int64, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("status = ? AND heartbeat_at < ?", models.JobStatusRunning, heartbeatBefore).
+		Updates(map[string]interface{}{
+			"status": gorm.Expr("CASE WHEN attempts >= max_attempts THEN ? ELSE ? END",
+				models.JobStatusDead, models.JobStatusQueued),
+			"completed_at": gorm.Expr("CASE WHEN attempts >= max_attempts THEN CAST(? AS TIMESTAMP) END", time.Now()),
+			"last_error":   message,
+			"locked_by":    nil,
+			"run_at":       time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Cancel stops a queued or running job. Returns false if it had already finished.
+func (r *JobRepository) Cancel(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status IN ?", id, []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusCancelled,
+			"locked_by":    nil,
+			"completed_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Retry requeues a dead or cancelled job with a fresh set of attempts.
+// Returns false if the job is not in one of those states.
+func (r *JobRepository) Retry(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status IN ?", id, []models.JobStatus{models.JobStatusDead, models.JobStatusCancelled}).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusQueued,
+			"attempts":     0,
+			"run_at":       time.Now(),
+			"started_at":   nil,
+			"completed_at": nil,
+			"heartbeat_at": nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteFinishedBefore deletes up to limit finished jobs completed before the
+// cutoff and returns them, so their handlers can release leftover resources
+func (r *JobRepository) DeleteFinishedBefore(before time.Time, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+
+	err := r.db.Clauses(clause.Returning{}).
+		Where("id IN (?)", r.db.Model(&models.Job{}).
+			Select("id").
+			Where("status IN ? AND completed_at < ?", []models.JobStatus{
+				models.JobStatusCompleted,
+				models.JobStatusDead,
+				models.JobStatusCancelled,
+			}, before).
+			Limit(limit)).
+		Delete(&jobs).Error
+
+	return jobs, err
+}
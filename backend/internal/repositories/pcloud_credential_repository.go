@@ -30,9 +30,14 @@ func (r *PCloudCredentialRepository) FindByID(id uuid.UUID) (*models.PCloudCrede
 	return &credential, nil
 }
 
-// Update updates pCloud credential
-func (r *PCloudCredentialRepository) Update(credential *models.PCloudCredential) error {
-	return r.db.Save(credential).Error
+// Update writes the given columns of a pCloud credential. Health, quarantine,
+// usage and selection columns are kept by their own jobs and are only written
+// when named, so a stale copy cannot overwrite them.
+func (r *PCloudCredentialRepository) Update(credential *models.PCloudCredential, columns ...string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	return r.db.Model(credential).Select(columns).Updates(credential).Error
 }
 
 // Delete soft deletes pCloud credential
@@ -47,25 +52,48 @@ func (r *PCloudCredentialRepository) GetAll() ([]models.PCloudCredential, error)
 	return credentials, err
 }
 
-// GetActive gets all active pCloud credentials
+// GetActive gets all active pCloud credentials in upload rotation (not quarantined)
 func (r *PCloudCredentialRepository) GetActive() ([]models.PCloudCredential, error) {
 	var credentials []models.PCloudCredential
-	err := r.db.Where("is_active = ?", true).
+	err := r.db.Where("is_active = ? AND quarantined_at IS NULL", true).
 		Order("storage_used_gb ASC"). // Order by least used first
 		Find(&credentials).Error
 	return credentials, err
 }
 
+// GetEnabled gets all credentials enabled by an admin, including quarantined ones
+func (r *PCloudCredentialRepository) GetEnabled() ([]models.PCloudCredential, error) {
+	var credentials []models.PCloudCredential
+	err := r.db.Where("is_active = ?", true).
+		Order("created_at ASC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// UpdateHealth stores the latest health check state on a credential
+func (r *PCloudCredentialRepository) UpdateHealth(credential *models.PCloudCredential) error {
+	return r.db.Model(&models.PCloudCredential{}).Where("id = ?", credential.ID).
+		Updates(map[string]interface{}{
+			"health_status":     credential.HealthStatus,
+			"health_error":      credential.HealthError,
+			"health_latency_ms": credential.HealthLatencyMS,
+			"health_checked_at": credential.HealthCheckedAt,
+			"health_failures":   credential.HealthFailures,
+			"health_successes":  credential.HealthSuccesses,
+			"quarantined_at":    credential.QuarantinedAt,
+		}).Error
+}
+
 // ToggleActive toggles credential active status
 func (r *PCloudCredentialRepository) ToggleActive(id uuid.UUID, isActive bool) error {
 	return r.db.Model(&models.PCloudCredential{}).Where("id = ?", id).
 		Update("is_active", isActive).Error
 }
 
-// GetByStorageAvailable gets credentials ordered by available storage (most to least)
+// GetByStorageAvailable gets credentials in upload rotation ordered by available storage (most to least)
 func (r *PCloudCredentialRepository) GetByStorageAvailable() ([]models.PCloudCredential, error) {
 	var credentials []models.PCloudCredential
-	err := r.db.Where("is_active = ?", true).
+	err := r.db.Where("is_active = ? AND quarantined_at IS NULL", true).
 		Order("(storage_limit_gb - storage_used_gb) DESC").
 		Find(&credentials).Error
 	return credentials, err
@@ -75,4 +103,22 @@ func (r *PCloudCredentialRepository) GetByStorageAvailable() ([]models.PCloudCre
 func (r *PCloudCredentialRepository) UpdateStorageUsed(id uuid.UUID, storageUsedGB float64) error {
 	return r.db.Model(&models.PCloudCredential{}).Where("id = ?", id).
 		Update("storage_used_gb", storageUsedGB).Error
-}
\ No newline at end of file
+}
+
+// ReleaseStorage subtracts freed storage from a credential, never going below zero
+func (r *PCloudCredentialRepository) ReleaseStorage(id uuid.UUID, freedGB float64) error {
+	return r.db.Unscoped().Model(&models.PCloudCredential{}).Where("id = ?", id).
+		Update("storage_used_gb", gorm.Expr("GREATEST(storage_used_gb - ?, 0)", freedGB)).Error
+}
+
+// AddStorage adds to the storage used by a credential
+func (r *PCloudCredentialRepository) AddStorage(id uuid.UUID, addedGB float64) error {
+	return r.db.Model(&models.PCloudCredential{}).Where("id = ?", id).
+		Update("storage_used_gb", gorm.Expr("storage_used_gb + ?", addedGB)).Error
+}
+
+// MarkSelected records that an account was chosen for an upload
+func (r *PCloudCredentialRepository) MarkSelected(id uuid.UUID) error {
+	return r.db.Model(&models.PCloudCredential{}).Where("id = ?", id).
+		Update("last_selected_at", gorm.Expr("NOW()")).Error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PCloudHealthCheckRepository struct {
+	db *gorm.DB
+}
+
+func NewPCloudHealthCheckRepository(db *gorm.DB) *PCloudHealthCheckRepository {
+	return &PCloudHealthCheckRepository{db: db}
+}
+
+// Create records a health check
+func (r *PCloudHealthCheckRepository) Create(check *models.PCloudHealthCheck) error {
+	return r.db.Create(check).Error
+}
+
+// GetByCredential gets the latest health checks of a credential, newest first
+func (r *PCloudHealthCheckRepository) GetByCredential(credentialID uuid.UUID, limit int) ([]models.PCloudHealthCheck, error) {
+	var checks []models.PCloudHealthCheck
+	err := r.db.Where("credential_id = ?", credentialID).
+		Order("checked_at DESC").
+		Limit(limit).
+		Find(&checks).Error
+	return checks, err
+}
+
+// DeleteOlderThan prunes health history older than cutoff
+func (r *PCloudHealthCheckRepository) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	result := r.db.Where("checked_at < ?", cutoff).Delete(&models.PCloudHealthCheck{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPlaylistOrderMismatch is returned when a new order does not list exactly the playlist's videos
+var ErrPlaylistOrderMismatch = errors.New("order must list every video of the playlist exactly once")
+
+// publishedItem restricts playlist items to published, non-trashed videos
+const publishedItem = "EXISTS (SELECT 1 FROM videos WHERE videos.id = playlist_items.video_id AND videos.is_published = true AND videos.deleted_at IS NULL)"
+
+// PlaylistNeighbors are the published items around a video in a playlist
+type PlaylistNeighbors struct {
+	Position int                  // Index of the video among the published items
+	Total    int64                // Number of published items
+	Previous *models.PlaylistItem // nil at the start
+	Next     *models.PlaylistItem // nil at the end
+}
+
+type PlaylistRepository struct {
+	db *gorm.DB
+}
+
+func NewPlaylistRepository(db *gorm.DB) *PlaylistRepository {
+	return &PlaylistRepository{db: db}
+}
+
+// Create creates a new playlist
+func (r *PlaylistRepository) Create(playlist *models.Playlist) error {
+	return r.db.Create(playlist).Error
+}
+
+// FindByID finds playlist by ID
+func (r *PlaylistRepository) FindByID(id uuid.UUID) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.First(&playlist, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// FindBySlug finds playlist by slug
+func (r *PlaylistRepository) FindBySlug(slug string) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.Where("slug = ?", slug).First(&playlist).Error
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// Update updates playlist
+func (r *PlaylistRepository) Update(playlist *models.Playlist) error {
+	return r.db.Omit("Items").Save(playlist).Error
+}
+
+// Delete soft deletes playlist
+func (r *PlaylistRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.Playlist{}, "id = ?", id).Error
+}
+
+// SlugExists checks if slug exists, including on deleted playlists (the column is unique)
+func (r *PlaylistRepository) SlugExists(slug string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Playlist{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+// GetAll gets playlists of any visibility with their item counts (admin)
+func (r *PlaylistRepository) GetAll(page, limit int) ([]models.Playlist, int64, error) {
+	return r.list(r.db.Model(&models.Playlist{}), false, page, limit)
+}
+
+// GetPublic gets listed playlists, newest first, counting published items only
+func (r *PlaylistRepository) GetPublic(page, limit int) ([]models.Playlist, int64, error) {
+	return r.list(r.db.Model(&models.Playlist{}).Where("visibility = ?", models.PlaylistPublic), true, page, limit)
+}
+
+func (r *PlaylistRepository) list(query *gorm.DB, publishedOnly bool, page, limit int) ([]models.Playlist, int64, error) {
+	var playlists []models.Playlist
+	var total int64
+
+	offset := (page - 1) * limit
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&playlists).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.fillItemCounts(playlists, publishedOnly); err != nil {
+		return nil, 0, err
+	}
+	return playlists, total, nil
+}
+
+// fillItemCounts sets ItemCount of each playlist
+func (r *PlaylistRepository) fillItemCounts(playlists []models.Playlist, publishedOnly bool) error {
+	if len(playlists) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(playlists))
+	for i, playlist := range playlists {
+		ids[i] = playlist.ID
+	}
+
+	var rows []struct {
+		PlaylistID uuid.UUID
+		Count      int64
+	}
+	query := r.db.Model(&models.PlaylistItem{}).
+		Select("playlist_id, COUNT(*) AS count").
+		Where("playlist_id IN ?", ids)
+	if publishedOnly {
+		query = query.Where(publishedItem)
+	}
+	if err := query.Group("playlist_id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PlaylistID] = row.Count
+	}
+	for i := range playlists {
+		playlists[i].ItemCount = counts[playlists[i].ID]
+	}
+	return nil
+}
+
+// GetItems gets the items of a playlist in order with their videos. Public
+// pages only see published videos; admins also see drafts and trashed ones.
+func (r *PlaylistRepository) GetItems(playlistID uuid.UUID, publishedOnly bool) ([]models.PlaylistItem, error) {
+	var items []models.PlaylistItem
+
+	query := r.db.Where("playlist_id = ?", playlistID)
+	if publishedOnly {
+		query = query.Where(publishedItem).
+			Preload("Video").
+			Preload("Video.WrapperLink").
+			Preload("Video.Category")
+	} else {
+		query = query.Preload("Video", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+	}
+
+	err := query.Order("position ASC").Find(&items).Error
+	return items, err
+}
+
+// lockPlaylist locks the playlist row, serializing changes to its item positions
+func lockPlaylist(tx *gorm.DB, playlistID uuid.UUID) error {
+	var playlist models.Playlist
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&playlist, "id = ?", playlistID).Error
+}
+
+// AddItems appends videos to the end of a playlist; videos already in it keep their place
+func (r *PlaylistRepository) AddItems(playlistID uuid.UUID, videoIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+
+		var next int
+		err := tx.Model(&models.PlaylistItem{}).
+			Where("playlist_id = ?", playlistID).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Scan(&next).Error
+		if err != nil {
+			return err
+		}
+
+		for _, videoID := range videoIDs {
+			item := models.PlaylistItem{PlaylistID: playlistID, VideoID: videoID, Position: next}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&item)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				next++
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveItem removes a video from a playlist and closes the gap in positions.
+// Returns false if the video was not in the playlist.
+func (r *PlaylistRepository) RemoveItem(playlistID, videoID uuid.UUID) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+
+		var item models.PlaylistItem
+		err := tx.Where("playlist_id = ? AND video_id = ?", playlistID, videoID).First(&item).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("playlist_id = ? AND video_id = ?", playlistID, videoID).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		removed = true
+
+		return tx.Model(&models.PlaylistItem{}).
+			Where("playlist_id = ? AND position > ?", playlistID, item.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+	return removed, err
+}
+
+// Reorder sets the order of a playlist. videoIDs must list every video of
+// the playlist (including unpublished ones) exactly once.
+func (r *PlaylistRepository) Reorder(playlistID uuid.UUID, videoIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+
+		var current []uuid.UUID
+		if err := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID).Pluck("video_id", &current).Error; err != nil {
+			return err
+		}
+
+		inPlaylist := make(map[uuid.UUID]bool, len(current))
+		for _, id := range current {
+			inPlaylist[id] = true
+		}
+		if len(videoIDs) != len(current) {
+			return ErrPlaylistOrderMismatch
+		}
+		for _, id := range videoIDs {
+			if !inPlaylist[id] {
+				return ErrPlaylistOrderMismatch
+			}
+			delete(inPlaylist, id) // A repeated ID leaves another one unlisted
+		}
+
+		for position, videoID := range videoIDs {
+			err := tx.Model(&models.PlaylistItem{}).
+				Where("playlist_id = ? AND video_id = ?", playlistID, videoID).
+				UpdateColumn("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Neighbors finds the published items before and after a video in a playlist.
+// Returns gorm.ErrRecordNotFound if the video is not a published item of it.
+func (r *PlaylistRepository) Neighbors(playlistID, videoID uuid.UUID) (*PlaylistNeighbors, error) {
+	var item models.PlaylistItem
+	err := r.db.Where("playlist_id = ? AND video_id = ?", playlistID, videoID).
+		Where(publishedItem).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+
+	published := func() *gorm.DB {
+		return r.db.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID).Where(publishedItem)
+	}
+
+	neighbors := &PlaylistNeighbors{}
+	var position int64
+	if err := published().Where("position < ?", item.Position).Count(&position).Error; err != nil {
+		return nil, err
+	}
+	neighbors.Position = int(position)
+	if err := published().Count(&neighbors.Total).Error; err != nil {
+		return nil, err
+	}
+
+	neighbors.Previous, err = r.neighbor(published().Where("position < ?", item.Position).Order("position DESC"))
+	if err != nil {
+		return nil, err
+	}
+	neighbors.Next, err = r.neighbor(published().Where("position > ?", item.Position).Order("position ASC"))
+	if err != nil {
+		return nil, err
+	}
+
+	return neighbors, nil
+}
+
+// neighbor gets the first item of an ordered query with its video, or nil
+func (r *PlaylistRepository) neighbor(query *gorm.DB) (*models.PlaylistItem, error) {
+	var item models.PlaylistItem
+	err := query.Preload("Video").Preload("Video.WrapperLink").First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// viewInteraction is how strongly a viewer's views show interest in a video:
+// 0.5 for opening it up to 1 for watching it all
+const viewInteraction = "0.5 + LEAST(MAX(watched_percentage), 100) / 200"
+
+// Viewer identifies whose history recommendations come from: a logged-in user,
+// or an anonymous session
+type Viewer struct {
+	UserID    *uuid.UUID
+	SessionID string
+}
+
+// SimilarityModel controls how the video similarity model is built
+type SimilarityModel struct {
+	Since         time.Time // Views and likes before this are ignored
+	LikeWeight    float64   // Interaction a like adds to a view
+	ViewerHistory int       // Most recent videos per viewer that count; pairing grows with its square
+	MinViewers    int       // Viewers two videos need in common to be similar
+	Shrinkage     float64   // Damps similarities backed by few viewers
+	Neighbors     int       // Similar videos kept per video
+}
+
+// HistoryItem is a video in a viewer's history
+type HistoryItem struct {
+	VideoID uuid.UUID
+	Weight  float64 // Interaction strength, see viewInteraction plus likes
+	LastAt  time.Time
+}
+
+type RecommendationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) *RecommendationRepository {
+	return &RecommendationRepository{db: db}
+}
+
+// rebuildSimilarities computes the cosine similarity of every pair of
+// published videos over their viewers' interactions, shrunk towards 0 for
+// pairs with few viewers in common, and keeps the best per video. Only each
+// viewer's most recent videos count: sessions are client-supplied, and one
+// session with thousands of views must not make the pairing quadratic.
+const rebuildSimilarities = `
+WITH interactions AS (
+	SELECT viewer, video_id, weight
+	FROM (
+		SELECT viewer, video_id, SUM(weight) AS weight,
+			ROW_NUMBER() OVER (PARTITION BY viewer ORDER BY MAX(last_at) DESC, video_id) AS n
+		FROM (
+			SELECT COALESCE('u:' || user_id::text, 's:' || session_id) AS viewer, video_id,
+				` + viewInteraction + ` AS weight, MAX(viewed_at) AS last_at
+			FROM video_views
+			WHERE viewed_at >= ?
+			GROUP BY 1, 2
+			UNION ALL
+			SELECT 'u:' || user_id::text, video_id, ?::float8, created_at
+			FROM video_likes
+			WHERE created_at >= ?
+		) signals
+		JOIN videos ON videos.id = signals.video_id AND videos.is_published = true AND videos.deleted_at IS NULL
+		GROUP BY viewer, video_id
+	) by_viewer
+	WHERE n <= ?
+),
+norms AS (
+	SELECT video_id, SQRT(SUM(weight * weight)) AS norm FROM interactions GROUP BY video_id
+),
+pairs AS (
+	SELECT a.video_id, b.video_id AS similar_video_id, SUM(a.weight * b.weight) AS dot, COUNT(*) AS viewers
+	FROM interactions a
+	JOIN interactions b ON b.viewer = a.viewer AND b.video_id <> a.video_id
+	GROUP BY a.video_id, b.video_id
+	HAVING COUNT(*) >= ?
+),
+scored AS (
+	SELECT p.video_id, p.similar_video_id, p.viewers,
+		p.dot / (na.norm * nb.norm) * p.viewers / (p.viewers + ?::float8) AS score
+	FROM pairs p
+	JOIN norms na ON na.video_id = p.video_id
+	JOIN norms nb ON nb.video_id = p.similar_video_id
+),
+ranked AS (
+	SELECT video_id, similar_video_id, viewers, score,
+		ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY score DESC, similar_video_id) AS rank
+	FROM scored
+)
+INSERT INTO video_similarities (video_id, similar_video_id, score, viewers, computed_at)
+SELECT video_id, similar_video_id, score, viewers, NOW() FROM ranked WHERE rank <= ?`
+
+// RebuildSimilarities replaces the similarity model with a freshly computed
+// one and returns how many similarities were stored. Readers see the old
+// model until it commits.
+func (r *RecommendationRepository) RebuildSimilarities(model SimilarityModel) (int64, error) {
+	var stored int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM video_similarities").Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec(rebuildSimilarities,
+			model.Since,
+			model.LikeWeight, model.Since,
+			model.ViewerHistory,
+			model.MinViewers,
+			model.Shrinkage,
+			model.Neighbors,
+		)
+		stored = result.RowsAffected
+		return result.Error
+	})
+	return stored, err
+}
+
+// GetHistory gets the videos a viewer watched or liked since a time, most recent first
+func (r *RecommendationRepository) GetHistory(viewer Viewer, since time.Time, likeWeight float64, limit int) ([]HistoryItem, error) {
+	var history []HistoryItem
+
+	interactions := "SELECT video_id, " + viewInteraction + " AS weight, MAX(viewed_at) AS last_at FROM video_views WHERE viewed_at >= ? AND "
+	args := []interface{}{since}
+	if viewer.UserID != nil {
+		interactions += "user_id = ? GROUP BY video_id" +
+			" UNION ALL SELECT video_id, ?::float8, created_at FROM video_likes WHERE created_at >= ? AND user_id = ?"
+		args = append(args, *viewer.UserID, likeWeight, since, *viewer.UserID)
+	} else {
+		interactions += "session_id = ? GROUP BY video_id"
+		args = append(args, viewer.SessionID)
+	}
+
+	err := r.db.Raw(`SELECT video_id, SUM(weight) AS weight, MAX(last_at) AS last_at
+		FROM (`+interactions+`) interactions
+		GROUP BY video_id
+		ORDER BY last_at DESC, video_id
+		LIMIT ?`, append(args, limit)...).
+		Scan(&history).Error
+	return history, err
+}
+
+// GetCompleted gets the videos a viewer ever watched to completedPercent
+func (r *RecommendationRepository) GetCompleted(viewer Viewer, completedPercent float64) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	query := r.db.Model(&models.VideoView{}).Where("watched_percentage >= ?", completedPercent)
+	if viewer.UserID != nil {
+		query = query.Where("user_id = ?", *viewer.UserID)
+	} else {
+		query = query.Where("session_id = ?", viewer.SessionID)
+	}
+
+	err := query.Distinct().Pluck("video_id", &ids).Error
+	return ids, err
+}
+
+// GetSimilar gets the similar videos of each of the videos
+func (r *RecommendationRepository) GetSimilar(videoIDs []uuid.UUID) ([]models.VideoSimilarity, error) {
+	var similarities []models.VideoSimilarity
+	if len(videoIDs) == 0 {
+		return similarities, nil
+	}
+	err := r.db.Where("video_id IN ?", videoIDs).Find(&similarities).Error
+	return similarities, err
+}
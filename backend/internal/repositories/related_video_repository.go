@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Weights of the related video signals
+const (
+	relatedTagWeight      = 3.0 // Per shared tag
+	relatedCoViewWeight   = 4.0 // Times ln(1 + sessions that watched both)
+	relatedCategoryWeight = 2.0
+	relatedRecencyWeight  = 1.0 // Halves every half-life of the related video's age
+)
+
+// relatedTagVideos caps the videos a tag pairs up, most recent first. Pairing
+// grows with the square of a tag's videos, and a tag on that many videos says
+// little about how related two of them are.
+const relatedTagVideos = 200
+
+// relatedSessionVideos caps the videos a viewing session pairs up, most
+// recently watched first. Session IDs come from clients, so a crawler or a
+// forged session must not make the rebuild quadratic in its views.
+const relatedSessionVideos = 50
+
+// relatedRecency decays from 1 for a video published now by half every
+// half-life (arg: half-life in days); capped so old videos never underflow
+const relatedRecency = "EXP(-LN(2) * LEAST(GREATEST(EXTRACT(EPOCH FROM NOW() - " + videoPublishedAt + ") / 86400, 0) / ?::float8, 30))"
+
+// RelatedScoring controls how related videos are computed
+type RelatedScoring struct {
+	CoViewSince  time.Time // Views before this do not count as co-views
+	HalfLifeDays float64   // Age at which the recency boost halves
+	PerVideo     int       // Related videos kept per video
+}
+
+type RelatedVideoRepository struct {
+	db *gorm.DB
+}
+
+func NewRelatedVideoRepository(db *gorm.DB) *RelatedVideoRepository {
+	return &RelatedVideoRepository{db: db}
+}
+
+// rebuildRelated scores every pair of published videos that share a tag (among
+// its relatedTagVideos most recent) or a viewing session (among its
+// relatedSessionVideos last watched) and keeps the best per video. Same
+// category and recency only boost such pairs; they are too broad to make
+// candidates on their own.
+const rebuildRelated = `
+WITH published AS (
+	SELECT id, category_id, ` + relatedRecency + ` AS recency
+	FROM videos
+	WHERE is_published = true AND deleted_at IS NULL
+),
+tagged AS (
+	SELECT video_id, tag_id
+	FROM (
+		SELECT video_tags.video_id, video_tags.tag_id,
+			ROW_NUMBER() OVER (PARTITION BY video_tags.tag_id ORDER BY ` + videoPublishedAt + ` DESC, videos.id) AS n
+		FROM video_tags
+		JOIN videos ON videos.id = video_tags.video_id AND videos.is_published = true AND videos.deleted_at IS NULL
+	) by_tag
+	WHERE n <= ?
+),
+tag_pairs AS (
+	SELECT a.video_id, b.video_id AS related_video_id, COUNT(*) AS shared_tags
+	FROM tagged a
+	JOIN tagged b ON b.tag_id = a.tag_id AND b.video_id <> a.video_id
+	GROUP BY a.video_id, b.video_id
+),
+sessions AS (
+	SELECT session_id, video_id
+	FROM (
+		SELECT session_id, video_id,
+			ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY MAX(viewed_at) DESC, video_id) AS n
+		FROM video_views
+		WHERE viewed_at >= ?
+		GROUP BY session_id, video_id
+	) by_session
+	WHERE n <= ?
+),
+co_view_pairs AS (
+	SELECT a.video_id, b.video_id AS related_video_id, COUNT(*) AS co_views
+	FROM sessions a
+	JOIN sessions b ON b.session_id = a.session_id AND b.video_id <> a.video_id
+	GROUP BY a.video_id, b.video_id
+),
+pairs AS (
+	SELECT video_id, related_video_id, SUM(shared_tags) AS shared_tags, SUM(co_views) AS co_views
+	FROM (
+		SELECT video_id, related_video_id, shared_tags, 0 AS co_views FROM tag_pairs
+		UNION ALL
+		SELECT video_id, related_video_id, 0, co_views FROM co_view_pairs
+	) signals
+	GROUP BY video_id, related_video_id
+),
+scored AS (
+	SELECT p.video_id, p.related_video_id,
+		?::float8 * p.shared_tags
+		+ ?::float8 * LN(1 + p.co_views)
+		+ CASE WHEN v.category_id = r.category_id THEN ?::float8 ELSE 0 END
+		+ ?::float8 * r.recency AS score
+	FROM pairs p
+	JOIN published v ON v.id = p.video_id
+	JOIN published r ON r.id = p.related_video_id
+),
+ranked AS (
+	SELECT video_id, related_video_id, score,
+		ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY score DESC, related_video_id) AS rank
+	FROM scored
+)
+INSERT INTO related_videos (video_id, related_video_id, rank, score, computed_at)
+SELECT video_id, related_video_id, rank, score, NOW() FROM ranked WHERE rank <= ?`
+
+// Rebuild replaces all related videos with freshly computed ones and returns
+// how many were stored. Readers see the old set until it commits.
+func (r *RelatedVideoRepository) Rebuild(scoring RelatedScoring) (int64, error) {
+	var stored int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM related_videos").Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec(rebuildRelated,
+			scoring.HalfLifeDays,
+			relatedTagVideos,
+			scoring.CoViewSince,
+			relatedSessionVideos,
+			relatedTagWeight, relatedCoViewWeight, relatedCategoryWeight, relatedRecencyWeight,
+			scoring.PerVideo,
+		)
+		stored = result.RowsAffected
+		return result.Error
+	})
+	return stored, err
+}
+
+// GetRelated gets the precomputed related videos of a video, most related
+// first, skipping those unpublished or trashed since
+func (r *RelatedVideoRepository) GetRelated(videoID uuid.UUID, limit int) ([]models.Video, error) {
+	var related []models.RelatedVideo
+	err := r.db.Joins("JOIN videos ON videos.id = related_videos.related_video_id").
+		Where("related_videos.video_id = ?", videoID).
+		Where("videos.is_published = ? AND videos.deleted_at IS NULL", true).
+		Preload("RelatedVideo").
+		Preload("RelatedVideo.WrapperLink").
+		Preload("RelatedVideo.Category").
+		Order("related_videos.rank ASC").
+		Limit(limit).
+		Find(&related).Error
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]models.Video, 0, len(related))
+	for _, item := range related {
+		if item.RelatedVideo != nil {
+			videos = append(videos, *item.RelatedVideo)
+		}
+	}
+	return videos, nil
+}
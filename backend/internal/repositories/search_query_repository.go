@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SearchQueryRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchQueryRepository(db *gorm.DB) *SearchQueryRepository {
+	return &SearchQueryRepository{db: db}
+}
+
+// Record counts a submitted query and stores how many results it found
+func (r *SearchQueryRepository) Record(query string, results int) error {
+	now := time.Now()
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "query"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"search_count":     gorm.Expr("search_queries.search_count + 1"),
+			"result_count":     results,
+			"last_searched_at": now,
+		}),
+	}).Create(&models.SearchQuery{
+		Query:          query,
+		SearchCount:    1,
+		ResultCount:    results,
+		LastSearchedAt: now,
+	}).Error
+}
+
+// Popular gets the most searched queries starting with prefix that found results
+func (r *SearchQueryRepository) Popular(prefix string, minSearches, limit int) ([]string, error) {
+	var queries []string
+	err := r.db.Model(&models.SearchQuery{}).
+		Where("query LIKE ? AND result_count > 0 AND search_count >= ?", escapeLike(prefix)+"%", minSearches).
+		Order("search_count DESC, last_searched_at DESC").
+		Limit(limit).
+		Pluck("query", &queries).Error
+	return queries, err
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) *SettingRepository {
+	return &SettingRepository{db: db}
+}
+
+// Get gets a setting value; ok is false when the setting was never stored
+func (r *SettingRepository) Get(key string) (value string, ok bool, err error) {
+	var setting models.Setting
+	err = r.db.First(&setting, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return setting.Value, true, nil
+}
+
+// Set creates or replaces a setting
+func (r *SettingRepository) Set(key, value string) error {
+	return setSetting(r.db, key, value)
+}
+
+// setSetting is Set on a given connection or transaction
+func setSetting(tx *gorm.DB, key, value string) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&models.Setting{Key: key, Value: value}).Error
+}
+
+// Delete removes a setting so its config default applies again
+func (r *SettingRepository) Delete(key string) error {
+	return r.db.Delete(&models.Setting{}, "key = ?", key).Error
+}
@@ -0,0 +1,368 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagCount is a tag with the number of videos carrying it
+type TagCount struct {
+	models.Tag
+	VideoCount int64 `json:"video_count"`
+}
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// FindByID finds tag by ID with its aliases
+func (r *TagRepository) FindByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Preload("Aliases").First(&tag, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindBySlug finds the tag owning a slug, either as its own slug or as an alias
+func (r *TagRepository) FindBySlug(slug string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Where("slug = ?", slug).
+		Or("id IN (?)", r.db.Model(&models.TagAlias{}).Select("tag_id").Where("slug = ?", slug)).
+		First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetAll gets tags with their aliases and video counts (admin), optionally
+// filtered by a lowercase name or slug fragment
+func (r *TagRepository) GetAll(search string, page, limit int) ([]TagCount, int64, error) {
+	var tags []models.Tag
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.Tag{})
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("lower(video_search_unescape(name)) LIKE ? OR slug LIKE ?", pattern, pattern)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Aliases").
+		Order("name ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&tags).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, len(tags))
+	for i := range tags {
+		ids[i] = tags[i].ID
+	}
+	counts, err := r.countVideos(ids, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]TagCount, len(tags))
+	for i := range tags {
+		result[i] = TagCount{Tag: tags[i], VideoCount: counts[tags[i].ID]}
+	}
+	return result, total, nil
+}
+
+// countVideos counts videos per tag, excluding trashed ones
+func (r *TagRepository) countVideos(ids []uuid.UUID, publishedOnly bool) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		TagID uuid.UUID
+		Count int64
+	}
+
+	query := r.db.Model(&models.VideoTag{}).
+		Select("video_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN videos ON videos.id = video_tags.video_id AND videos.deleted_at IS NULL").
+		Where("video_tags.tag_id IN ?", ids)
+	if publishedOnly {
+		query = query.Where("videos.is_published = ?", true)
+	}
+
+	if err := query.Group("video_tags.tag_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TagID] = row.Count
+	}
+	return counts, nil
+}
+
+// CountVideos counts the published videos of a tag
+func (r *TagRepository) CountVideos(id uuid.UUID) (int64, error) {
+	counts, err := r.countVideos([]uuid.UUID{id}, true)
+	return counts[id], err
+}
+
+// GetPopular gets the tags with the most published videos
+func (r *TagRepository) GetPopular(limit int) ([]TagCount, error) {
+	var tags []TagCount
+	err := r.db.Model(&models.Tag{}).
+		Select("tags.*, COUNT(*) AS video_count").
+		Joins("JOIN video_tags ON video_tags.tag_id = tags.id").
+		Joins("JOIN videos ON videos.id = video_tags.video_id AND videos.is_published = ? AND videos.deleted_at IS NULL", true).
+		Group("tags.id").
+		Order("video_count DESC, tags.name").
+		Limit(limit).
+		Scan(&tags).Error
+	return tags, err
+}
+
+// SuggestNames gets tags (names unescaped) on published videos starting with
+// the lowercase prefix, most used first. Uses the trigram index on tag names.
+func (r *TagRepository) SuggestNames(prefix string, limit int) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Model(&models.Tag{}).
+		Select("tags.id, video_search_unescape(tags.name) AS name, tags.slug").
+		Joins("JOIN video_tags ON video_tags.tag_id = tags.id").
+		Joins("JOIN videos ON videos.id = video_tags.video_id AND videos.is_published = ? AND videos.deleted_at IS NULL", true).
+		Where("lower(video_search_unescape(tags.name)) LIKE ?", escapeLike(prefix)+"%").
+		Group("tags.id").
+		Order("COUNT(*) DESC, tags.name").
+		Limit(limit).
+		Find(&tags).Error
+	return tags, err
+}
+
+// SlugOwner gets the ID of the tag using a slug as its slug or an alias, or nil
+func (r *TagRepository) SlugOwner(slug string) (*uuid.UUID, error) {
+	tag, err := r.FindBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag.ID, nil
+}
+
+// SetVideoTags replaces the tags of a video. Each candidate resolves to the tag
+// owning its slug (directly or by alias) or is created. Returns the canonical
+// tags in order, which are also copied to videos.tags.
+func (r *TagRepository) SetVideoTags(videoID uuid.UUID, candidates []models.Tag) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tags, err = setVideoTags(tx, videoID, candidates)
+		return err
+	})
+	return tags, err
+}
+
+// setVideoTags is SetVideoTags inside a transaction
+func setVideoTags(tx *gorm.DB, videoID uuid.UUID, candidates []models.Tag) ([]models.Tag, error) {
+	var tags []models.Tag
+
+	seen := make(map[uuid.UUID]bool)
+	for _, candidate := range candidates {
+		tag, err := resolveTag(tx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tags = append(tags, *tag)
+		}
+	}
+
+	if err := tx.Where("video_id = ?", videoID).Delete(&models.VideoTag{}).Error; err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		links := make([]models.VideoTag, len(tags))
+		for i, tag := range tags {
+			links[i] = models.VideoTag{VideoID: videoID, TagID: tag.ID, Position: i}
+		}
+		if err := tx.Create(&links).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := refreshVideoTagNames(tx, []uuid.UUID{videoID}); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// resolveTag finds the tag owning the candidate's slug, creating it if needed
+func resolveTag(tx *gorm.DB, candidate models.Tag) (*models.Tag, error) {
+	var tag models.Tag
+	err := tx.Where("slug = ?", candidate.Slug).
+		Or("id IN (?)", tx.Model(&models.TagAlias{}).Select("tag_id").Where("slug = ?", candidate.Slug)).
+		First(&tag).Error
+	if err == nil {
+		return &tag, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// A concurrent upload may create the same tag first
+	tag = models.Tag{Name: candidate.Name, Slug: candidate.Slug}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.First(&tag, "slug = ?", candidate.Slug).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// refreshVideoTagNames copies the canonical tag names, in order, to videos.tags
+// (which also updates their search vectors)
+func refreshVideoTagNames(tx *gorm.DB, videoIDs []uuid.UUID) error {
+	if len(videoIDs) == 0 {
+		return nil
+	}
+	return tx.Unscoped().Model(&models.Video{}).
+		Where("id IN ?", videoIDs).
+		UpdateColumn("tags", gorm.Expr(`COALESCE((
+			SELECT array_agg(tags.name ORDER BY video_tags.position)
+			FROM video_tags JOIN tags ON tags.id = video_tags.tag_id
+			WHERE video_tags.video_id = videos.id
+		), '{}')`)).Error
+}
+
+// taggedVideoIDs gets the IDs of all videos (including trashed) carrying a tag
+func taggedVideoIDs(tx *gorm.DB, tagID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&models.VideoTag{}).Where("tag_id = ?", tagID).Pluck("video_id", &ids).Error
+	return ids, err
+}
+
+// Rename changes a tag's name and slug. A changed slug stays reachable as an alias.
+func (r *TagRepository) Rename(id uuid.UUID, name, slug string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if slug != tag.Slug {
+			// The new slug may have been one of the tag's own aliases
+			if err := tx.Delete(&models.TagAlias{}, "slug = ? AND tag_id = ?", slug, id).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.TagAlias{Slug: tag.Slug, TagID: id}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&tag).Updates(map[string]interface{}{"name": name, "slug": slug}).Error; err != nil {
+			return err
+		}
+
+		videoIDs, err := taggedVideoIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		return refreshVideoTagNames(tx, videoIDs)
+	})
+}
+
+// Merge moves every video and alias of the source tag to the target and
+// deletes the source; its slug becomes an alias of the target
+func (r *TagRepository) Merge(sourceID, targetID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var source models.Tag
+		if err := tx.First(&source, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+
+		var links []models.VideoTag
+		if err := tx.Where("tag_id = ?", sourceID).Find(&links).Error; err != nil {
+			return err
+		}
+
+		videoIDs := make([]uuid.UUID, len(links))
+		for i := range links {
+			videoIDs[i] = links[i].VideoID
+			links[i].TagID = targetID
+		}
+
+		// Videos already carrying the target keep their existing position
+		if len(links) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, 500).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("tag_id = ?", sourceID).Delete(&models.VideoTag{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.TagAlias{}).Where("tag_id = ?", sourceID).Update("tag_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Tag{}, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.TagAlias{Slug: source.Slug, TagID: targetID}).Error; err != nil {
+			return err
+		}
+
+		return refreshVideoTagNames(tx, videoIDs)
+	})
+}
+
+// AddAlias makes a slug resolve to the tag
+func (r *TagRepository) AddAlias(tagID uuid.UUID, slug string) error {
+	return r.db.Create(&models.TagAlias{Slug: slug, TagID: tagID}).Error
+}
+
+// DeleteAlias removes an alias of the tag. Returns false if it did not exist.
+func (r *TagRepository) DeleteAlias(tagID uuid.UUID, slug string) (bool, error) {
+	result := r.db.Delete(&models.TagAlias{}, "slug = ? AND tag_id = ?", slug, tagID)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete removes a tag from all videos and deletes it with its aliases
+func (r *TagRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		videoIDs, err := taggedVideoIDs(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("tag_id = ?", id).Delete(&models.VideoTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", id).Delete(&models.TagAlias{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Tag{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return refreshVideoTagNames(tx, videoIDs)
+	})
+}
@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VideoSort orders a video listing
+type VideoSort string
+
+const (
+	SortDefault   VideoSort = ""          // The listing's own order (feed score, newest, relevance)
+	SortRelevance VideoSort = "relevance" // Search rank; popularity outside search
+	SortNewest    VideoSort = "newest"
+	SortOldest    VideoSort = "oldest"
+	SortViews     VideoSort = "views"
+	SortLikes     VideoSort = "likes"
+)
+
+// VideoSorts lists the accepted sort options
+var VideoSorts = []VideoSort{SortRelevance, SortNewest, SortOldest, SortViews, SortLikes}
+
+// videoPublishedAt falls back to the creation time for videos published without a date
+const videoPublishedAt = "COALESCE(videos.published_at, videos.created_at)"
+
+// orderBy returns the ORDER BY for a sort, or "" for SortDefault and SortRelevance
+func (s VideoSort) orderBy() string {
+	switch s {
+	case SortNewest:
+		return videoPublishedAt + " DESC, videos.id"
+	case SortOldest:
+		return videoPublishedAt + " ASC, videos.id"
+	case SortViews:
+		return "videos.view_count DESC, videos.id"
+	case SortLikes:
+		return "videos.like_count DESC, videos.id"
+	}
+	return ""
+}
+
+// DurationBucket is a named duration range used for filtering and facets
+type DurationBucket struct {
+	Name       string
+	MinSeconds int // Inclusive
+	MaxSeconds int // Exclusive; 0 means no upper bound
+}
+
+// DurationBuckets are the duration ranges, shortest first. Videos without a
+// known duration belong to none.
+var DurationBuckets = []DurationBucket{
+	{Name: "short", MinSeconds: 1, MaxSeconds: 4 * 60},
+	{Name: "medium", MinSeconds: 4 * 60, MaxSeconds: 20 * 60},
+	{Name: "long", MinSeconds: 20 * 60},
+}
+
+// FindDurationBucket finds a duration bucket by name
+func FindDurationBucket(name string) (DurationBucket, bool) {
+	for _, bucket := range DurationBuckets {
+		if bucket.Name == name {
+			return bucket, true
+		}
+	}
+	return DurationBucket{}, false
+}
+
+// condition returns the SQL condition matching the bucket
+func (b DurationBucket) condition() (string, []interface{}) {
+	if b.MaxSeconds == 0 {
+		return "videos.duration_seconds >= ?", []interface{}{b.MinSeconds}
+	}
+	return "(videos.duration_seconds >= ? AND videos.duration_seconds < ?)", []interface{}{b.MinSeconds, b.MaxSeconds}
+}
+
+// VideoFilter narrows a listing of published videos
+type VideoFilter struct {
+	CategoryIDs     []uuid.UUID
+	MinDuration     int      // Seconds, 0 means no minimum
+	MaxDuration     int      // Seconds, 0 means no maximum
+	DurationBuckets []string // Names from DurationBuckets, any of them matches
+	PublishedAfter  *time.Time
+	Tags            []string // Tag slugs; aliases match their tag
+	MatchAllTags    bool     // Require every tag instead of any
+}
+
+// videoHasTag matches videos tagged with any of the slugs (args: slugs twice)
+const videoHasTag = `EXISTS (SELECT 1 FROM video_tags WHERE video_tags.video_id = videos.id AND video_tags.tag_id IN (
+	SELECT id FROM tags WHERE slug IN ? UNION SELECT tag_id FROM tag_aliases WHERE slug IN ?))`
+
+// IsEmpty reports whether the filter matches every published video
+func (f VideoFilter) IsEmpty() bool {
+	return len(f.CategoryIDs) == 0 && f.MinDuration == 0 && f.MaxDuration == 0 &&
+		len(f.DurationBuckets) == 0 && f.PublishedAfter == nil && len(f.Tags) == 0
+}
+
+// withoutCategories drops the category condition, for the category facet
+func (f VideoFilter) withoutCategories() VideoFilter {
+	f.CategoryIDs = nil
+	return f
+}
+
+// withoutDuration drops the duration conditions, for the duration facet
+func (f VideoFilter) withoutDuration() VideoFilter {
+	f.MinDuration, f.MaxDuration, f.DurationBuckets = 0, 0, nil
+	return f
+}
+
+// apply adds the filter conditions to a query on videos
+func (f VideoFilter) apply(query *gorm.DB) *gorm.DB {
+	if len(f.CategoryIDs) > 0 {
+		query = query.Where("videos.category_id IN ?", f.CategoryIDs)
+	}
+	if f.MinDuration > 0 {
+		query = query.Where("videos.duration_seconds >= ?", f.MinDuration)
+	}
+	if f.MaxDuration > 0 {
+		query = query.Where("videos.duration_seconds > 0 AND videos.duration_seconds <= ?", f.MaxDuration)
+	}
+	if len(f.DurationBuckets) > 0 {
+		var conditions []string
+		var args []interface{}
+		for _, name := range f.DurationBuckets {
+			if bucket, ok := FindDurationBucket(name); ok {
+				condition, bucketArgs := bucket.condition()
+				conditions = append(conditions, condition)
+				args = append(args, bucketArgs...)
+			}
+		}
+		if len(conditions) > 0 {
+			query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+		}
+	}
+	if f.PublishedAfter != nil {
+		query = query.Where(videoPublishedAt+" >= ?", *f.PublishedAfter)
+	}
+	if len(f.Tags) > 0 {
+		if f.MatchAllTags {
+			for _, slug := range f.Tags {
+				query = query.Where(videoHasTag, []string{slug}, []string{slug})
+			}
+		} else {
+			query = query.Where(videoHasTag, f.Tags, f.Tags)
+		}
+	}
+	return query
+}
+
+// ListVideos gets published videos matching the filter. SortDefault is newest first.
+func (r *VideoRepository) ListVideos(filter VideoFilter, sort VideoSort, page, limit int) ([]models.Video, int64, error) {
+	var videos []models.Video
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := filter.apply(r.db.Model(&models.Video{}).Where("videos.is_published = ?", true))
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := sort.orderBy()
+	if order == "" {
+		order = SortNewest.orderBy()
+		if sort == SortRelevance {
+			order = videoPopularity + " DESC, videos.id"
+		}
+	}
+
+	err := query.Preload("WrapperLink").
+		Preload("Category").
+		Order(order).
+		Offset(offset).
+		Limit(limit).
+		Find(&videos).Error
+
+	return videos, total, err
+}
+
+// CategoryFacet is the number of matching videos in a category
+type CategoryFacet struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	Slug       string    `json:"slug"`
+	Count      int64     `json:"count"`
+}
+
+// DurationFacet is the number of matching videos in a duration bucket
+type DurationFacet struct {
+	Bucket     string `json:"bucket"`
+	MinSeconds int    `json:"min_seconds"`
+	MaxSeconds int    `json:"max_seconds,omitempty"` // Omitted for the open-ended bucket
+	Count      int64  `json:"count"`
+}
+
+// VideoFacets are counts of search matches per category and duration bucket.
+// Each facet ignores its own filter, so other values stay selectable.
+type VideoFacets struct {
+	Categories []CategoryFacet `json:"categories"`
+	Durations  []DurationFacet `json:"durations"`
+}
+
+// SearchFacets counts the matches of a search per category and duration bucket
+func (r *VideoRepository) SearchFacets(q VideoSearchQuery) (*VideoFacets, error) {
+	facets := &VideoFacets{Categories: []CategoryFacet{}}
+
+	err := r.searchScope(q.TSConfig, q.Keyword, q.Filter.withoutCategories()).
+		Joins("JOIN categories ON categories.id = videos.category_id AND categories.deleted_at IS NULL").
+		Select("videos.category_id, categories.name, categories.slug, COUNT(*) AS count").
+		Group("videos.category_id, categories.name, categories.slug").
+		Order("count DESC, categories.name").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// One CASE expression assigns each video its bucket
+	var cases strings.Builder
+	var args []interface{}
+	cases.WriteString("CASE")
+	for _, bucket := range DurationBuckets {
+		condition, bucketArgs := bucket.condition()
+		cases.WriteString(fmt.Sprintf(" WHEN %s THEN ?", condition))
+		args = append(append(args, bucketArgs...), bucket.Name)
+	}
+	cases.WriteString(" END")
+
+	var rows []struct {
+		Bucket string
+		Count  int64
+	}
+	err = r.searchScope(q.TSConfig, q.Keyword, q.Filter.withoutDuration()).
+		Select(cases.String()+" AS bucket, COUNT(*) AS count", args...).
+		Where("videos.duration_seconds > 0").
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+	for _, bucket := range DurationBuckets {
+		facets.Durations = append(facets.Durations, DurationFacet{
+			Bucket:     bucket.Name,
+			MinSeconds: bucket.MinSeconds,
+			MaxSeconds: bucket.MaxSeconds,
+			Count:      counts[bucket.Name],
+		})
+	}
+
+	return facets, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"bobastream/internal/models"
+	"fmt"
 	"strings"
 	"time"
 
@@ -23,6 +24,29 @@ func (r *VideoRepository) Create(video *models.Video) error {
 	return r.db.Create(video).Error
 }
 
+// CreateWithTags creates a new video and links it to its tags (see
+// TagRepository.SetVideoTags) in one transaction, so a video is never left
+// without its tags. Returns the canonical tags in order.
+func (r *VideoRepository) CreateWithTags(video *models.Video, candidates []models.Tag) ([]models.Tag, error) {
+	var tags []models.Tag
+	published := video.IsPublished
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(video).Error; err != nil {
+			return err
+		}
+		// is_published defaults to true, so GORM leaves false out of the INSERT
+		if !published {
+			if err := tx.Model(video).Update("is_published", false).Error; err != nil {
+				return err
+			}
+		}
+		var err error
+		tags, err = setVideoTags(tx, video.ID, candidates)
+		return err
+	})
+	return tags, err
+}
+
 // FindByID finds video by ID with wrapper link
 func (r *VideoRepository) FindByID(id uuid.UUID) (*models.Video, error) {
 	var video models.Video
@@ -33,6 +57,22 @@ func (r *VideoRepository) FindByID(id uuid.UUID) (*models.Video, error) {
 	return &video, nil
 }
 
+// FindByWrapperSlug finds a published video by the current slug of its
+// wrapper link. Slugs come from titles and can be guessed, so drafts are only
+// reachable by their wrapper token.
+func (r *VideoRepository) FindByWrapperSlug(slug string) (*models.Video, error) {
+	var video models.Video
+	err := r.db.Joins("JOIN wrapper_links ON wrapper_links.video_id = videos.id").
+		Where("wrapper_links.slug = ? AND videos.is_published = ?", slug, true).
+		Preload("WrapperLink").
+		Preload("Category").
+		First(&video).Error
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
 // FindByWrapperToken finds video by wrapper token
 func (r *VideoRepository) FindByWrapperToken(token string) (*models.Video, error) {
 	var video models.Video
@@ -47,6 +87,74 @@ func (r *VideoRepository) FindByWrapperToken(token string) (*models.Video, error
 	return &video, nil
 }
 
+// GetFileIDsByCredential returns the pCloud file IDs (including trashed videos)
+// already stored for a credential
+func (r *VideoRepository) GetFileIDsByCredential(credentialID uuid.UUID) (map[string]bool, error) {
+	var fileIDs []string
+	err := r.db.Unscoped().Model(&models.Video{}).
+		Where("pcloud_credential_id = ?", credentialID).
+		Pluck("pcloud_file_id", &fileIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(fileIDs))
+	for _, id := range fileIDs {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// GetTrafficByCredential estimates bytes streamed from each credential since a
+// time: file size times the share watched, counting views without progress as full
+func (r *VideoRepository) GetTrafficByCredential(since time.Time) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		PCloudCredentialID uuid.UUID `gorm:"column:pcloud_credential_id"`
+		Bytes              float64
+	}
+	err := r.db.Unscoped().Table("video_views vv").
+		Select(`v.pcloud_credential_id,
+			SUM(COALESCE(NULLIF(v.file_size_bytes, 0), v.file_size_mb * 1048576) *
+				LEAST(COALESCE(NULLIF(vv.watched_percentage, 0), 100), 100) / 100) AS bytes`).
+		Joins("JOIN videos v ON v.id = vv.video_id").
+		Where("vv.viewed_at >= ?", since).
+		Group("v.pcloud_credential_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	traffic := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		traffic[row.PCloudCredentialID] = int64(row.Bytes)
+	}
+	return traffic, nil
+}
+
+// FindByContentFingerprint finds a video (including trashed ones) with the same
+// SHA-256, or the same pCloud hash and size
+func (r *VideoRepository) FindByContentFingerprint(sha256Hex, pcloudHash string, size int64) (*models.Video, error) {
+	query := r.db.Unscoped().Preload("WrapperLink")
+
+	switch {
+	case sha256Hex != "" && pcloudHash != "":
+		query = query.Where("content_sha256 = ? OR (content_hash = ? AND file_size_bytes = ?)", sha256Hex, pcloudHash, size)
+	case sha256Hex != "":
+		query = query.Where("content_sha256 = ?", sha256Hex)
+	case pcloudHash != "":
+		query = query.Where("content_hash = ? AND file_size_bytes = ?", pcloudHash, size)
+	default:
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var video models.Video
+	// Prefer live videos over trashed ones
+	if err := query.Order("deleted_at IS NOT NULL, created_at ASC").First(&video).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
 // Update updates video
 func (r *VideoRepository) Update(video *models.Video) error {
 	return r.db.Save(video).Error
@@ -57,6 +165,119 @@ func (r *VideoRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Video{}, "id = ?", id).Error
 }
 
+// FindTrashedByID finds a soft-deleted video by ID with its pCloud credential,
+// even if the credential was deleted since
+func (r *VideoRepository) FindTrashedByID(id uuid.UUID) (*models.Video, error) {
+	var video models.Video
+	err := r.db.Unscoped().
+		Preload("PCloudCredential", unscopedCredential).
+		Where("deleted_at IS NOT NULL").
+		First(&video, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// GetTrashedVideos gets soft-deleted videos, most recently deleted first,
+// optionally only those that failed to purge (admin)
+func (r *VideoRepository) GetTrashedVideos(purgeFailedOnly bool, page, limit int) ([]models.Video, int64, error) {
+	var videos []models.Video
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.Unscoped().Where("deleted_at IS NOT NULL")
+	if purgeFailedOnly {
+		query = query.Where("purge_failures > 0")
+	}
+
+	if err := query.Model(&models.Video{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("PCloudCredential").
+		Preload("Category").
+		Order("deleted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&videos).Error
+
+	return videos, total, err
+}
+
+// Restore clears the soft-delete marker of a trashed video
+func (r *VideoRepository) Restore(id uuid.UUID) error {
+	result := r.db.Unscoped().Model(&models.Video{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at":      nil,
+			"purge_failures":  0,
+			"purge_error":     "",
+			"purge_failed_at": nil,
+			"purge_retry_at":  nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPurgeableVideos gets trashed videos deleted before cutoff with their
+// pCloud credentials, even deleted ones (their files still need removing),
+// skipping those backing off from a failed purge until now
+func (r *VideoRepository) GetPurgeableVideos(cutoff, now time.Time, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Where("purge_retry_at IS NULL OR purge_retry_at <= ?", now).
+		Preload("PCloudCredential", unscopedCredential).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&videos).Error
+	return videos, err
+}
+
+// RecordPurgeFailure counts a failed purge of a trashed video and schedules the next attempt
+func (r *VideoRepository) RecordPurgeFailure(id uuid.UUID, message string, retryAt time.Time) error {
+	return r.db.Unscoped().Model(&models.Video{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"purge_failures":  gorm.Expr("purge_failures + 1"),
+			"purge_error":     message,
+			"purge_failed_at": time.Now(),
+			"purge_retry_at":  retryAt,
+		}).Error
+}
+
+// unscopedCredential preloads a pCloud credential even if it was soft-deleted
+func unscopedCredential(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// HardDelete permanently removes a video and all rows depending on it
+func (r *VideoRepository) HardDelete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", id).Delete(&models.WrapperLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", id).Delete(&models.VideoView{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", id).Delete(&models.VideoLike{}).Error; err != nil {
+			return err
+		}
+		// Ad impressions are kept for reporting, only the video reference is dropped
+		if err := tx.Model(&models.AdImpression{}).Where("video_id = ?", id).
+			Update("video_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Video{}, "id = ?", id).Error
+	})
+}
+
 // GetPublishedVideos gets published videos with scoring for feed
 func (r *VideoRepository) GetPublishedVideos(page, limit int) ([]models.Video, int64, error) {
 	var videos []models.Video
@@ -81,6 +302,13 @@ func (r *VideoRepository) GetPublishedVideos(page, limit int) ([]models.Video, i
 	return videos, total, err
 }
 
+// CountPublished counts published videos
+func (r *VideoRepository) CountPublished() (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Video{}).Where("is_published = ?", true).Count(&total).Error
+	return total, err
+}
+
 // GetAllVideos gets all videos (admin)
 func (r *VideoRepository) GetAllVideos(page, limit int) ([]models.Video, int64, error) {
 	var videos []models.Video
@@ -103,107 +331,226 @@ func (r *VideoRepository) GetAllVideos(page, limit int) ([]models.Video, int64,
 	return videos, total, err
 }
 
-// GetRelatedVideos gets related videos based on category and tags
-func (r *VideoRepository) GetRelatedVideos(videoID uuid.UUID, categoryID string, tags []string, limit int) ([]models.Video, error) {
+// GetRelatedVideos scores published videos in the same category or sharing a
+// tag live by shared tags, same category and recency, for videos without
+// precomputed related videos (see RelatedVideoRepository). Co-views are left
+// to the nightly computation.
+func (r *VideoRepository) GetRelatedVideos(video *models.Video, exclude []uuid.UUID, halfLifeDays float64, limit int) ([]models.Video, error) {
 	var videos []models.Video
 
-	query := r.db.Where("id != ? AND is_published = ?", videoID, true)
-
-	// Prioritize same category
-	if categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
+	query := r.db.Where("id != ? AND is_published = ?", video.ID, true).
+		Where(`videos.category_id = ? OR EXISTS (SELECT 1 FROM video_tags a JOIN video_tags b ON b.tag_id = a.tag_id
+			WHERE a.video_id = ? AND b.video_id = videos.id)`, video.CategoryID, video.ID)
+	if len(exclude) > 0 {
+		query = query.Where("id NOT IN ?", exclude)
 	}
 
+	score := clause.OrderBy{Expression: clause.Expr{
+		SQL: `(?::float8 * (SELECT COUNT(*) FROM video_tags a JOIN video_tags b ON b.tag_id = a.tag_id
+				WHERE a.video_id = ? AND b.video_id = videos.id)
+			+ CASE WHEN videos.category_id = ? THEN ?::float8 ELSE 0 END
+			+ ?::float8 * ` + relatedRecency + `) DESC, view_count DESC, videos.id`,
+		Vars: []interface{}{
+			relatedTagWeight, video.ID,
+			video.CategoryID, relatedCategoryWeight,
+			relatedRecencyWeight, halfLifeDays,
+		},
+		WithoutParentheses: true,
+	}}
+
 	err := query.Preload("WrapperLink").
 		Preload("Category").
-		Order("view_count DESC").
+		Clauses(score).
 		Limit(limit).
 		Find(&videos).Error
 
 	return videos, err
 }
 
-// ✅ FIXED: SearchVideos with explicit escaping for ILIKE wildcards
-func (r *VideoRepository) SearchVideos(keyword string, page, limit int) ([]models.Video, int64, error) {
-	var videos []models.Video
+// VideoSearchHit is a full-text search match with its rank and highlighted
+// fragments (matches wrapped in the StartSel/StopSel markers of SearchOptions)
+type VideoSearchHit struct {
+	ID                   uuid.UUID
+	Rank                 float64
+	TitleHighlight       string
+	DescriptionHighlight string
+	Video                models.Video `gorm:"-"`
+}
+
+// VideoSearchQuery describes a full-text search
+type VideoSearchQuery struct {
+	TSConfig         string // Text search configuration, same as used for search_vector
+	Keyword          string // websearch_to_tsquery syntax: words, "phrases", OR, -excluded
+	Filter           VideoFilter
+	Sort             VideoSort // SortDefault and SortRelevance order by rank
+	PopularityWeight float64   // 0..1 share of the rank taken by views and likes
+	StartSel         string
+	StopSel          string
+}
+
+// videoPopularity maps views and likes to [0, 1), saturating for very popular videos
+const videoPopularity = "(ln(1 + view_count + 5 * like_count) / (ln(1 + view_count + 5 * like_count) + 10))"
+
+// videoTSQuery parses a keyword in web search syntax; args are the config and keyword
+const videoTSQuery = "websearch_to_tsquery(CAST(? AS regconfig), ?)"
+
+// searchScope selects published videos matching the keyword and filter
+func (r *VideoRepository) searchScope(tsConfig, keyword string, filter VideoFilter) *gorm.DB {
+	query := r.db.Model(&models.Video{}).Where("videos.is_published = ?", true)
+	if keyword != "" {
+		query = query.Where("videos.search_vector @@ "+videoTSQuery, tsConfig, keyword)
+	}
+	return filter.apply(query)
+}
+
+// SearchVideos finds published videos matching the keyword and filter, by
+// default ordered by text relevance blended with popularity. An empty keyword
+// ranks by popularity alone.
+func (r *VideoRepository) SearchVideos(q VideoSearchQuery, page, limit int) ([]VideoSearchHit, int64, error) {
+	var hits []VideoSearchHit
 	var total int64
 
 	offset := (page - 1) * limit
 
-	// ✅ Escape special LIKE characters (%, _)
-	escapedKeyword := strings.ReplaceAll(keyword, "%", "\\%")
-	escapedKeyword = strings.ReplaceAll(escapedKeyword, "_", "\\_")
-	
-	searchPattern := "%" + escapedKeyword + "%"
+	query := r.searchScope(q.TSConfig, q.Keyword, q.Filter)
 
-	query := r.db.Where("is_published = ? AND (title ILIKE ? OR description ILIKE ?)",
-		true, searchPattern, searchPattern)
-
-	if err := query.Model(&models.Video{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
+	if total == 0 {
+		return []VideoSearchHit{}, 0, nil
+	}
 
-	err := query.Preload("WrapperLink").
-		Preload("Category").
-		Order("view_count DESC").
+	if q.Keyword == "" {
+		query = query.Select("id, " + videoPopularity + " AS rank")
+	} else {
+		// ts_rank normalization 32 scales the rank to [0, 1) like the popularity term
+		titleOptions := fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, q.StartSel, q.StopSel)
+		descriptionOptions := fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=" … "`, q.StartSel, q.StopSel)
+
+		query = query.Select(
+			"id, "+
+				"(1 - CAST(? AS float8)) * ts_rank(search_vector, "+videoTSQuery+", 32) + CAST(? AS float8) * "+videoPopularity+" AS rank, "+
+				"ts_headline(CAST(? AS regconfig), video_search_unescape(title), "+videoTSQuery+", ?) AS title_highlight, "+
+				"ts_headline(CAST(? AS regconfig), video_search_unescape(description), "+videoTSQuery+", ?) AS description_highlight",
+			q.PopularityWeight, q.TSConfig, q.Keyword, q.PopularityWeight,
+			q.TSConfig, q.TSConfig, q.Keyword, titleOptions,
+			q.TSConfig, q.TSConfig, q.Keyword, descriptionOptions,
+		)
+	}
+
+	order := q.Sort.orderBy()
+	if order == "" {
+		order = "rank DESC, view_count DESC, id"
+	}
+
+	err := query.Order(order).
 		Offset(offset).
 		Limit(limit).
-		Find(&videos).Error
+		Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
 
-	return videos, total, err
-}
+	// Load the full videos and keep the ranked order
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
 
-// ✅ FIXED: SearchVideosByCategory with explicit escaping
-func (r *VideoRepository) SearchVideosByCategory(keyword string, categoryID uuid.UUID, page, limit int) ([]models.Video, int64, error) {
 	var videos []models.Video
-	var total int64
+	err = r.db.Where("id IN ?", ids).
+		Preload("WrapperLink").
+		Preload("Category").
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, err
+	}
 
-	offset := (page - 1) * limit
+	byID := make(map[uuid.UUID]models.Video, len(videos))
+	for _, video := range videos {
+		byID[video.ID] = video
+	}
 
-	// ✅ Escape special LIKE characters
-	escapedKeyword := strings.ReplaceAll(keyword, "%", "\\%")
-	escapedKeyword = strings.ReplaceAll(escapedKeyword, "_", "\\_")
-	
-	searchPattern := "%" + escapedKeyword + "%"
+	result := hits[:0]
+	for _, hit := range hits {
+		video, ok := byID[hit.ID]
+		if !ok {
+			continue // Deleted between the two queries
+		}
+		hit.Video = video
+		result = append(result, hit)
+	}
 
-	query := r.db.Where("is_published = ? AND category_id = ? AND (title ILIKE ? OR description ILIKE ?)",
-		true, categoryID, searchPattern, searchPattern)
+	return result, total, nil
+}
 
-	if err := query.Model(&models.Video{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+// escapeLike escapes LIKE wildcards so user input only matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
 
-	err := query.Preload("WrapperLink").
-		Preload("Category").
-		Order("view_count DESC").
-		Offset(offset).
+// startsWithFirst orders rows whose expression starts with the lowercase prefix
+// first, then by the given order (one clause: Order() would drop an expression)
+func startsWithFirst(expr, prefix, then string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                "(" + expr + " LIKE ?) DESC, " + then,
+		Vars:               []interface{}{escapeLike(prefix) + "%"},
+		WithoutParentheses: true,
+	}}
+}
+
+// SuggestTitles gets published video titles (unescaped) containing the lowercase
+// prefix, titles starting with it first. Uses the trigram index on titles.
+func (r *VideoRepository) SuggestTitles(prefix string, limit int) ([]string, error) {
+	var titles []string
+	title := "lower(video_search_unescape(title))"
+
+	err := r.db.Model(&models.Video{}).
+		Where("is_published = ?", true).
+		Where(title+" LIKE ?", "%"+escapeLike(prefix)+"%").
+		Clauses(startsWithFirst(title, prefix, "view_count DESC")).
 		Limit(limit).
-		Find(&videos).Error
+		Pluck("video_search_unescape(title)", &titles).Error
 
-	return videos, total, err
+	return titles, err
 }
 
-// GetVideosByCategory gets videos by category
-func (r *VideoRepository) GetVideosByCategory(categoryID uuid.UUID, page, limit int) ([]models.Video, int64, error) {
-	var videos []models.Video
-	var total int64
+// TextSearchConfigExists reports whether Postgres has the text search configuration
+func (r *VideoRepository) TextSearchConfigExists(name string) (bool, error) {
+	var count int64
+	err := r.db.Table("pg_ts_config").Where("cfgname = ?", name).Count(&count).Error
+	return count > 0, err
+}
 
-	offset := (page - 1) * limit
+// RebuildSearchVectors stores the text search configuration read by
+// video_search_config() under the settings key and recomputes every
+// search_vector with it, without bumping updated_at. Both happen in one
+// transaction, so a failed rebuild keeps the previous configuration.
+func (r *VideoRepository) RebuildSearchVectors(settingKey, tsConfig string) (int64, error) {
+	var rows int64
 
-	query := r.db.Where("is_published = ? AND category_id = ?", true, categoryID)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := setSetting(tx, settingKey, tsConfig); err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE videos DISABLE TRIGGER update_videos_updated_at").Error; err != nil {
+			return err
+		}
 
-	if err := query.Model(&models.Video{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+		result := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).
+			Unscoped().
+			Model(&models.Video{}).
+			UpdateColumn("search_vector", gorm.Expr("video_search_vector(video_search_config(), title, tags, description)"))
+		if result.Error != nil {
+			return result.Error
+		}
+		rows = result.RowsAffected
 
-	err := query.Preload("WrapperLink").
-		Preload("Category").
-		Order("published_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&videos).Error
+		return tx.Exec("ALTER TABLE videos ENABLE TRIGGER update_videos_updated_at").Error
+	})
 
-	return videos, total, err
+	return rows, err
 }
 
 // IncrementViewCount increments video view count
@@ -248,24 +595,65 @@ func (r *VideoRepository) DecrementLikeCount(id uuid.UUID) error {
 	})
 }
 
-// GetExpiredSourceURLVideos gets videos with expired source URLs
-func (r *VideoRepository) GetExpiredSourceURLVideos() ([]models.Video, error) {
+// GetLinksDueForRefresh gets videos whose source URL expires before the given time,
+// soonest first, skipping those backing off from a failed refresh until now
+func (r *VideoRepository) GetLinksDueForRefresh(before, now time.Time, limit int) ([]models.Video, error) {
 	var videos []models.Video
-	err := r.db.Where("source_url_expires_at < ?", time.Now().Add(1*time.Hour)).
+	err := r.db.Where("source_url_expires_at IS NULL OR source_url_expires_at < ?", before).
+		Where("link_refresh_retry_at IS NULL OR link_refresh_retry_at <= ?", now).
 		Preload("PCloudCredential").
+		Order("source_url_expires_at ASC NULLS FIRST").
+		Limit(limit).
 		Find(&videos).Error
 	return videos, err
 }
 
-// UpdateSourceURL updates video source URL and expiry
+// UpdateSourceURL updates video source URL and expiry, clearing refresh failures
 func (r *VideoRepository) UpdateSourceURL(id uuid.UUID, sourceURL string, expiresAt time.Time) error {
 	return r.db.Model(&models.Video{}).Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"source_url":            sourceURL,
-			"source_url_expires_at": expiresAt,
+			"source_url":             sourceURL,
+			"source_url_expires_at":  expiresAt,
+			"link_refresh_failures":  0,
+			"link_refresh_error":     "",
+			"link_refresh_failed_at": nil,
+			"link_refresh_retry_at":  nil,
 		}).Error
 }
 
+// RecordLinkRefreshFailure counts a failed link refresh and schedules the next attempt
+func (r *VideoRepository) RecordLinkRefreshFailure(id uuid.UUID, message string, retryAt time.Time) error {
+	return r.db.Model(&models.Video{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"link_refresh_failures":  gorm.Expr("link_refresh_failures + 1"),
+			"link_refresh_error":     message,
+			"link_refresh_failed_at": time.Now(),
+			"link_refresh_retry_at":  retryAt,
+		}).Error
+}
+
+// GetLinkRefreshFailures gets videos with at least minFailures consecutive refresh failures
+func (r *VideoRepository) GetLinkRefreshFailures(minFailures, page, limit int) ([]models.Video, int64, error) {
+	var videos []models.Video
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.Video{}).Where("link_refresh_failures >= ?", minFailures)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("PCloudCredential").
+		Order("link_refresh_failures DESC, link_refresh_failed_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&videos).Error
+
+	return videos, total, err
+}
+
 // GetTopVideos gets top videos by view count
 func (r *VideoRepository) GetTopVideos(limit int, days int) ([]models.Video, error) {
 	var videos []models.Video
@@ -280,4 +668,94 @@ func (r *VideoRepository) GetTopVideos(limit int, days int) ([]models.Video, err
 		Find(&videos).Error
 
 	return videos, err
-}
\ No newline at end of file
+}
+
+// GetTrendingVideos gets published videos by their views since a time, then
+// by all-time views, so the list is full even when few videos were watched
+func (r *VideoRepository) GetTrendingVideos(since time.Time, exclude []uuid.UUID, limit int) ([]models.Video, error) {
+	var videos []models.Video
+
+	query := r.db.Joins("LEFT JOIN (SELECT video_id, COUNT(*) AS recent_views FROM video_views WHERE viewed_at >= ? GROUP BY video_id) trending ON trending.video_id = videos.id", since).
+		Where("videos.is_published = ?", true)
+	if len(exclude) > 0 {
+		query = query.Where("videos.id NOT IN ?", exclude)
+	}
+
+	err := query.Preload("WrapperLink").
+		Preload("Category").
+		Order("COALESCE(trending.recent_views, 0) DESC, videos.view_count DESC, videos.id").
+		Limit(limit).
+		Find(&videos).Error
+
+	return videos, err
+}
+
+// FindPublishedByIDs gets the published videos among the IDs, in no particular order
+func (r *VideoRepository) FindPublishedByIDs(ids []uuid.UUID) ([]models.Video, error) {
+	var videos []models.Video
+	if len(ids) == 0 {
+		return videos, nil
+	}
+
+	err := r.db.Where("id IN ? AND is_published = ?", ids, true).
+		Preload("WrapperLink").
+		Preload("Category").
+		Find(&videos).Error
+
+	return videos, err
+}
+
+// GetStreamingCandidates gets videos not known to be streaming-optimized that
+// the faststart job has not given up on (MP4/MOV, or not probed yet)
+func (r *VideoRepository) GetStreamingCandidates(limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := r.db.Preload("PCloudCredential").
+		Where("streaming_optimized IS NOT TRUE AND streaming_error IS NULL").
+		Where("container IS NULL OR container = '' OR container IN ?", []string{"mp4", "mov"}).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&videos).Error
+	return videos, err
+}
+
+// UpdateStreamingState records whether a video is streaming-optimized, or why it cannot be
+func (r *VideoRepository) UpdateStreamingState(id uuid.UUID, optimized bool, failure *string) error {
+	return r.db.Model(&models.Video{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"streaming_optimized": optimized,
+			"streaming_error":     failure,
+		}).Error
+}
+
+// UpdateMediaInfo stores probed container metadata of a video
+func (r *VideoRepository) UpdateMediaInfo(video *models.Video) error {
+	return r.db.Model(&models.Video{}).Where("id = ?", video.ID).
+		Updates(map[string]interface{}{
+			"container":        video.Container,
+			"width":            video.Width,
+			"height":           video.Height,
+			"video_codec":      video.VideoCodec,
+			"audio_codec":      video.AudioCodec,
+			"bitrate_kbps":     video.BitrateKbps,
+			"duration_seconds": video.DurationSeconds,
+		}).Error
+}
+
+// ReplaceStoredFile points a video at a new pCloud file with the same content
+// layout (e.g. after moving moov to the front) and marks it streaming-optimized.
+// The content SHA-256 stays that of the original upload, which duplicates match.
+func (r *VideoRepository) ReplaceStoredFile(id uuid.UUID, fileID, pcloudHash, sourceURL string, expiresAt time.Time) error {
+	return r.db.Model(&models.Video{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"pcloud_file_id":         fileID,
+			"content_hash":           pcloudHash,
+			"source_url":             sourceURL,
+			"source_url_expires_at":  expiresAt,
+			"link_refresh_failures":  0,
+			"link_refresh_error":     "",
+			"link_refresh_failed_at": nil,
+			"link_refresh_retry_at":  nil,
+			"streaming_optimized":    true,
+			"streaming_error":        nil,
+		}).Error
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"bobastream/internal/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrVideoVersionConflict is returned when a video was edited since the version an edit is based on
+var ErrVideoVersionConflict = errors.New("video was changed by someone else; reload and try again")
+
+type VideoRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewVideoRevisionRepository(db *gorm.DB) *VideoRevisionRepository {
+	return &VideoRevisionRepository{db: db}
+}
+
+// SaveEdit writes the metadata of a video if it is still at version and
+// records the revision, both or neither. On success the video and the
+// revision carry the new version.
+func (r *VideoRevisionRepository) SaveEdit(video *models.Video, version int, revision *models.VideoRevision) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The version column is read-only to gorm, so Save never rolls it back
+		result := tx.Exec("UPDATE videos SET version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL", video.ID, version)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVideoVersionConflict
+		}
+
+		err := tx.Model(&models.Video{}).
+			Where("id = ?", video.ID).
+			Updates(map[string]interface{}{
+				"title":         video.Title,
+				"description":   video.Description,
+				"thumbnail_url": video.ThumbnailURL,
+				"category_id":   video.CategoryID,
+				"tags":          video.Tags,
+				"is_published":  video.IsPublished,
+				"published_at":  video.PublishedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		revision.VideoID = video.ID
+		revision.Version = version + 1
+		return tx.Create(revision).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	video.Version = version + 1
+	return nil
+}
+
+// GetByVideo gets the revisions of a video with their editors, newest first
+func (r *VideoRevisionRepository) GetByVideo(videoID uuid.UUID, page, limit int) ([]models.VideoRevision, int64, error) {
+	var revisions []models.VideoRevision
+	var total int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.VideoRevision{}).Where("video_id = ?", videoID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Editor").
+		Order("version DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&revisions).Error
+
+	return revisions, total, err
+}
+
+// GetAfter gets the revisions of a video newer than a version, newest first
+func (r *VideoRevisionRepository) GetAfter(videoID uuid.UUID, version int) ([]models.VideoRevision, error) {
+	var revisions []models.VideoRevision
+	err := r.db.Where("video_id = ? AND version > ?", videoID, version).
+		Order("version DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
@@ -2,11 +2,26 @@ package repositories
 
 import (
 	"bobastream/internal/models"
+	"errors"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrSlugTaken is returned when another wrapper link took the slug first
+var ErrSlugTaken = errors.New("slug is already taken")
+
+// slugTaken maps a violation of the unique wrapper_links.slug to ErrSlugTaken
+func slugTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "wrapper_links_slug_key" {
+		return ErrSlugTaken
+	}
+	return err
+}
+
 type WrapperLinkRepository struct {
 	db *gorm.DB
 }
@@ -15,9 +30,9 @@ func NewWrapperLinkRepository(db *gorm.DB) *WrapperLinkRepository {
 	return &WrapperLinkRepository{db: db}
 }
 
-// Create creates a new wrapper link
+// Create creates a new wrapper link; ErrSlugTaken if its slug is in use
 func (r *WrapperLinkRepository) Create(link *models.WrapperLink) error {
-	return r.db.Create(link).Error
+	return slugTaken(r.db.Create(link).Error)
 }
 
 // FindByToken finds wrapper link by token
@@ -72,4 +87,54 @@ func (r *WrapperLinkRepository) SlugExists(slug string) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.WrapperLink{}).Where("slug = ?", slug).Count(&count).Error
 	return count > 0, err
+}
+
+// FindByOldSlug finds the wrapper link a slug belonged to before a rename
+func (r *WrapperLinkRepository) FindByOldSlug(slug string) (*models.WrapperLink, error) {
+	var link models.WrapperLink
+	err := r.db.Where("id = (?)", r.db.Model(&models.WrapperLinkSlug{}).Select("wrapper_link_id").Where("slug = ?", slug)).
+		First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// SlugAvailable checks that a slug is neither the current nor an old slug of
+// another wrapper link than linkID
+func (r *WrapperLinkRepository) SlugAvailable(slug string, linkID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.WrapperLink{}).Where("slug = ? AND id <> ?", slug, linkID).Count(&count).Error
+	if err != nil || count > 0 {
+		return false, err
+	}
+	err = r.db.Model(&models.WrapperLinkSlug{}).Where("slug = ? AND wrapper_link_id <> ?", slug, linkID).Count(&count).Error
+	return count == 0, err
+}
+
+// ChangeSlug gives a wrapper link a new slug, keeping the old one in the slug
+// history. A link renamed back to an old slug takes it out of the history.
+// ErrSlugTaken if another link has the slug.
+func (r *WrapperLinkRepository) ChangeSlug(link *models.WrapperLink, slug string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if link.Slug != "" {
+			old := models.WrapperLinkSlug{Slug: link.Slug, WrapperLinkID: link.ID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&old).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Where("slug = ? AND wrapper_link_id = ?", slug, link.ID).Delete(&models.WrapperLinkSlug{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.WrapperLink{}).Where("id = ?", link.ID).Update("slug", slug).Error
+	})
+	if err != nil {
+		return slugTaken(err)
+	}
+
+	link.Slug = slug
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SelectionStrategy decides which pCloud account receives a new upload
+type SelectionStrategy string
+
+const (
+	StrategyMostFree           SelectionStrategy = "most_free"            // Most free storage
+	StrategyRoundRobin         SelectionStrategy = "round_robin"          // Account that received an upload longest ago
+	StrategyLeastRecentTraffic SelectionStrategy = "least_recent_traffic" // Least streaming traffic in the traffic window
+	StrategyWeighted           SelectionStrategy = "weighted"             // Random, proportional to each account's weight
+	StrategyTagPinned          SelectionStrategy = "tag_pinned"           // Accounts pinned to the video's category or tags
+)
+
+// SelectionStrategies lists every supported strategy
+var SelectionStrategies = []SelectionStrategy{
+	StrategyMostFree,
+	StrategyRoundRobin,
+	StrategyLeastRecentTraffic,
+	StrategyWeighted,
+	StrategyTagPinned,
+}
+
+// selectionStrategySetting is the settings key of the admin override
+const selectionStrategySetting = "pcloud.selection_strategy"
+
+// minFreeStorageGB keeps nearly full accounts out of rotation (100MB)
+const minFreeStorageGB = 0.1
+
+// maxFileShareOfAccount caps a single file at 10% of an account's total storage
+const maxFileShareOfAccount = 0.1
+
+// ValidSelectionStrategy reports whether s names a supported strategy
+func ValidSelectionStrategy(s string) bool {
+	for _, strategy := range SelectionStrategies {
+		if string(strategy) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadPlacement describes an upload for account selection
+type UploadPlacement struct {
+	SizeBytes  int64
+	CategoryID *uuid.UUID
+	Tags       []string
+}
+
+// AccountCandidate is one account as seen by the selector
+type AccountCandidate struct {
+	Account       *models.PCloudCredential `json:"account"`
+	FreeGB        float64                  `json:"free_gb"`
+	RecentTraffic int64                    `json:"recent_traffic_bytes"`
+	Eligible      bool                     `json:"eligible"`
+	Reason        string                   `json:"reason,omitempty"` // Why the account was excluded
+	PinnedMatch   bool                     `json:"pinned_match,omitempty"`
+}
+
+// AccountSelection is the outcome of choosing an account
+type AccountSelection struct {
+	Strategy   SelectionStrategy        `json:"strategy"`
+	Source     string                   `json:"source"` // "config" or "admin"
+	Account    *models.PCloudCredential `json:"account"`
+	Candidates []AccountCandidate       `json:"candidates"`
+}
+
+// AccountSelector picks the pCloud account for new uploads
+type AccountSelector struct {
+	pcloudRepo      *repositories.PCloudCredentialRepository
+	videoRepo       *repositories.VideoRepository
+	categoryRepo    *repositories.CategoryRepository
+	settingRepo     *repositories.SettingRepository
+	defaultStrategy SelectionStrategy
+	trafficWindow   time.Duration
+
+	mu  sync.Mutex // Serializes selections so round robin advances once per upload
+	rnd *rand.Rand
+}
+
+func NewAccountSelector(
+	pcloudRepo *repositories.PCloudCredentialRepository,
+	videoRepo *repositories.VideoRepository,
+	categoryRepo *repositories.CategoryRepository,
+	settingRepo *repositories.SettingRepository,
+	defaultStrategy string,
+	trafficWindow time.Duration,
+) *AccountSelector {
+	if !ValidSelectionStrategy(defaultStrategy) {
+		fmt.Printf("⚠️  WARNING: Unknown pCloud selection strategy '%s', using %s\n", defaultStrategy, StrategyMostFree)
+		defaultStrategy = string(StrategyMostFree)
+	}
+	if trafficWindow <= 0 {
+		trafficWindow = 24 * time.Hour
+	}
+
+	return &AccountSelector{
+		pcloudRepo:      pcloudRepo,
+		videoRepo:       videoRepo,
+		categoryRepo:    categoryRepo,
+		settingRepo:     settingRepo,
+		defaultStrategy: SelectionStrategy(defaultStrategy),
+		trafficWindow:   trafficWindow,
+		rnd:             rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// DefaultStrategy gets the strategy configured in the environment
+func (s *AccountSelector) DefaultStrategy() SelectionStrategy {
+	return s.defaultStrategy
+}
+
+// Strategy gets the active strategy and whether it comes from "config" or "admin"
+func (s *AccountSelector) Strategy() (SelectionStrategy, string, error) {
+	value, ok, err := s.settingRepo.Get(selectionStrategySetting)
+	if err != nil {
+		return "", "", err
+	}
+	if !ok || !ValidSelectionStrategy(value) {
+		return s.defaultStrategy, "config", nil
+	}
+	return SelectionStrategy(value), "admin", nil
+}
+
+// SetStrategy overrides the configured strategy (admin); an empty strategy restores the default
+func (s *AccountSelector) SetStrategy(strategy string) error {
+	if strategy == "" {
+		return s.settingRepo.Delete(selectionStrategySetting)
+	}
+	if !ValidSelectionStrategy(strategy) {
+		return fmt.Errorf("unknown selection strategy '%s'", strategy)
+	}
+	return s.settingRepo.Set(selectionStrategySetting, strategy)
+}
+
+// Select chooses the account for an upload and records the choice
+func (s *AccountSelector) Select(placement UploadPlacement) (*models.PCloudCredential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	selection, err := s.evaluate(placement)
+	if err != nil {
+		return nil, err
+	}
+	if selection.Account == nil {
+		return nil, noAccountError(placement)
+	}
+
+	if err := s.pcloudRepo.MarkSelected(selection.Account.ID); err != nil {
+		// Non-critical: only round robin ordering is affected
+		fmt.Printf("⚠️  WARNING: Failed to record selection of account '%s': %v\n", selection.Account.AccountName, err)
+	}
+
+	return selection.Account, nil
+}
+
+// Preview shows which account an upload would land on, without recording it (admin).
+// Weighted selection is random, so a preview only shows one possible outcome.
+func (s *AccountSelector) Preview(placement UploadPlacement) (*AccountSelection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.evaluate(placement)
+}
+
+// evaluate checks every account in rotation and applies the active strategy
+func (s *AccountSelector) evaluate(placement UploadPlacement) (*AccountSelection, error) {
+	strategy, source, err := s.Strategy()
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.pcloudRepo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) == 0 {
+		return nil, errors.New("no active pCloud accounts available")
+	}
+
+	var traffic map[uuid.UUID]int64
+	if strategy == StrategyLeastRecentTraffic {
+		traffic, err = s.videoRepo.GetTrafficByCredential(time.Now().Add(-s.trafficWindow))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pins map[string]bool
+	if strategy == StrategyTagPinned {
+		pins = s.placementPins(placement)
+	}
+
+	fileSizeGB := float64(placement.SizeBytes) / (1024 * 1024 * 1024)
+
+	selection := &AccountSelection{
+		Strategy:   strategy,
+		Source:     source,
+		Candidates: make([]AccountCandidate, 0, len(credentials)),
+	}
+
+	for i := range credentials {
+		credential := &credentials[i]
+		candidate := AccountCandidate{
+			Account:       credential,
+			FreeGB:        credential.StorageLimitGB - credential.StorageUsedGB,
+			RecentTraffic: traffic[credential.ID],
+			Eligible:      true,
+		}
+
+		switch {
+		case candidate.FreeGB < minFreeStorageGB:
+			candidate.Eligible = false
+			candidate.Reason = "less than 100MB free"
+		case fileSizeGB*storageOverheadFactor > candidate.FreeGB:
+			candidate.Eligible = false
+			candidate.Reason = fmt.Sprintf("file (%.2fGB) exceeds free storage (%.2fGB)", fileSizeGB, candidate.FreeGB)
+		case fileSizeGB > credential.StorageLimitGB*maxFileShareOfAccount:
+			candidate.Eligible = false
+			candidate.Reason = fmt.Sprintf("file (%.2fGB) exceeds 10%% of account storage", fileSizeGB)
+		}
+
+		if strategy == StrategyTagPinned {
+			for _, tag := range credential.PinnedTags {
+				if pins[strings.ToLower(tag)] {
+					candidate.PinnedMatch = true
+					break
+				}
+			}
+		}
+
+		selection.Candidates = append(selection.Candidates, candidate)
+	}
+
+	if strategy == StrategyTagPinned {
+		restrictToPinned(selection.Candidates)
+	}
+
+	if chosen := s.choose(strategy, selection.Candidates); chosen != nil {
+		selection.Account = chosen.Account
+	}
+
+	return selection, nil
+}
+
+// choose picks one eligible candidate according to the strategy
+func (s *AccountSelector) choose(strategy SelectionStrategy, candidates []AccountCandidate) *AccountCandidate {
+	eligible := make([]*AccountCandidate, 0, len(candidates))
+	for i := range candidates {
+		if candidates[i].Eligible {
+			eligible = append(eligible, &candidates[i])
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	// Ties go to the account with the most free storage
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return eligible[i].FreeGB > eligible[j].FreeGB
+	})
+
+	switch strategy {
+	case StrategyRoundRobin:
+		sort.SliceStable(eligible, func(i, j int) bool {
+			a, b := eligible[i].Account.LastSelectedAt, eligible[j].Account.LastSelectedAt
+			if a == nil || b == nil {
+				return a == nil && b != nil // Never-used accounts first
+			}
+			return a.Before(*b)
+		})
+
+	case StrategyLeastRecentTraffic:
+		sort.SliceStable(eligible, func(i, j int) bool {
+			return eligible[i].RecentTraffic < eligible[j].RecentTraffic
+		})
+
+	case StrategyWeighted:
+		total := 0
+		for _, c := range eligible {
+			total += c.Account.SelectionWeight
+		}
+		pick := s.rnd.Intn(total)
+		for _, c := range eligible {
+			pick -= c.Account.SelectionWeight
+			if pick < 0 {
+				return c
+			}
+		}
+	}
+
+	return eligible[0]
+}
+
+// restrictToPinned keeps accounts pinned to the upload when there are any;
+// otherwise pinned accounts stay reserved and only unpinned ones are used,
+// falling back to every account when all of them are pinned elsewhere
+func restrictToPinned(candidates []AccountCandidate) {
+	matched, unpinned := false, false
+	for _, c := range candidates {
+		if !c.Eligible {
+			continue
+		}
+		if c.PinnedMatch {
+			matched = true
+		}
+		if len(c.Account.PinnedTags) == 0 {
+			unpinned = true
+		}
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+		if !c.Eligible {
+			continue
+		}
+		switch {
+		case matched && !c.PinnedMatch:
+			c.Eligible = false
+			c.Reason = "not pinned to this category or tags"
+		case !matched && unpinned && len(c.Account.PinnedTags) > 0:
+			c.Eligible = false
+			c.Reason = "reserved for pinned categories or tags"
+		}
+	}
+}
+
+// placementPins collects the lowercase category slug and tags an account can be pinned to
+func (s *AccountSelector) placementPins(placement UploadPlacement) map[string]bool {
+	pins := make(map[string]bool, len(placement.Tags)+1)
+	for _, tag := range placement.Tags {
+		pins[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	if placement.CategoryID != nil {
+		if category, err := s.categoryRepo.FindByID(*placement.CategoryID); err == nil {
+			pins[strings.ToLower(category.Slug)] = true
+		}
+	}
+
+	delete(pins, "")
+	return pins
+}
+
+// noAccountError explains why no account could take an upload
+func noAccountError(placement UploadPlacement) error {
+	if placement.SizeBytes > 0 {
+		return fmt.Errorf("no pCloud account with sufficient storage available for file (%.2fGB)",
+			float64(placement.SizeBytes)/(1024*1024*1024))
+	}
+	return errors.New("no pCloud account with sufficient storage available")
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bobastream/internal/media"
+	"bobastream/internal/models"
+	"bobastream/internal/pcloud"
+	"bobastream/internal/repositories"
+	"bobastream/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Catalog import item actions
+const (
+	CatalogActionImport   = "import"   // Would be imported (dry run)
+	CatalogActionImported = "imported" // Video created
+	CatalogActionSkip     = "skip"     // Already imported
+	CatalogActionFailed   = "failed"
+)
+
+// videoExtensions are treated as videos even when pCloud reports a generic content type
+var videoExtensions = map[string]bool{
+	".mp4": true, ".m4v": true, ".mov": true, ".webm": true,
+	".mkv": true, ".avi": true, ".wmv": true, ".flv": true,
+}
+
+// CatalogImportOptions selects the folder to scan and how to import it
+type CatalogImportOptions struct {
+	CredentialID     uuid.UUID
+	FolderID         int64  // Folder to scan; 0 is the account root
+	FolderPath       string // Used instead of FolderID when set
+	Recursive        bool   // Also scan subfolders
+	DryRun           bool   // Only report what would be imported
+	CreateCategories bool   // Create categories for folder names that match none
+	Publish          bool   // Publish imported videos; otherwise they are drafts to review first
+	Limit            int    // Max videos to import in this run; 0 means no limit
+}
+
+// CatalogImportItem is the outcome for one video file
+type CatalogImportItem struct {
+	FileID          int64      `json:"file_id"`
+	Path            string     `json:"path"`
+	SizeBytes       int64      `json:"size_bytes"`
+	Title           string     `json:"title"`
+	Category        string     `json:"category,omitempty"`
+	CategoryID      *uuid.UUID `json:"category_id,omitempty"`
+	DurationSeconds int        `json:"duration_seconds,omitempty"`
+	Action          string     `json:"action"`
+	Reason          string     `json:"reason,omitempty"`
+	VideoID         *uuid.UUID `json:"video_id,omitempty"`
+}
+
+// CatalogImportResult summarizes a catalog import run
+type CatalogImportResult struct {
+	DryRun    bool                `json:"dry_run"`
+	Publish   bool                `json:"publish"`   // Whether imported videos are published or drafts
+	Scanned   int                 `json:"scanned"`   // Video files found
+	Imported  int                 `json:"imported"`  // Imported, or would be on a dry run
+	Skipped   int                 `json:"skipped"`   // Already imported
+	Failed    int                 `json:"failed"`    // Import errors
+	Remaining int                 `json:"remaining"` // Left for a later run because of Limit
+	Items     []CatalogImportItem `json:"items"`
+}
+
+// CatalogImportService creates videos for files already stored on pCloud
+type CatalogImportService struct {
+	pcloudRepo      *repositories.PCloudCredentialRepository
+	videoRepo       *repositories.VideoRepository
+	pcloudService   *PCloudService
+	videoService    *VideoService
+	categoryService *CategoryService
+	probeClient     *http.Client
+}
+
+func NewCatalogImportService(
+	pcloudRepo *repositories.PCloudCredentialRepository,
+	videoRepo *repositories.VideoRepository,
+	pcloudService *PCloudService,
+	videoService *VideoService,
+	categoryService *CategoryService,
+) *CatalogImportService {
+	return &CatalogImportService{
+		pcloudRepo:      pcloudRepo,
+		videoRepo:       videoRepo,
+		pcloudService:   pcloudService,
+		videoService:    videoService,
+		categoryService: categoryService,
+		probeClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// catalogFile is a video file found while scanning, with its parent folder name
+type catalogFile struct {
+	meta   pcloud.Metadata
+	path   string
+	folder string
+}
+
+// Import scans a pCloud folder and creates a video for every video file not imported yet
+func (s *CatalogImportService) Import(ctx context.Context, opts CatalogImportOptions) (*CatalogImportResult, error) {
+	credential, err := s.pcloudRepo.FindByID(opts.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("pCloud account not found: %w", err)
+	}
+
+	root, err := s.pcloudService.ListFolder(ctx, credential, opts.FolderID, pcloud.ListFolderOptions{
+		Path:      opts.FolderPath,
+		Recursive: opts.Recursive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.videoRepo.GetFileIDsByCredential(credential.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rootPath := opts.FolderPath
+	if rootPath == "" {
+		rootPath = "/"
+	}
+	var files []catalogFile
+	collectVideoFiles(root, rootPath, &files)
+
+	result := &CatalogImportResult{
+		DryRun:  opts.DryRun,
+		Publish: opts.Publish,
+		Items:   []CatalogImportItem{},
+	}
+	categories := make(map[string]resolvedCategory) // Resolved per folder name
+
+	for _, file := range files {
+		result.Scanned++
+
+		item := CatalogImportItem{
+			FileID:    file.meta.FileID,
+			Path:      file.path,
+			SizeBytes: file.meta.Size,
+			Title:     titleFromFilename(file.meta.Name),
+			Category:  file.folder,
+		}
+
+		if existing[strconv.FormatInt(file.meta.FileID, 10)] {
+			item.Action = CatalogActionSkip
+			item.Reason = "already imported"
+			result.Skipped++
+			result.Items = append(result.Items, item)
+			continue
+		}
+
+		if opts.Limit > 0 && result.Imported+result.Failed >= opts.Limit {
+			result.Remaining++
+			continue
+		}
+
+		category, note, err := s.resolveCategory(file.folder, opts, categories)
+		if err != nil {
+			item.Action = CatalogActionFailed
+			item.Reason = "category: " + err.Error()
+			result.Failed++
+			result.Items = append(result.Items, item)
+			continue
+		}
+		if category != nil {
+			item.CategoryID = &category.ID
+			item.Category = category.Name
+		}
+
+		if opts.DryRun {
+			item.Action = CatalogActionImport
+			item.Reason = note
+			result.Imported++
+			result.Items = append(result.Items, item)
+			continue
+		}
+
+		if err := s.importFile(ctx, credential, file, category, opts.Publish, &item); err != nil {
+			item.Action = CatalogActionFailed
+			item.Reason = err.Error()
+			result.Failed++
+			result.Items = append(result.Items, item)
+
+			// Stop early on cancellation instead of failing every remaining file
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			continue
+		}
+
+		item.Action = CatalogActionImported
+		result.Imported++
+		result.Items = append(result.Items, item)
+	}
+
+	return result, nil
+}
+
+// importFile probes one file and creates its video and wrapper link
+func (s *CatalogImportService) importFile(ctx context.Context, credential *models.PCloudCredential, file catalogFile, category *models.Category, publish bool, item *CatalogImportItem) error {
+	sourceURL, expiresAt, err := s.pcloudService.GetFileLink(ctx, credential, file.meta.FileID)
+	if err != nil {
+		return fmt.Errorf("failed to get pCloud link: %w", err)
+	}
+
+	// Metadata is best effort: an unparsable file is still imported, but
+	// a file without a video track is not
+	reader := media.NewHTTPReaderAt(ctx, s.probeClient, sourceURL, file.meta.Size)
+	mediaInfo, err := s.videoService.ProbeMedia(reader, file.meta.Size)
+	switch {
+	case errors.Is(err, media.ErrNoVideoTrack):
+		return err
+	case err != nil:
+		item.Reason = "metadata unknown: " + err.Error()
+	case mediaInfo == nil:
+		item.Reason = "metadata unknown: unsupported container"
+	default:
+		item.DurationSeconds = mediaInfo.DurationSeconds()
+	}
+
+	var categoryID *uuid.UUID
+	if category != nil {
+		categoryID = &category.ID
+	}
+
+	upload := &UploadResult{
+		Credential: credential,
+		FileID:     file.meta.FileID,
+		Hash:       strconv.FormatUint(file.meta.Hash, 10),
+		Size:       file.meta.Size,
+	}
+
+	video, _, err := s.videoService.CreateUploadedVideo(VideoMetadata{
+		Title:           item.Title,
+		CategoryID:      categoryID,
+		DurationSeconds: item.DurationSeconds,
+		Media:           mediaInfo,
+		Draft:           !publish, // Titles come from filenames
+
+		StreamingOptimized: storedStreamingState(reader, file.meta.Size, mediaInfo),
+	}, upload, sourceURL, expiresAt)
+	if video != nil {
+		item.VideoID = &video.ID
+	}
+	if err != nil {
+		return err
+	}
+
+	// The file was never counted against the account, unlike regular uploads
+	if err := s.pcloudService.AddStorage(credential.ID, file.meta.Size); err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to update storage tracking for account '%s': %v\n",
+			credential.AccountName, err)
+	}
+
+	return nil
+}
+
+// resolveCategory maps a folder name to a category, creating it when allowed.
+// The note explains what a dry run would do.
+func (s *CatalogImportService) resolveCategory(folder string, opts CatalogImportOptions, cache map[string]resolvedCategory) (*models.Category, string, error) {
+	if folder == "" {
+		return nil, "", nil
+	}
+
+	key := strings.ToLower(folder)
+	if resolved, ok := cache[key]; ok {
+		return resolved.category, resolved.note, nil
+	}
+
+	category, err := s.categoryService.FindByNameOrSlug(folder)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	resolved := resolvedCategory{category: category}
+	switch {
+	case category != nil:
+	case !opts.CreateCategories:
+		resolved.note = "no category named '" + folder + "'"
+	case opts.DryRun:
+		resolved.note = "category '" + folder + "' will be created"
+	default:
+		name := utils.TruncateString(utils.SanitizeString(folder), 100)
+		resolved.category, err = s.categoryService.CreateCategory(name, "", "", nil)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	cache[key] = resolved
+	return resolved.category, resolved.note, nil
+}
+
+// resolvedCategory caches the category chosen for a folder name
+type resolvedCategory struct {
+	category *models.Category
+	note     string
+}
+
+// collectVideoFiles walks a listing and gathers video files with their folder name
+func collectVideoFiles(folder *pcloud.Metadata, folderPath string, files *[]catalogFile) {
+	folderName := folder.Name
+	if folderName == "/" {
+		folderName = ""
+	}
+
+	for i := range folder.Contents {
+		entry := &folder.Contents[i]
+		entryPath := path.Join(folderPath, entry.Name)
+
+		if entry.IsFolder {
+			collectVideoFiles(entry, entryPath, files)
+			continue
+		}
+		if !isVideoFile(entry) {
+			continue
+		}
+
+		*files = append(*files, catalogFile{
+			meta:   *entry,
+			path:   entryPath,
+			folder: folderName,
+		})
+	}
+}
+
+// isVideoFile checks pCloud's content type, falling back to the extension
+func isVideoFile(meta *pcloud.Metadata) bool {
+	if strings.HasPrefix(meta.ContentType, "video/") {
+		return true
+	}
+	return videoExtensions[strings.ToLower(path.Ext(meta.Name))]
+}
+
+// titleFromFilename turns "My_Video.final-cut.mp4" into "My Video final-cut"
+func titleFromFilename(name string) string {
+	title := strings.TrimSuffix(name, path.Ext(name))
+
+	title = strings.Map(func(r rune) rune {
+		if r == '_' || r == '.' || unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, title)
+
+	// Names without spaces often use dashes as separators
+	if !strings.Contains(title, " ") {
+		title = strings.ReplaceAll(title, "-", " ")
+	}
+
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		title = name
+	}
+
+	return utils.TruncateString(utils.SanitizeString(title), 500)
+}
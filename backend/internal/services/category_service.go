@@ -1,12 +1,63 @@
 package services
 
 import (
+	"bobastream/internal/cache"
 	"bobastream/internal/models"
 	"bobastream/internal/repositories"
+	"bobastream/internal/utils"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"errors"
+	"html"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrCategoryNameTaken    = errors.New("category name already exists")
+	ErrCategorySlugTaken    = errors.New("category slug already exists")
+	ErrCategoryInUse        = errors.New("category still has videos")
+	ErrCategoryMoveToSelf   = errors.New("videos cannot be moved to the category being deleted")
+	ErrCategoryNoTarget     = errors.New("target category not found")
+	ErrCategoryInvalidOrder = errors.New("order must list existing categories at most once")
+	ErrCategoryNoParent     = repositories.ErrCategoryNoParent
+	ErrCategoryCycle        = repositories.ErrCategoryCycle
+	ErrCategoryHasChildren  = errors.New("category still has subcategories")
+)
+
+// CategoryMove gives a category a new parent, nil for the root
+type CategoryMove = repositories.CategoryMove
+
+// categoryTreeCacheKey caches the tree of active categories
+const categoryTreeCacheKey = "categories:tree"
+
+// CategoryNode is a category in the category tree. VideoCount counts its own
+// published videos, TotalVideoCount those of its whole subtree.
+type CategoryNode struct {
+	repositories.CategoryCount
+	TotalVideoCount int64           `json:"total_video_count"`
+	Children        []*CategoryNode `json:"children"`
+}
+
+// CategoryBreadcrumb is one step on the path from a root category down to a category
+type CategoryBreadcrumb struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Slug string    `json:"slug"`
+}
+
+// CategoryVideoAction says what happens to the videos of a deleted category
+type CategoryVideoAction string
+
+const (
+	CategoryVideosRefuse   CategoryVideoAction = "refuse"   // Keep the category while it has videos
+	CategoryVideosUnset    CategoryVideoAction = "unset"    // Leave the videos uncategorized
+	CategoryVideosReassign CategoryVideoAction = "reassign" // Move the videos to another category
 )
 
 type CategoryService struct {
@@ -17,30 +68,39 @@ func NewCategoryService(categoryRepo *repositories.CategoryRepository) *Category
 	return &CategoryService{categoryRepo: categoryRepo}
 }
 
-// CreateCategory creates a new category
-func (s *CategoryService) CreateCategory(name, description, icon string) (*models.Category, error) {
-	// Check if name exists
-	exists, err := s.categoryRepo.NameExists(name)
+// CreateCategory creates a new category below parentID, or a root category when nil
+func (s *CategoryService) CreateCategory(name, description, icon string, parentID *uuid.UUID) (*models.Category, error) {
+	if parentID != nil {
+		if _, err := s.GetCategoryByID(*parentID); errors.Is(err, ErrCategoryNotFound) {
+			return nil, ErrCategoryNoParent
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if name exists among the siblings
+	exists, err := s.categoryRepo.NameExists(parentID, name)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("category name already exists")
+		return nil, ErrCategoryNameTaken
 	}
 
 	// Generate slug from name
 	slug := s.generateSlug(name)
 
-	// Check if slug exists
-	slugExists, err := s.categoryRepo.SlugExists(slug)
+	// Check if slug exists among the siblings
+	slugExists, err := s.categoryRepo.SlugExists(parentID, slug)
 	if err != nil {
 		return nil, err
 	}
 	if slugExists {
-		return nil, errors.New("category slug already exists")
+		return nil, ErrCategorySlugTaken
 	}
 
 	category := &models.Category{
+		ParentID:    parentID,
 		Name:        name,
 		Slug:        slug,
 		Description: description,
@@ -51,29 +111,67 @@ func (s *CategoryService) CreateCategory(name, description, icon string) (*model
 	if err := s.categoryRepo.Create(category); err != nil {
 		return nil, err
 	}
+	s.invalidateTree()
 
 	return category, nil
 }
 
-// UpdateCategory updates category
-func (s *CategoryService) UpdateCategory(id uuid.UUID, name, description, icon string, displayOrder int) (*models.Category, error) {
-	category, err := s.categoryRepo.FindByID(id)
+// UpdateCategory updates category; empty name, description and icon are left
+// unchanged. A move takes the category with its subcategories below another
+// category (or to the root) in the same write, so a failed update moves nothing.
+func (s *CategoryService) UpdateCategory(id uuid.UUID, name, description, icon string, displayOrder int, move *CategoryMove) (*models.Category, error) {
+	category, err := s.GetCategoryByID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	// If name changed, regenerate slug and check uniqueness
-	if name != "" && name != category.Name {
-		slug := s.generateSlug(name)
-		slugExists, err := s.categoryRepo.SlugExists(slug)
+	parentID := category.ParentID
+	if move != nil && sameParent(category.ParentID, move.ParentID) {
+		move = nil
+	}
+	if move != nil {
+		parentID = move.ParentID
+		if parentID != nil {
+			parent, err := s.GetCategoryByID(*parentID)
+			if errors.Is(err, ErrCategoryNotFound) {
+				return nil, ErrCategoryNoParent
+			}
+			if err != nil {
+				return nil, err
+			}
+			// Checked again on current paths when moving
+			if parent.ID == category.ID || strings.HasPrefix(parent.Path, category.Path+"/") {
+				return nil, ErrCategoryCycle
+			}
+		}
+	}
+
+	// Name and slug must be unique among the siblings at the (new) parent
+	renamed := name != "" && name != category.Name
+	if renamed || move != nil {
+		newName, newSlug := category.Name, category.Slug
+		if renamed {
+			newName, newSlug = name, s.generateSlug(name)
+		}
+
+		nameExists, err := s.categoryRepo.NameExists(parentID, newName)
 		if err != nil {
 			return nil, err
 		}
-		if slugExists {
-			return nil, errors.New("category slug already exists")
+		if nameExists {
+			return nil, ErrCategoryNameTaken
 		}
-		category.Name = name
-		category.Slug = slug
+		if move != nil || newSlug != category.Slug {
+			slugExists, err := s.categoryRepo.SlugExists(parentID, newSlug)
+			if err != nil {
+				return nil, err
+			}
+			if slugExists {
+				return nil, ErrCategorySlugTaken
+			}
+		}
+		category.Name = newName
+		category.Slug = newSlug
 	}
 
 	if description != "" {
@@ -86,26 +184,182 @@ func (s *CategoryService) UpdateCategory(id uuid.UUID, name, description, icon s
 
 	category.DisplayOrder = displayOrder
 
-	if err := s.categoryRepo.Update(category); err != nil {
+	if err := s.categoryRepo.Update(category, move); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
 		return nil, err
 	}
+	s.invalidateTree()
 
 	return category, nil
 }
 
-// DeleteCategory soft deletes category
-func (s *CategoryService) DeleteCategory(id uuid.UUID) error {
-	return s.categoryRepo.Delete(id)
+// DeleteCategory soft deletes category. Its videos, trashed ones included,
+// are left uncategorized or moved to target depending on action; with
+// CategoryVideosRefuse a category with videos is kept. Returns the number of
+// videos moved.
+func (s *CategoryService) DeleteCategory(id uuid.UUID, action CategoryVideoAction, target *uuid.UUID) (int64, error) {
+	if _, err := s.GetCategoryByID(id); err != nil {
+		return 0, err
+	}
+
+	children, err := s.categoryRepo.CountChildren(id)
+	if err != nil {
+		return 0, err
+	}
+	if children > 0 {
+		return 0, ErrCategoryHasChildren
+	}
+
+	switch action {
+	case CategoryVideosReassign:
+		if target == nil || *target == id {
+			return 0, ErrCategoryMoveToSelf
+		}
+		if _, err := s.GetCategoryByID(*target); errors.Is(err, ErrCategoryNotFound) {
+			return 0, ErrCategoryNoTarget
+		} else if err != nil {
+			return 0, err
+		}
+	case CategoryVideosUnset:
+		target = nil
+	default:
+		count, err := s.categoryRepo.CountVideos(id)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			return 0, ErrCategoryInUse
+		}
+		target = nil
+	}
+
+	moved, err := s.categoryRepo.DeleteAndMoveVideos(id, target)
+	if err != nil {
+		return 0, err
+	}
+	s.invalidateTree()
+	invalidateSyndication() // Category feeds and sitemaps
+	if moved > 0 {
+		ctx := context.Background()
+		cache.DeletePattern(ctx, "feed:*")
+		cache.DeletePattern(ctx, relatedCachePattern)
+	}
+
+	return moved, nil
 }
 
 // GetCategoryByID gets category by ID
 func (s *CategoryService) GetCategoryByID(id uuid.UUID) (*models.Category, error) {
-	return s.categoryRepo.FindByID(id)
+	category, err := s.categoryRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// GetCategoryBySlug gets a category by its slug path from the root, e.g. "music/live"
+func (s *CategoryService) GetCategoryBySlug(slugPath string) (*models.Category, error) {
+	var category *models.Category
+	var parentID *uuid.UUID
+	for _, slug := range strings.Split(strings.Trim(slugPath, "/"), "/") {
+		var err error
+		category, err = s.categoryRepo.FindBySlug(parentID, slug)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		parentID = &category.ID
+	}
+	return category, nil
+}
+
+// GetSubtreeIDs gets the IDs of a category and its active descendants
+func (s *CategoryService) GetSubtreeIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	category, err := s.GetCategoryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.categoryRepo.SubtreeIDs(category)
+}
+
+// GetBreadcrumbs gets the path from the root category down to a category
+func (s *CategoryService) GetBreadcrumbs(category *models.Category) ([]CategoryBreadcrumb, error) {
+	ancestors := []models.Category{*category}
+	if category.Depth > 0 {
+		var err error
+		if ancestors, err = s.categoryRepo.FindByIDs(category.AncestorIDs()); err != nil {
+			return nil, err
+		}
+	}
+
+	breadcrumbs := make([]CategoryBreadcrumb, len(ancestors))
+	for i, ancestor := range ancestors {
+		breadcrumbs[i] = CategoryBreadcrumb{ID: ancestor.ID, Name: ancestor.Name, Slug: ancestor.Slug}
+	}
+	return breadcrumbs, nil
 }
 
-// GetCategoryBySlug gets category by slug
-func (s *CategoryService) GetCategoryBySlug(slug string) (*models.Category, error) {
-	return s.categoryRepo.FindBySlug(slug)
+// ✅ CACHED: GetCategoryTree gets the active categories as a tree, siblings
+// in display order. Subcategories of inactive categories are left out.
+func (s *CategoryService) GetCategoryTree() ([]*CategoryNode, error) {
+	ctx := context.Background()
+
+	var tree []*CategoryNode
+	if err := cache.Get(ctx, categoryTreeCacheKey, &tree); err == nil {
+		return tree, nil
+	}
+
+	categories, err := s.GetActiveCategoriesWithCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parents come first: categories are ordered by depth
+	tree = []*CategoryNode{}
+	nodes := make(map[uuid.UUID]*CategoryNode, len(categories))
+	for _, category := range categories {
+		node := &CategoryNode{CategoryCount: category, Children: []*CategoryNode{}}
+		if category.ParentID == nil {
+			tree = append(tree, node)
+		} else if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			continue
+		}
+		nodes[category.ID] = node
+	}
+	for _, node := range tree {
+		sumVideoCounts(node)
+	}
+
+	cache.Set(ctx, categoryTreeCacheKey, tree, 10*time.Minute)
+	return tree, nil
+}
+
+// sameParent reports whether two parent IDs name the same parent, nil being the root
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sumVideoCounts sets TotalVideoCount of a node and its descendants
+func sumVideoCounts(node *CategoryNode) int64 {
+	node.TotalVideoCount = node.VideoCount
+	for _, child := range node.Children {
+		node.TotalVideoCount += sumVideoCounts(child)
+	}
+	return node.TotalVideoCount
+}
+
+// invalidateTree drops the cached category tree after categories changed
+func (s *CategoryService) invalidateTree() {
+	cache.Delete(context.Background(), categoryTreeCacheKey)
 }
 
 // GetAllCategories gets all categories
@@ -118,9 +372,61 @@ func (s *CategoryService) GetActiveCategories() ([]models.Category, error) {
 	return s.categoryRepo.GetActive()
 }
 
+// GetAllCategoriesWithCounts gets all categories with their number of
+// non-trashed videos, published or not (admin)
+func (s *CategoryService) GetAllCategoriesWithCounts() ([]repositories.CategoryCount, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return s.categoryRepo.WithVideoCounts(categories, false)
+}
+
+// GetActiveCategoriesWithCounts gets active categories with their number of published videos
+func (s *CategoryService) GetActiveCategoriesWithCounts() ([]repositories.CategoryCount, error) {
+	categories, err := s.categoryRepo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+	return s.categoryRepo.WithVideoCounts(categories, true)
+}
+
 // ToggleActive toggles category active status
 func (s *CategoryService) ToggleActive(id uuid.UUID, isActive bool) error {
-	return s.categoryRepo.ToggleActive(id, isActive)
+	if _, err := s.GetCategoryByID(id); err != nil {
+		return err
+	}
+	if err := s.categoryRepo.ToggleActive(id, isActive); err != nil {
+		return err
+	}
+	s.invalidateTree()
+	return nil
+}
+
+// ReorderCategories sets the display order of the listed categories to their
+// position in ids; categories not listed keep their order (admin)
+func (s *CategoryService) ReorderCategories(ids []uuid.UUID) error {
+	unique := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) != len(ids) {
+		return ErrCategoryInvalidOrder
+	}
+
+	count, err := s.categoryRepo.CountExisting(ids)
+	if err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrCategoryInvalidOrder
+	}
+
+	if err := s.categoryRepo.Reorder(ids); err != nil {
+		return err
+	}
+	s.invalidateTree()
+	return nil
 }
 
 // UpdateDisplayOrder updates category display order
@@ -128,16 +434,30 @@ func (s *CategoryService) UpdateDisplayOrder(id uuid.UUID, order int) error {
 	return s.categoryRepo.UpdateDisplayOrder(id, order)
 }
 
-// generateSlug generates URL-friendly slug from name
+// FindByNameOrSlug finds a category whose name (case-insensitive) or slug
+// matches name, preferring root categories (GetAll lists shallower ones first)
+func (s *CategoryService) FindByNameOrSlug(name string) (*models.Category, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	slug := s.generateSlug(name)
+	for i := range categories {
+		if strings.EqualFold(categories[i].Name, name) || (slug != "" && categories[i].Slug == slug) {
+			return &categories[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// generateSlug generates URL-friendly slug from a (sanitized, HTML-escaped)
+// name. Names without letters or digits get a hash-based slug.
 func (s *CategoryService) generateSlug(name string) string {
-	slug := strings.ToLower(name)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	// Remove special characters (basic sanitization)
-	slug = strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
-			return r
-		}
-		return -1
-	}, slug)
-	return slug
+	name = strings.TrimSpace(name)
+	if slug := utils.Slugify(html.UnescapeString(name), 100); slug != "" {
+		return slug
+	}
+	sum := md5.Sum([]byte(name))
+	return "category-" + hex.EncodeToString(sum[:])[:8]
 }
\ No newline at end of file
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"bobastream/internal/media"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrFaststartRunning is returned when a faststart batch is already in progress
+var ErrFaststartRunning = errors.New("faststart batch already running")
+
+// FaststartOptions tunes streaming optimization of MP4 files
+type FaststartOptions struct {
+	TempDir   string        // Where rewritten copies are written; empty uses the OS default
+	BatchSize int           // Max existing videos handled per batch run
+	Timeout   time.Duration // Per-video limit for download, rewrite and re-upload
+}
+
+// FaststartSummary is the outcome of one batch run over existing videos
+type FaststartSummary struct {
+	Candidates       int       `json:"candidates"`
+	Optimized        int       `json:"optimized"`         // Rewritten and re-uploaded
+	AlreadyOptimized int       `json:"already_optimized"` // Only needed the flag set
+	Failed           int       `json:"failed"`
+	DurationMS       int64     `json:"duration_ms"`
+	StartedAt        time.Time `json:"started_at"`
+}
+
+// PreparedUpload is a file ready to be sent to pCloud
+type PreparedUpload struct {
+	File               *os.File // Rewritten copy with moov first; nil when the original is sent
+	StreamingOptimized *bool    // nil when the container is unknown
+}
+
+// Rewritten reports whether a rewritten copy must be uploaded instead of the original
+func (p *PreparedUpload) Rewritten() bool {
+	return p.File != nil
+}
+
+// Close removes the rewritten copy
+func (p *PreparedUpload) Close() {
+	if p.File != nil {
+		p.File.Close()
+		os.Remove(p.File.Name())
+	}
+}
+
+// FaststartService moves the MP4 moov box to the front of uploads and existing videos
+type FaststartService struct {
+	videoRepo     *repositories.VideoRepository
+	pcloudService *PCloudService
+	httpClient    *http.Client
+	opts          FaststartOptions
+
+	running     atomic.Bool
+	mu          sync.Mutex
+	lastSummary *FaststartSummary
+	wg          sync.WaitGroup
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+func NewFaststartService(
+	videoRepo *repositories.VideoRepository,
+	pcloudService *PCloudService,
+	opts FaststartOptions,
+) *FaststartService {
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Hour
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &FaststartService{
+		videoRepo:     videoRepo,
+		pcloudService: pcloudService,
+		httpClient:    &http.Client{}, // Downloads are bounded by the per-video context
+		opts:          opts,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Prepare checks an upload and, for MP4/MOV files with moov at the end, writes
+// a faststart copy to a temp file. Optimization never fails an upload: on error
+// the original is sent and recorded as not optimized. Callers must Close the result.
+func (s *FaststartService) Prepare(r io.ReaderAt, size int64, info *media.Info) *PreparedUpload {
+	prepared := &PreparedUpload{}
+
+	optimized, err := streamingState(r, size, info)
+	if err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to check MP4 layout: %v\n", err)
+		return prepared
+	}
+	prepared.StreamingOptimized = optimized
+	if optimized == nil || *optimized {
+		return prepared
+	}
+
+	file, err := s.rewrite(r, size)
+	if err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to move moov to the front, uploading as is: %v\n", err)
+		return prepared
+	}
+
+	prepared.File = file
+	prepared.StreamingOptimized = boolPtr(true)
+	return prepared
+}
+
+// storedStreamingState reports whether a stored file is streaming-optimized,
+// nil when unknown (imports record it; the batch job fixes them later)
+func storedStreamingState(r io.ReaderAt, size int64, info *media.Info) *bool {
+	optimized, err := streamingState(r, size, info)
+	if err != nil {
+		return nil
+	}
+	return optimized
+}
+
+// streamingState is nil for unknown containers. WebM/Matroska players read
+// headers first and fetch cues lazily, so only MP4/MOV layouts matter.
+func streamingState(r io.ReaderAt, size int64, info *media.Info) (*bool, error) {
+	if info == nil {
+		return nil, nil
+	}
+	switch info.Container {
+	case "webm", "matroska":
+		return boolPtr(true), nil
+	case "mp4", "mov":
+		fast, err := media.IsFaststart(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return &fast, nil
+	default:
+		return nil, nil
+	}
+}
+
+// rewrite writes a faststart copy of r to a temp file positioned at its start
+func (s *FaststartService) rewrite(r io.ReaderAt, size int64) (*os.File, error) {
+	file, err := os.CreateTemp(s.opts.TempDir, "faststart-*.mp4")
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(err error) (*os.File, error) {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	w := bufio.NewWriterSize(file, 1<<20)
+	if err := media.WriteFaststart(w, r, size); err != nil {
+		return fail(err)
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return file, nil
+}
+
+// Start runs a batch over existing videos in the background (admin)
+func (s *FaststartService) Start() error {
+	if !s.running.CompareAndSwap(false, true) {
+		return ErrFaststartRunning
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Store(false)
+
+		summary := s.optimizeExisting(s.ctx)
+		fmt.Printf("✅ Faststart batch done: %d optimized, %d already optimized, %d failed\n",
+			summary.Optimized, summary.AlreadyOptimized, summary.Failed)
+	}()
+	return nil
+}
+
+// OptimizeExisting fixes the layout of existing videos, one batch per run (called by cron)
+func (s *FaststartService) OptimizeExisting(ctx context.Context) (*FaststartSummary, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrFaststartRunning
+	}
+	defer s.running.Store(false)
+
+	return s.optimizeExisting(ctx), ctx.Err()
+}
+
+// Status reports whether a batch is running and the outcome of the last one
+func (s *FaststartService) Status() (bool, *FaststartSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running.Load(), s.lastSummary
+}
+
+// Shutdown cancels a running batch and waits for it to stop
+func (s *FaststartService) Shutdown() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *FaststartService) optimizeExisting(ctx context.Context) *FaststartSummary {
+	summary := &FaststartSummary{StartedAt: time.Now()}
+	defer func() {
+		summary.DurationMS = time.Since(summary.StartedAt).Milliseconds()
+		s.mu.Lock()
+		s.lastSummary = summary
+		s.mu.Unlock()
+	}()
+
+	videos, err := s.videoRepo.GetStreamingCandidates(s.opts.BatchSize)
+	if err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to get videos to optimize: %v\n", err)
+		return summary
+	}
+	summary.Candidates = len(videos)
+
+	for i := range videos {
+		if ctx.Err() != nil {
+			break
+		}
+		video := &videos[i]
+
+		rewritten, err := s.optimizeVideo(ctx, video)
+		switch {
+		case err != nil:
+			summary.Failed++
+			fmt.Printf("⚠️  WARNING: Failed to optimize video %s for streaming: %v\n", video.ID, err)
+		case rewritten:
+			summary.Optimized++
+		default:
+			summary.AlreadyOptimized++
+		}
+	}
+
+	return summary
+}
+
+// optimizeVideo checks one stored video and re-uploads a faststart copy when needed.
+// Permanent problems are recorded on the video so it is not retried.
+func (s *FaststartService) optimizeVideo(ctx context.Context, video *models.Video) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	credential := video.PCloudCredential
+	if credential == nil {
+		return false, s.giveUp(video, "pCloud account not found")
+	}
+	fileID, err := strconv.ParseInt(video.PCloudFileID, 10, 64)
+	if err != nil {
+		return false, s.giveUp(video, "invalid pCloud file ID")
+	}
+
+	sourceURL, _, err := s.pcloudService.GetFileLink(ctx, credential, fileID)
+	if err != nil {
+		return false, err
+	}
+
+	// Headers are checked over range requests first: most files need no download
+	if size := video.FileSizeBytes; size > 0 {
+		remote := media.NewHTTPReaderAt(ctx, s.httpClient, sourceURL, size)
+		optimized, err := s.inspect(video, remote, size)
+		if err != nil || optimized {
+			return false, err
+		}
+	}
+
+	// Rewriting streams through the whole file, so it is downloaded once
+	original, size, err := s.download(ctx, sourceURL)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		original.Close()
+		os.Remove(original.Name())
+	}()
+
+	optimized, err := s.inspect(video, original, size)
+	if err != nil || optimized {
+		return false, err
+	}
+
+	rewritten, err := s.rewrite(original, size)
+	if err != nil {
+		if errors.Is(err, media.ErrFaststartUnsupported) || errors.Is(err, media.ErrMalformed) {
+			return false, s.giveUp(video, err.Error())
+		}
+		return false, err
+	}
+	defer func() {
+		rewritten.Close()
+		os.Remove(rewritten.Name())
+	}()
+
+	// Same account, so the old file can go once the video points at the new one
+	upload, err := s.pcloudService.UploadFileToAccount(ctx, credential, rewritten, video.ID.String()+"."+video.Container, size)
+	if err != nil {
+		return false, err
+	}
+
+	newURL, expiresAt, err := s.pcloudService.GetFileLink(ctx, credential, upload.FileID)
+	if err == nil {
+		err = s.videoRepo.ReplaceStoredFile(video.ID, strconv.FormatInt(upload.FileID, 10), upload.Hash, newURL, expiresAt)
+	}
+	if err != nil {
+		if discardErr := s.pcloudService.DiscardUpload(context.Background(), upload); discardErr != nil {
+			fmt.Printf("⚠️  WARNING: Failed to discard pCloud file %d: %v\n", upload.FileID, discardErr)
+		}
+		return false, err
+	}
+
+	old := &UploadResult{Credential: credential, FileID: fileID, Size: size}
+	if err := s.pcloudService.DiscardUpload(context.Background(), old); err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to delete replaced pCloud file %d: %v\n", fileID, err)
+	}
+
+	return true, nil
+}
+
+// download copies a pCloud file to a temp file
+func (s *FaststartService) download(ctx context.Context, url string) (*os.File, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("download returned %s", resp.Status)
+	}
+
+	file, err := os.CreateTemp(s.opts.TempDir, "faststart-src-*")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := io.Copy(file, resp.Body)
+	if err == nil && resp.ContentLength > 0 && size != resp.ContentLength {
+		err = fmt.Errorf("download truncated: received %d of %d bytes", size, resp.ContentLength)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, err
+	}
+	return file, size, nil
+}
+
+// inspect probes a stored file, backfilling media info of videos uploaded before
+// probing existed, and records it as optimized when its layout already is
+func (s *FaststartService) inspect(video *models.Video, r io.ReaderAt, size int64) (bool, error) {
+	info, err := media.Probe(r, size)
+	if err != nil {
+		if errors.Is(err, media.ErrUnsupportedFormat) || errors.Is(err, media.ErrMalformed) {
+			return false, s.giveUp(video, err.Error())
+		}
+		return false, err
+	}
+
+	if video.Container == "" {
+		applyMediaInfo(video, info)
+		if err := s.videoRepo.UpdateMediaInfo(video); err != nil {
+			fmt.Printf("⚠️  WARNING: Failed to store media info of video %s: %v\n", video.ID, err)
+		}
+	}
+
+	optimized, err := streamingState(r, size, info)
+	if err != nil {
+		return false, s.giveUp(video, err.Error())
+	}
+	if optimized == nil {
+		return false, s.giveUp(video, "unsupported container "+info.Container)
+	}
+	if *optimized {
+		return true, s.videoRepo.UpdateStreamingState(video.ID, true, nil)
+	}
+	return false, nil
+}
+
+// giveUp records why a video cannot be optimized so later batches skip it
+func (s *FaststartService) giveUp(video *models.Video, reason string) error {
+	if err := s.videoRepo.UpdateStreamingState(video.ID, false, &reason); err != nil {
+		return err
+	}
+	return errors.New(reason)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bobastream/internal/media"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"bobastream/internal/utils"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// progressFlushInterval limits how often byte progress is written to the database
+const progressFlushInterval = time.Second
+
+// VideoImportPayload is the job payload of a remote import. The import itself
+// lives in import_jobs, which keeps its progress for the admin.
+type VideoImportPayload struct {
+	ImportID uuid.UUID `json:"import_id"`
+}
+
+// VideoImportResult is the job result of a finished import
+type VideoImportResult struct {
+	ImportID uuid.UUID `json:"import_id"`
+	VideoID  uuid.UUID `json:"video_id"`
+}
+
+// ImportService imports videos from remote URLs as background jobs
+type ImportService struct {
+	importRepo    *repositories.ImportJobRepository
+	jobQueue      *JobQueue
+	videoService  *VideoService
+	pcloudService *PCloudService
+	validator     *UploadValidator
+	httpClient    *http.Client
+	probeClient   *http.Client // Reads headers back from pCloud
+	maxSizeBytes  int64
+}
+
+func NewImportService(
+	importRepo *repositories.ImportJobRepository,
+	jobQueue *JobQueue,
+	videoService *VideoService,
+	pcloudService *PCloudService,
+	validator *UploadValidator,
+	maxSizeBytes int64,
+	timeout time.Duration,
+) *ImportService {
+	s := &ImportService{
+		importRepo:    importRepo,
+		jobQueue:      jobQueue,
+		videoService:  videoService,
+		pcloudService: pcloudService,
+		validator:     validator,
+		httpClient:    utils.NewPublicHTTPClient(timeout),
+		probeClient:   &http.Client{Timeout: 30 * time.Second},
+		maxSizeBytes:  maxSizeBytes,
+	}
+
+	jobQueue.Register(models.JobTypeVideoImport, JobHandler{
+		Run:     s.runJob,
+		Cleanup: s.cleanup,
+	})
+	return s
+}
+
+// CreateJob stores a new import job and queues it
+func (s *ImportService) CreateJob(job *models.ImportJob) error {
+	if _, err := utils.ValidateRemoteURL(job.SourceURL); err != nil {
+		return err
+	}
+
+	job.Status = models.ImportStatusPending
+	if err := s.importRepo.Create(job); err != nil {
+		return err
+	}
+
+	queued, err := s.jobQueue.Enqueue(models.JobTypeVideoImport, VideoImportPayload{ImportID: job.ID}, job.CreatedBy)
+	if err != nil {
+		if markErr := s.importRepo.MarkFailed(job.ID, "failed to queue import"); markErr != nil {
+			fmt.Printf("⚠️  WARNING: Failed to mark import job %s as failed: %v\n", job.ID, markErr)
+		}
+		return err
+	}
+
+	if err := s.importRepo.SetJobID(job.ID, queued.ID); err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to link import job %s to queue job %s: %v\n", job.ID, queued.ID, err)
+	} else {
+		job.JobID = &queued.ID
+	}
+	return nil
+}
+
+// GetJobByID gets import job by ID
+func (s *ImportService) GetJobByID(id uuid.UUID) (*models.ImportJob, error) {
+	return s.importRepo.FindByID(id)
+}
+
+// GetJobs gets import jobs with pagination (admin)
+func (s *ImportService) GetJobs(status string, page, limit int) ([]models.ImportJob, int64, error) {
+	return s.importRepo.GetAll(status, page, limit)
+}
+
+// runJob runs one attempt of an import and records on the import whether the
+// queue will try it again
+func (s *ImportService) runJob(ctx context.Context, job *models.Job) (interface{}, error) {
+	var payload VideoImportPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, PermanentError(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	videoID, err := s.run(ctx, payload.ImportID)
+	if err == nil {
+		return VideoImportResult{ImportID: payload.ImportID, VideoID: videoID}, nil
+	}
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		if markErr := s.importRepo.MarkFailed(payload.ImportID, err.Error()); markErr != nil {
+			fmt.Printf("⚠️  WARNING: Failed to mark import job %s as failed: %v\n", payload.ImportID, markErr)
+		}
+	} else if markErr := s.importRepo.MarkRetrying(payload.ImportID, err.Error()); markErr != nil {
+		fmt.Printf("⚠️  WARNING: Failed to requeue import job %s: %v\n", payload.ImportID, markErr)
+	}
+	return nil, err
+}
+
+// cleanup fails an import whose queue job was cancelled before it finished
+func (s *ImportService) cleanup(job *models.Job) {
+	var payload VideoImportPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return
+	}
+	if err := s.importRepo.FailUnfinished(payload.ImportID, "cancelled"); err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to mark import job %s as failed: %v\n", payload.ImportID, err)
+	}
+}
+
+// run downloads the remote file, streams it into pCloud and creates the video.
+// Failures that another attempt cannot fix are permanent.
+func (s *ImportService) run(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	job, err := s.importRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, PermanentError(err)
+		}
+		return uuid.Nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, job.SourceURL, nil)
+	if err != nil {
+		return uuid.Nil, PermanentError(fmt.Errorf("invalid source URL: %w", err))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to fetch source: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("remote server returned %s", resp.Status)
+		// Server errors and rate limits may pass; other statuses will not
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			err = PermanentError(err)
+		}
+		return uuid.Nil, err
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/") {
+		return uuid.Nil, PermanentError(fmt.Errorf("remote URL returned %s, not a video file", resp.Header.Get("Content-Type")))
+	}
+
+	// pCloud account selection needs the size up front
+	size := resp.ContentLength
+	if size <= 0 {
+		return uuid.Nil, PermanentError(errors.New("remote server did not report the file size (Content-Length)"))
+	}
+	if s.maxSizeBytes > 0 && size > s.maxSizeBytes {
+		return uuid.Nil, PermanentError(fmt.Errorf("file size (%d bytes) exceeds import limit (%d bytes)", size, s.maxSizeBytes))
+	}
+
+	// ✅ SNIFF MAGIC BYTES BEFORE ANYTHING IS SENT TO PCLOUD. Remote URLs often
+	// have no file extension, so only the content and size are checked.
+	source := bufio.NewReaderSize(resp.Body, media.SniffLen)
+	header, _ := source.Peek(media.SniffLen)
+	if err := s.validator.CheckFile("", size, header); err != nil {
+		return uuid.Nil, PermanentError(err)
+	}
+
+	if err := s.importRepo.MarkStarted(id, size); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Throttled progress reporting; called from the upload goroutine
+	var lastFlush atomic.Int64
+	onProgress := func(sent int64) {
+		now := time.Now().UnixNano()
+		last := lastFlush.Load()
+		if now-last < int64(progressFlushInterval) || !lastFlush.CompareAndSwap(last, now) {
+			return
+		}
+		if err := s.importRepo.UpdateProgress(id, sent); err != nil {
+			fmt.Printf("⚠️  WARNING: Failed to update import progress for %s: %v\n", id, err)
+		}
+	}
+
+	body := &countingReader{r: io.LimitReader(source, size)}
+	placement := UploadPlacement{CategoryID: job.CategoryID, Tags: job.Tags}
+	upload, err := s.pcloudService.UploadFileWithProgress(ctx, body, importFilename(resp, id), size, placement, onProgress)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Anything after a successful upload must clean the pCloud file up on failure
+	videoCreated := false
+	defer func() {
+		if !videoCreated {
+			if err := s.pcloudService.DiscardUpload(context.Background(), upload); err != nil {
+				fmt.Printf("⚠️  WARNING: Failed to discard pCloud file %d: %v\n", upload.FileID, err)
+			}
+		}
+	}()
+
+	if body.n != size {
+		return uuid.Nil, fmt.Errorf("download truncated: received %d of %d bytes", body.n, size)
+	}
+
+	if err := s.importRepo.UpdateStatus(id, models.ImportStatusProcessing); err != nil {
+		return uuid.Nil, err
+	}
+
+	if !job.Force {
+		existing, err := s.videoService.FindDuplicate(upload.SHA256, upload.Hash, upload.Size)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to check for duplicates: %w", err)
+		}
+		if existing != nil {
+			return uuid.Nil, PermanentError(fmt.Errorf("duplicate of video %s (%s); import again with force to import anyway", existing.ID, existing.Title))
+		}
+	}
+
+	sourceURL, expiresAt, err := s.pcloudService.GetFileLink(ctx, upload.Credential, upload.FileID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get pCloud link: %w", err)
+	}
+
+	// The download was streamed straight through, so headers are read back from pCloud
+	stored := media.NewHTTPReaderAt(ctx, s.probeClient, sourceURL, upload.Size)
+	mediaInfo, err := s.videoService.ProbeMedia(stored, upload.Size)
+	if err != nil {
+		return uuid.Nil, PermanentError(err)
+	}
+	if err := s.validator.CheckMedia(mediaInfo); err != nil {
+		return uuid.Nil, PermanentError(err)
+	}
+
+	video, _, err := s.videoService.CreateUploadedVideo(VideoMetadata{
+		Title:           job.Title,
+		Description:     job.Description,
+		ThumbnailURL:    job.ThumbnailURL,
+		CategoryID:      job.CategoryID,
+		Tags:            job.Tags,
+		DurationSeconds: job.DurationSeconds,
+		Media:           mediaInfo,
+
+		StreamingOptimized: storedStreamingState(stored, upload.Size, mediaInfo),
+	}, upload, sourceURL, expiresAt)
+	if video != nil {
+		videoCreated = true
+	}
+	if err != nil {
+		if video == nil {
+			return uuid.Nil, fmt.Errorf("failed to save video: %w", err)
+		}
+		// Retrying would import the file a second time
+		return uuid.Nil, PermanentError(fmt.Errorf("video %s saved but its wrapper link failed: %w", video.ID, err))
+	}
+
+	if err := s.importRepo.MarkCompleted(id, video.ID, body.n); err != nil {
+		return uuid.Nil, PermanentError(fmt.Errorf("video %s saved but the import could not be completed: %w", video.ID, err))
+	}
+	return video.ID, nil
+}
+
+// importFilename picks a filename from Content-Disposition or the final URL path
+func importFilename(resp *http.Response, id uuid.UUID) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(params["filename"]); name != "." && name != "/" && name != "" {
+			return name
+		}
+	}
+
+	if name := path.Base(resp.Request.URL.Path); name != "." && name != "/" && name != "" {
+		return name
+	}
+
+	return "import-" + id.String()
+}
+
+// countingReader counts bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobNotFound       = errors.New("job not found")
+	ErrJobNotCancellable = errors.New("job already finished")
+	ErrJobNotRetryable   = errors.New("only dead jobs and cancelled jobs whose input was kept can be retried")
+	ErrUnknownJobType    = errors.New("unknown job type")
+)
+
+// permanentError marks a job failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// PermanentError wraps a job error so the job is dead-lettered without further attempts
+func PermanentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// JobHandler runs jobs of one type
+type JobHandler struct {
+	// Run does the work; the returned value is stored as the job result (JSON).
+	// ctx is cancelled on timeout, admin cancellation and server shutdown.
+	Run func(ctx context.Context, job *models.Job) (interface{}, error)
+
+	// Cleanup releases resources of a job that will not run again (optional).
+	// It may be called more than once per job.
+	Cleanup func(job *models.Job)
+
+	// NoRetryAfterCancel rejects retrying cancelled jobs, for types whose
+	// Cleanup discards input another run would need (dead jobs keep theirs
+	// until purged)
+	NoRetryAfterCancel bool
+}
+
+// JobQueueOptions tunes the job queue workers
+type JobQueueOptions struct {
+	Workers      int           // Jobs run concurrently by this process
+	PollInterval time.Duration // How often idle workers look for due jobs
+	MaxAttempts  int           // Attempts before a job is dead-lettered
+	BackoffBase  time.Duration // Delay after the first failure, doubled per attempt
+	BackoffMax   time.Duration
+	Timeout      time.Duration // Time limit for a single attempt
+	StaleAfter   time.Duration // Running jobs without a heartbeat this long are requeued
+	Retention    time.Duration // Finished jobs are purged after this long
+}
+
+// JobQueue runs typed background jobs stored in Postgres. Any number of
+// processes can share the queue; jobs are claimed with FOR UPDATE SKIP LOCKED.
+type JobQueue struct {
+	jobRepo  *repositories.JobRepository
+	opts     JobQueueOptions
+	handlers map[models.JobType]JobHandler
+	workerID string
+
+	wake   chan struct{} // Signals idle workers that a job was enqueued
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewJobQueue(jobRepo *repositories.JobRepository, opts JobQueueOptions) *JobQueue {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = 30 * time.Second
+	}
+	if opts.BackoffMax < opts.BackoffBase {
+		opts.BackoffMax = opts.BackoffBase
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Hour
+	}
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = 2 * time.Minute
+	}
+
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &JobQueue{
+		jobRepo:  jobRepo,
+		opts:     opts,
+		handlers: make(map[models.JobType]JobHandler),
+		workerID: fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		wake:     make(chan struct{}, opts.Workers),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register sets the handler for a job type. Call before Start.
+func (q *JobQueue) Register(jobType models.JobType, handler JobHandler) {
+	q.handlers[jobType] = handler
+}
+
+// Enqueue stores a new job; payload is encoded as JSON
+func (q *JobQueue) Enqueue(jobType models.JobType, payload interface{}, createdBy *uuid.UUID) (*models.Job, error) {
+	if _, ok := q.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Status:      models.JobStatusQueued,
+		Payload:     data,
+		MaxAttempts: q.opts.MaxAttempts,
+		RunAt:       time.Now(),
+		CreatedBy:   createdBy,
+	}
+	if err := q.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	q.notify()
+	return job, nil
+}
+
+// GetJob gets job by ID
+func (q *JobQueue) GetJob(id uuid.UUID) (*models.Job, error) {
+	job, err := q.jobRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// GetJobs gets jobs with pagination (admin)
+func (q *JobQueue) GetJobs(status, jobType string, page, limit int) ([]models.Job, int64, error) {
+	return q.jobRepo.GetAll(status, jobType, page, limit)
+}
+
+// CountByStatus counts jobs per status (admin)
+func (q *JobQueue) CountByStatus() (map[models.JobStatus]int64, error) {
+	return q.jobRepo.CountByStatus()
+}
+
+// Cancel stops a queued or running job. A running job is interrupted at its
+// next heartbeat, whichever process runs it.
+func (q *JobQueue) Cancel(id uuid.UUID) (*models.Job, error) {
+	job, err := q.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled, err := q.jobRepo.Cancel(id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrJobNotCancellable
+	}
+
+	// A running job is cleaned up by its worker once it stops
+	if job.Status == models.JobStatusQueued {
+		q.cleanup(job)
+	}
+	return q.GetJob(id)
+}
+
+// Retry requeues a dead or cancelled job with a fresh set of attempts
+func (q *JobQueue) Retry(id uuid.UUID) (*models.Job, error) {
+	job, err := q.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == models.JobStatusCancelled && q.handlers[job.Type].NoRetryAfterCancel {
+		return nil, ErrJobNotRetryable
+	}
+
+	retried, err := q.jobRepo.Retry(id)
+	if err != nil {
+		return nil, err
+	}
+	if !retried {
+		return nil, ErrJobNotRetryable
+	}
+
+	q.notify()
+	return q.GetJob(id)
+}
+
+// PurgeFinished deletes finished jobs past the retention period (called by cron)
+func (q *JobQueue) PurgeFinished(ctx context.Context) (int, error) {
+	if q.opts.Retention <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-q.opts.Retention)
+	purged := 0
+	for ctx.Err() == nil {
+		jobs, err := q.jobRepo.DeleteFinishedBefore(before, 500)
+		if err != nil {
+			return purged, err
+		}
+		for i := range jobs {
+			q.cleanup(&jobs[i])
+		}
+		purged += len(jobs)
+		if len(jobs) < 500 {
+			break
+		}
+	}
+	return purged, ctx.Err()
+}
+
+// Start launches the workers and the stale job reaper
+func (q *JobQueue) Start() {
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go func(n int) {
+			defer q.wg.Done()
+			q.work(fmt.Sprintf("%s#%d", q.workerID, n))
+		}(i + 1)
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.reap()
+	}()
+}
+
+// Shutdown stops the workers. Interrupted jobs go back to the queue without
+// using up an attempt.
+func (q *JobQueue) Shutdown() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+// notify wakes an idle worker, if any
+func (q *JobQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *JobQueue) types() []models.JobType {
+	types := make([]models.JobType, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	return types
+}
+
+// work claims and runs jobs until shutdown
+func (q *JobQueue) work(workerID string) {
+	types := q.types()
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for q.ctx.Err() == nil {
+		job, err := q.jobRepo.Claim(types, workerID)
+		if err != nil {
+			fmt.Printf("⚠️  WARNING: Failed to claim job: %v\n", err)
+		}
+		if job != nil {
+			q.run(job, workerID)
+			continue
+		}
+
+		select {
+		case <-q.wake:
+		case <-ticker.C:
+		case <-q.ctx.Done():
+		}
+	}
+}
+
+// reap periodically requeues jobs whose worker died
+func (q *JobQueue) reap() {
+	ticker := time.NewTicker(q.opts.StaleAfter / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+
+		requeued, err := q.jobRepo.RequeueStale(time.Now().Add(-q.opts.StaleAfter), "worker stopped responding")
+		if err != nil {
+			fmt.Printf("⚠️  WARNING: Failed to requeue stale jobs: %v\n", err)
+			continue
+		}
+		if requeued > 0 {
+			q.notify()
+		}
+	}
+}
+
+// run executes one claimed job and records the outcome
+func (q *JobQueue) run(job *models.Job, workerID string) {
+	handler := q.handlers[job.Type]
+
+	ctx, cancel := context.WithTimeout(q.ctx, q.opts.Timeout)
+	defer cancel()
+
+	// Heartbeats keep the job claimed; losing it means an admin cancelled it
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(q.opts.StaleAfter / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				held, err := q.jobRepo.Heartbeat(job.ID, workerID)
+				if err != nil {
+					fmt.Printf("⚠️  WARNING: Failed to record heartbeat for job %s: %v\n", job.ID, err)
+					continue
+				}
+				if !held {
+					close(lost)
+					cancel()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	result, err := q.invoke(ctx, handler, job)
+	close(done)
+
+	select {
+	case <-lost:
+		q.cleanupIfFinished(job)
+		return
+	default:
+	}
+
+	var ok bool
+	switch {
+	case err == nil:
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			data = nil
+		}
+		ok, err = q.jobRepo.MarkCompleted(job.ID, workerID, data)
+		q.cleanup(job)
+
+	case q.ctx.Err() != nil:
+		ok, err = q.jobRepo.Release(job.ID, workerID)
+
+	default:
+		fmt.Printf("⚠️  WARNING: Job %s (%s) attempt %d failed: %v\n", job.ID, job.Type, job.Attempts, err)
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+			ok, err = q.jobRepo.MarkDead(job.ID, workerID, err.Error())
+		} else {
+			ok, err = q.jobRepo.MarkRetry(job.ID, workerID, err.Error(), time.Now().Add(q.backoff(job.Attempts)))
+		}
+	}
+	if err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to record outcome of job %s: %v\n", job.ID, err)
+	} else if !ok {
+		// Lost the job between the last heartbeat and now
+		q.cleanupIfFinished(job)
+	}
+}
+
+// invoke runs the handler, turning panics into permanent failures
+func (q *JobQueue) invoke(ctx context.Context, handler JobHandler, job *models.Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = PermanentError(fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return handler.Run(ctx, job)
+}
+
+func (q *JobQueue) cleanup(job *models.Job) {
+	if handler, ok := q.handlers[job.Type]; ok && handler.Cleanup != nil {
+		handler.Cleanup(job)
+	}
+}
+
+// cleanupIfFinished cleans up a job this worker lost, unless it was requeued
+// as stale and will run again elsewhere
+func (q *JobQueue) cleanupIfFinished(job *models.Job) {
+	current, err := q.jobRepo.FindByID(job.ID)
+	if err == nil && !current.Finished() {
+		return
+	}
+	q.cleanup(job)
+}
+
+// backoff returns the delay before the next attempt: base doubled per attempt, capped
+func (q *JobQueue) backoff(attempts int) time.Duration {
+	delay := q.opts.BackoffBase
+	for i := 1; i < attempts && delay < q.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.BackoffMax {
+		delay = q.opts.BackoffMax
+	}
+	return delay
+}
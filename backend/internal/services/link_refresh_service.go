@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLinkRefreshRunning is returned when a refresh run is already in progress
+var ErrLinkRefreshRunning = errors.New("link refresh already running")
+
+// LinkRefreshOptions tunes proactive link refresh
+type LinkRefreshOptions struct {
+	Window      time.Duration // Renew links this long before they expire
+	Jitter      time.Duration // Random extra window per video, spreading renewals across runs
+	Workers     int           // Concurrent pCloud calls
+	BatchSize   int           // Max videos considered per run
+	BackoffBase time.Duration // Delay after the first failure, doubled per failure
+	BackoffMax  time.Duration
+	MaxFailures int // Consecutive failures after which a video is reported as permanently failing
+}
+
+// LinkRefreshSummary is the outcome of one refresh run
+type LinkRefreshSummary struct {
+	Candidates int       `json:"candidates"` // Videos with links inside window + jitter
+	Refreshed  int64     `json:"refreshed"`
+	Failed     int64     `json:"failed"`
+	Skipped    int64     `json:"skipped"` // Deferred by jitter or still in failure backoff
+	DurationMS int64     `json:"duration_ms"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// LinkRefreshService renews pCloud streaming links before they expire
+type LinkRefreshService struct {
+	videoRepo     *repositories.VideoRepository
+	pcloudService *PCloudService
+	opts          LinkRefreshOptions
+	running       atomic.Bool // Cron and admin-triggered runs must not overlap
+}
+
+func NewLinkRefreshService(
+	videoRepo *repositories.VideoRepository,
+	pcloudService *PCloudService,
+	opts LinkRefreshOptions,
+) *LinkRefreshService {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 500
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = 5 * time.Minute
+	}
+	if opts.BackoffMax < opts.BackoffBase {
+		opts.BackoffMax = opts.BackoffBase
+	}
+	if opts.MaxFailures < 1 {
+		opts.MaxFailures = 5
+	}
+
+	return &LinkRefreshService{
+		videoRepo:     videoRepo,
+		pcloudService: pcloudService,
+		opts:          opts,
+	}
+}
+
+// MaxFailures returns the failure count at which a video counts as permanently failing
+func (s *LinkRefreshService) MaxFailures() int {
+	return s.opts.MaxFailures
+}
+
+// RefreshLinks renews links expiring within the refresh window (called by cron)
+func (s *LinkRefreshService) RefreshLinks(ctx context.Context) (*LinkRefreshSummary, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrLinkRefreshRunning
+	}
+	defer s.running.Store(false)
+
+	summary := &LinkRefreshSummary{StartedAt: time.Now()}
+	defer func() { summary.DurationMS = time.Since(summary.StartedAt).Milliseconds() }()
+
+	now := summary.StartedAt
+	videos, err := s.videoRepo.GetLinksDueForRefresh(now.Add(s.opts.Window+s.opts.Jitter), now, s.opts.BatchSize)
+	if err != nil {
+		return summary, err
+	}
+	summary.Candidates = len(videos)
+
+	var refreshed, failed, skipped atomic.Int64
+	queue := make(chan *models.Video)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for video := range queue {
+				if err := s.refreshVideo(ctx, video); err != nil {
+					failed.Add(1)
+					continue
+				}
+				refreshed.Add(1)
+			}
+		}()
+	}
+
+	rng := rand.New(rand.NewSource(now.UnixNano()))
+	for i := range videos {
+		video := &videos[i]
+
+		if !s.isDue(video, now, rng) {
+			skipped.Add(1)
+			continue
+		}
+
+		select {
+		case queue <- video:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	summary.Refreshed = refreshed.Load()
+	summary.Failed = failed.Load()
+	summary.Skipped = skipped.Load()
+
+	return summary, ctx.Err()
+}
+
+// isDue applies per-video jitter to a candidate; failure backoff is applied
+// when candidates are selected, so failing links cannot crowd out due ones
+func (s *LinkRefreshService) isDue(video *models.Video, now time.Time, rng *rand.Rand) bool {
+	if video.SourceURLExpiresAt == nil {
+		return true
+	}
+
+	deadline := now.Add(s.opts.Window)
+	if s.opts.Jitter > 0 {
+		deadline = deadline.Add(time.Duration(rng.Int63n(int64(s.opts.Jitter))))
+	}
+	return video.SourceURLExpiresAt.Before(deadline)
+}
+
+// refreshVideo renews one link and records the outcome on the video
+func (s *LinkRefreshService) refreshVideo(ctx context.Context, video *models.Video) error {
+	err := s.renew(ctx, video)
+	if err == nil {
+		return nil
+	}
+
+	// Cancellation is not the video's fault
+	if ctx.Err() != nil {
+		return err
+	}
+
+	retryAt := time.Now().Add(s.backoff(video.LinkRefreshFailures + 1))
+	if recordErr := s.videoRepo.RecordLinkRefreshFailure(video.ID, err.Error(), retryAt); recordErr != nil {
+		fmt.Printf("⚠️  WARNING: Failed to record link refresh failure for video %s: %v\n", video.ID, recordErr)
+	}
+	fmt.Printf("⚠️  WARNING: Failed to refresh link for video %s (failure #%d): %v\n",
+		video.ID, video.LinkRefreshFailures+1, err)
+
+	return err
+}
+
+func (s *LinkRefreshService) renew(ctx context.Context, video *models.Video) error {
+	if video.PCloudCredential == nil {
+		return errors.New("no pCloud credential associated")
+	}
+
+	fileID, err := strconv.ParseInt(video.PCloudFileID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid pCloud file ID %q", video.PCloudFileID)
+	}
+
+	newURL, expiresAt, err := s.pcloudService.GetFileLink(ctx, video.PCloudCredential, fileID)
+	if err != nil {
+		return err
+	}
+
+	// Also clears the failure counters
+	return s.videoRepo.UpdateSourceURL(video.ID, newURL, expiresAt)
+}
+
+// backoff returns the wait after the given number of consecutive failures
+func (s *LinkRefreshService) backoff(failures int) time.Duration {
+	delay := s.opts.BackoffBase
+	for i := 1; i < failures && delay < s.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > s.opts.BackoffMax {
+		delay = s.opts.BackoffMax
+	}
+	return delay
+}
+
+// GetFailingVideos gets videos whose links failed to refresh (admin)
+func (s *LinkRefreshService) GetFailingVideos(permanentOnly bool, page, limit int) ([]models.Video, int64, error) {
+	minFailures := 1
+	if permanentOnly {
+		minFailures = s.opts.MaxFailures
+	}
+	return s.videoRepo.GetLinkRefreshFailures(minFailures, page, limit)
+}
+
+// IsPermanentlyFailing reports whether a video reached the failure threshold
+func (s *LinkRefreshService) IsPermanentlyFailing(video *models.Video) bool {
+	return video.LinkRefreshFailures >= s.opts.MaxFailures
+}
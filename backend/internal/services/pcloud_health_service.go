@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/pcloud"
+	"bobastream/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// nearlyFullRatio marks an account degraded when pCloud reports less free quota than this
+const nearlyFullRatio = 0.05
+
+// PCloudHealthOptions tunes account health checks
+type PCloudHealthOptions struct {
+	SlowThreshold    time.Duration // API latency above this marks an account degraded
+	QuarantineAfter  int           // Consecutive failed checks before leaving upload rotation
+	RecoverAfter     int           // Consecutive passed checks before rejoining upload rotation
+	HistoryRetention time.Duration // Health checks older than this are pruned
+}
+
+// PCloudHealthSummary is the outcome of checking every enabled account
+type PCloudHealthSummary struct {
+	Checked     int `json:"checked"`
+	Healthy     int `json:"healthy"`
+	Degraded    int `json:"degraded"`
+	Unhealthy   int `json:"unhealthy"`
+	Quarantined int `json:"quarantined"` // Newly quarantined in this run
+	Recovered   int `json:"recovered"`   // Returned to rotation in this run
+}
+
+// PCloudHealthService probes pCloud accounts and quarantines failing ones
+type PCloudHealthService struct {
+	pcloudRepo    *repositories.PCloudCredentialRepository
+	checkRepo     *repositories.PCloudHealthCheckRepository
+	pcloudService *PCloudService
+	opts          PCloudHealthOptions
+}
+
+func NewPCloudHealthService(
+	pcloudRepo *repositories.PCloudCredentialRepository,
+	checkRepo *repositories.PCloudHealthCheckRepository,
+	pcloudService *PCloudService,
+	opts PCloudHealthOptions,
+) *PCloudHealthService {
+	if opts.SlowThreshold <= 0 {
+		opts.SlowThreshold = 3 * time.Second
+	}
+	if opts.QuarantineAfter < 1 {
+		opts.QuarantineAfter = 3
+	}
+	if opts.RecoverAfter < 1 {
+		opts.RecoverAfter = 2
+	}
+
+	return &PCloudHealthService{
+		pcloudRepo:    pcloudRepo,
+		checkRepo:     checkRepo,
+		pcloudService: pcloudService,
+		opts:          opts,
+	}
+}
+
+// CheckAll probes every admin-enabled account, quarantined ones included (called by cron)
+func (s *PCloudHealthService) CheckAll(ctx context.Context) (*PCloudHealthSummary, error) {
+	credentials, err := s.pcloudRepo.GetEnabled()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PCloudHealthSummary{}
+	for i := range credentials {
+		credential := &credentials[i]
+		wasQuarantined := credential.IsQuarantined()
+
+		check, err := s.CheckAccount(ctx, credential)
+		if err != nil {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			// Log error but continue with other accounts
+			fmt.Printf("⚠️  WARNING: Failed to record health of pCloud account '%s': %v\n", credential.AccountName, err)
+			continue
+		}
+
+		summary.Checked++
+		switch check.Status {
+		case models.HealthStatusHealthy:
+			summary.Healthy++
+		case models.HealthStatusDegraded:
+			summary.Degraded++
+		default:
+			summary.Unhealthy++
+		}
+		if !wasQuarantined && credential.IsQuarantined() {
+			summary.Quarantined++
+		}
+		if wasQuarantined && !credential.IsQuarantined() {
+			summary.Recovered++
+		}
+	}
+
+	if s.opts.HistoryRetention > 0 {
+		if _, err := s.checkRepo.DeleteOlderThan(time.Now().Add(-s.opts.HistoryRetention)); err != nil {
+			fmt.Printf("⚠️  WARNING: Failed to prune pCloud health history: %v\n", err)
+		}
+	}
+
+	return summary, nil
+}
+
+// CheckAccount probes one account, records the check and updates its quarantine state
+func (s *PCloudHealthService) CheckAccount(ctx context.Context, credential *models.PCloudCredential) (*models.PCloudHealthCheck, error) {
+	start := time.Now()
+	info, err := s.pcloudService.UserInfo(ctx, credential)
+	latency := time.Since(start)
+
+	// Our own cancellation says nothing about the account
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	check := evaluateHealth(info, err, latency, s.opts.SlowThreshold)
+	check.CredentialID = credential.ID
+
+	if err := s.checkRepo.Create(check); err != nil {
+		return nil, err
+	}
+
+	s.applyCheck(credential, check)
+	if err := s.pcloudRepo.UpdateHealth(credential); err != nil {
+		return nil, err
+	}
+
+	return check, nil
+}
+
+// applyCheck updates streak counters and quarantines or restores the account
+func (s *PCloudHealthService) applyCheck(credential *models.PCloudCredential, check *models.PCloudHealthCheck) {
+	latencyMS := check.LatencyMS
+	checkedAt := check.CheckedAt
+
+	credential.HealthStatus = check.Status
+	credential.HealthError = check.Error
+	credential.HealthLatencyMS = &latencyMS
+	credential.HealthCheckedAt = &checkedAt
+
+	if check.Status == models.HealthStatusUnhealthy {
+		credential.HealthFailures++
+		credential.HealthSuccesses = 0
+	} else {
+		credential.HealthSuccesses++
+		credential.HealthFailures = 0
+	}
+
+	switch {
+	case !credential.IsQuarantined() && credential.HealthFailures >= s.opts.QuarantineAfter:
+		now := time.Now()
+		credential.QuarantinedAt = &now
+		log.Printf("🚫 pCloud account '%s' quarantined after %d failed health checks: %s\n",
+			credential.AccountName, credential.HealthFailures, check.Error)
+	case credential.IsQuarantined() && credential.HealthSuccesses >= s.opts.RecoverAfter:
+		credential.QuarantinedAt = nil
+		log.Printf("✅ pCloud account '%s' recovered and returned to upload rotation\n", credential.AccountName)
+	}
+}
+
+// evaluateHealth turns a userinfo result into a health check
+func evaluateHealth(info *pcloud.UserInfo, err error, latency time.Duration, slow time.Duration) *models.PCloudHealthCheck {
+	check := &models.PCloudHealthCheck{
+		Status:    models.HealthStatusHealthy,
+		LatencyMS: int(latency.Milliseconds()),
+		CheckedAt: time.Now(),
+	}
+
+	if err != nil {
+		check.Status = models.HealthStatusUnhealthy
+		check.Error = healthErrorMessage(err)
+
+		var apiErr *pcloud.APIError
+		if errors.As(err, &apiErr) && apiErr.Code != 0 {
+			code := apiErr.Code
+			check.ErrorCode = &code
+		}
+		return check
+	}
+
+	check.QuotaBytes = &info.Quota
+	check.UsedQuotaBytes = &info.UsedQuota
+
+	switch {
+	case info.Quota > 0 && info.UsedQuota >= info.Quota:
+		check.Status = models.HealthStatusUnhealthy
+		check.Error = "account is over quota"
+	case info.Quota > 0 && float64(info.Quota-info.UsedQuota) < float64(info.Quota)*nearlyFullRatio:
+		check.Status = models.HealthStatusDegraded
+		check.Error = "account is nearly full"
+	case latency > slow:
+		check.Status = models.HealthStatusDegraded
+		check.Error = fmt.Sprintf("slow API response (%dms)", latency.Milliseconds())
+	}
+
+	return check
+}
+
+// healthErrorMessage describes common failures in admin-friendly terms
+func healthErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, pcloud.ErrAuthFailed):
+		return "authentication failed: token revoked or expired (" + err.Error() + ")"
+	case errors.Is(err, pcloud.ErrQuotaExceeded):
+		return "account is over quota (" + err.Error() + ")"
+	case errors.Is(err, pcloud.ErrRateLimited):
+		return "rate limited by pCloud (" + err.Error() + ")"
+	default:
+		return err.Error()
+	}
+}
+
+// GetHistory gets the latest health checks of an account (admin)
+func (s *PCloudHealthService) GetHistory(credentialID uuid.UUID, limit int) ([]models.PCloudHealthCheck, error) {
+	return s.checkRepo.GetByCredential(credentialID, limit)
+}
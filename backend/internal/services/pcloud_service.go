@@ -1,271 +1,242 @@
 package services
 
 import (
-	"bobastream/config"
 	"bobastream/internal/models"
+	"bobastream/internal/pcloud"
 	"bobastream/internal/repositories"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// storageOverheadFactor is the safety margin added on top of a file's size when
+// tracking account usage (5% for pCloud metadata)
+const storageOverheadFactor = 1.05
+
+// Backoff of trashed videos that failed to purge: a day, doubled per
+// consecutive failure up to a month
+const (
+	purgeBackoffBase = 24 * time.Hour
+	purgeBackoffMax  = 30 * 24 * time.Hour
+)
+
 type PCloudService struct {
 	pcloudRepo *repositories.PCloudCredentialRepository
 	videoRepo  *repositories.VideoRepository
+	client     *pcloud.Client
+	selector   *AccountSelector
 }
 
 func NewPCloudService(
 	pcloudRepo *repositories.PCloudCredentialRepository,
 	videoRepo *repositories.VideoRepository,
+	client *pcloud.Client,
+	selector *AccountSelector,
 ) *PCloudService {
 	return &PCloudService{
 		pcloudRepo: pcloudRepo,
 		videoRepo:  videoRepo,
+		client:     client,
+		selector:   selector,
 	}
 }
 
-// PCloudUploadResponse response from pCloud upload API
-type PCloudUploadResponse struct {
-	Result   int    `json:"result"`
-	Metadata []struct {
-		Name   string `json:"name"`
-		FileID int64  `json:"fileid"`
-		Size   int64  `json:"size"`
-		Hash   string `json:"hash"`
-	} `json:"metadata"`
-	Error string `json:"error,omitempty"`
+// authFor builds pCloud client auth for a stored credential
+func authFor(credential *models.PCloudCredential) pcloud.Auth {
+	return pcloud.Auth{
+		Token:  credential.APIToken,
+		Region: credential.Region,
+	}
 }
 
-// PCloudLinkResponse response from pCloud getfilelink API
-type PCloudLinkResponse struct {
-	Result  int      `json:"result"`
-	Hosts   []string `json:"hosts"`
-	Path    string   `json:"path"`
-	Expires string   `json:"expires"`
-	Error   string   `json:"error,omitempty"`
+// UploadResult describes a file stored on pCloud by UploadFile
+type UploadResult struct {
+	Credential *models.PCloudCredential
+	FileID     int64
+	Hash       string // Content hash reported by pCloud
+	SHA256     string // Hex SHA-256 of the bytes sent, computed while streaming
+	Size       int64
 }
 
-// GetAvailableAccount gets pCloud account with most available space (auto-rotate)
-func (s *PCloudService) GetAvailableAccount() (*models.PCloudCredential, error) {
-	credentials, err := s.pcloudRepo.GetActive()
+// UploadFile uploads file to pCloud, on the account chosen by the selection strategy.
+// Passing an io.ReadSeeker lets the client retry failed attempts.
+func (s *PCloudService) UploadFile(ctx context.Context, file io.Reader, filename string, fileSize int64, placement UploadPlacement) (*UploadResult, error) {
+	return s.UploadFileWithProgress(ctx, file, filename, fileSize, placement, nil)
+}
+
+// UploadFileWithProgress is UploadFile reporting bytes sent to pCloud through onProgress
+func (s *PCloudService) UploadFileWithProgress(ctx context.Context, file io.Reader, filename string, fileSize int64, placement UploadPlacement, onProgress func(sent int64)) (*UploadResult, error) {
+	// Pick an account with room for the file (storage and 10% per-file limits)
+	placement.SizeBytes = fileSize
+	credential, err := s.selector.Select(placement)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(credentials) == 0 {
-		return nil, errors.New("no active pCloud accounts available")
-	}
-
-	// Find account with most available space
-	var bestAccount *models.PCloudCredential
-	maxAvailable := 0.0
-
-	for i := range credentials {
-		available := credentials[i].StorageLimitGB - credentials[i].StorageUsedGB
-		if available > maxAvailable {
-			maxAvailable = available
-			bestAccount = &credentials[i]
-		}
-	}
-
-	if bestAccount == nil || maxAvailable < 0.1 { // Less than 100MB available
-		return nil, errors.New("no pCloud account with sufficient storage available")
-	}
+	return s.uploadTo(ctx, credential, file, filename, fileSize, onProgress)
+}
 
-	return bestAccount, nil
+// UploadFileToAccount uploads file to a given account, bypassing account selection
+// (used when replacing a video's file in place)
+func (s *PCloudService) UploadFileToAccount(ctx context.Context, credential *models.PCloudCredential, file io.Reader, filename string, fileSize int64) (*UploadResult, error) {
+	return s.uploadTo(ctx, credential, file, filename, fileSize, nil)
 }
 
-// UploadFile uploads file to pCloud with auto-rotate account
-func (s *PCloudService) UploadFile(file multipart.File, filename string, fileSize int64) (*models.PCloudCredential, int64, string, error) {
+// uploadTo uploads file to the account root folder and tracks the storage used
+func (s *PCloudService) uploadTo(ctx context.Context, credential *models.PCloudCredential, file io.Reader, filename string, fileSize int64, onProgress func(sent int64)) (*UploadResult, error) {
 	// ✅ CALCULATE FILE SIZE IN GB
 	fileSizeMB := float64(fileSize) / (1024 * 1024)
 	fileSizeGB := fileSizeMB / 1024
 
-	// Get available account (auto-rotate based on storage)
-	credential, err := s.GetAvailableAccount()
-	if err != nil {
-		return nil, 0, "", err
-	}
-
-	// ✅ CHECK IF FILE SIZE EXCEEDS AVAILABLE STORAGE
-	availableGB := credential.StorageLimitGB - credential.StorageUsedGB
-	if fileSizeGB > availableGB {
-		return nil, 0, "", fmt.Errorf(
-			"file size (%.2fGB) exceeds available storage (%.2fGB) in account '%s'",
-			fileSizeGB,
-			availableGB,
-			credential.AccountName,
-		)
-	}
-
-	// ✅ CHECK IF FILE SIZE EXCEEDS 10% OF TOTAL STORAGE (safety limit)
-	maxAllowedGB := credential.StorageLimitGB * 0.1 // 10% of total
-	if fileSizeGB > maxAllowedGB {
-		return nil, 0, "", fmt.Errorf(
-			"file size (%.2fGB) exceeds maximum allowed file size (%.2fGB) for account '%s'",
-			fileSizeGB,
-			maxAllowedGB,
-			credential.AccountName,
-		)
-	}
-
-	// Upload to pCloud
-	fileID, hash, err := s.uploadToPCloud(credential.APIToken, file, filename)
+	// Upload to pCloud (root folder)
+	uploaded, err := s.client.UploadFile(ctx, authFor(credential), 0, filename, file, pcloud.UploadOptions{
+		OnProgress: onProgress,
+	})
 	if err != nil {
-		return nil, 0, "", fmt.Errorf("pCloud upload failed: %w", err)
+		return nil, fmt.Errorf("pCloud upload failed: %w", err)
 	}
 
 	// ✅ UPDATE STORAGE USED (WITH SAFETY MARGIN 5%)
-	storageIncrease := fileSizeGB * 1.05 // Add 5% overhead for metadata
+	storageIncrease := fileSizeGB * storageOverheadFactor // Add 5% overhead for metadata
 	credential.StorageUsedGB += storageIncrease
 
-	if err := s.pcloudRepo.Update(credential); err != nil {
+	// Increment in SQL so concurrent uploads and health checks don't overwrite each other
+	if err := s.pcloudRepo.AddStorage(credential.ID, storageIncrease); err != nil {
 		// ⚠️ NON-CRITICAL ERROR: File uploaded but storage tracking failed
 		// Log this but don't fail the upload
 		fmt.Printf("⚠️  WARNING: Failed to update storage tracking for account '%s': %v\n",
 			credential.AccountName, err)
 	}
 
-	return credential, fileID, hash, nil
+	return &UploadResult{
+		Credential: credential,
+		FileID:     uploaded.FileID,
+		Hash:       strconv.FormatUint(uploaded.Hash, 10),
+		SHA256:     uploaded.SHA256,
+		Size:       fileSize,
+	}, nil
 }
 
-// ✅ FIXED: uploadToPCloud with streaming upload (no memory leak)
-func (s *PCloudService) uploadToPCloud(apiToken string, file multipart.File, filename string) (int64, string, error) {
-	// ✅ Create pipe for streaming upload
-	pr, pw := io.Pipe()
-	writer := multipart.NewWriter(pw)
-
-	// ✅ Upload in separate goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		defer pw.Close()
-		defer writer.Close()
-
-		part, err := writer.CreateFormFile("file", filename)
-		if err != nil {
-			errChan <- err
-			pw.CloseWithError(err)
-			return
-		}
-
-		if _, err := io.Copy(part, file); err != nil {
-			errChan <- err
-			pw.CloseWithError(err)
-			return
-		}
-
-		errChan <- nil
-	}()
-
-	// Create request with streaming body
-	url := fmt.Sprintf("%s/uploadfile?auth=%s&folderid=0", config.GlobalConfig.PCloud.BaseURL, apiToken)
-	req, err := http.NewRequest("POST", url, pr)
-	if err != nil {
-		return 0, "", err
+// DiscardUpload deletes a freshly uploaded file and gives its storage back
+func (s *PCloudService) DiscardUpload(ctx context.Context, result *UploadResult) error {
+	if err := s.DeleteFile(ctx, result.Credential, result.FileID); err != nil {
+		return err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return s.ReleaseStorage(result.Credential.ID, float64(result.Size)/(1024*1024))
+}
 
-	// ✅ Increase timeout for large files
-	client := &http.Client{Timeout: 30 * time.Minute}
-	resp, err := client.Do(req)
+// GetFileLink gets streaming link from pCloud
+func (s *PCloudService) GetFileLink(ctx context.Context, credential *models.PCloudCredential, fileID int64) (string, time.Time, error) {
+	link, err := s.client.GetFileLink(ctx, authFor(credential), fileID)
 	if err != nil {
-		return 0, "", err
+		return "", time.Time{}, err
 	}
-	defer resp.Body.Close()
+	return link.URL, link.ExpiresAt, nil
+}
 
-	// Check goroutine error
-	if uploadErr := <-errChan; uploadErr != nil {
-		return 0, "", uploadErr
-	}
+// ListFolder lists a folder of a pCloud account by ID or path
+func (s *PCloudService) ListFolder(ctx context.Context, credential *models.PCloudCredential, folderID int64, opts pcloud.ListFolderOptions) (*pcloud.Metadata, error) {
+	return s.client.ListFolder(ctx, authFor(credential), folderID, opts)
+}
 
-	// Parse response
-	var uploadResp PCloudUploadResponse
-	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
-		return 0, "", err
-	}
+// UserInfo gets account details and quota from pCloud
+func (s *PCloudService) UserInfo(ctx context.Context, credential *models.PCloudCredential) (*pcloud.UserInfo, error) {
+	return s.client.UserInfo(ctx, authFor(credential))
+}
 
-	if uploadResp.Result != 0 {
-		return 0, "", fmt.Errorf("pCloud upload failed: %s", uploadResp.Error)
-	}
+// AddStorage records storage taken by files already on the account (catalog import)
+func (s *PCloudService) AddStorage(credentialID uuid.UUID, fileSizeBytes int64) error {
+	addedGB := float64(fileSizeBytes) / (1024 * 1024 * 1024) * storageOverheadFactor
+	return s.pcloudRepo.AddStorage(credentialID, addedGB)
+}
 
-	if len(uploadResp.Metadata) == 0 {
-		return 0, "", errors.New("no file metadata returned from pCloud")
+// DeleteFile deletes a file from pCloud. A file that is already gone is not an error.
+func (s *PCloudService) DeleteFile(ctx context.Context, credential *models.PCloudCredential, fileID int64) error {
+	err := s.client.DeleteFile(ctx, authFor(credential), fileID)
+	if err != nil && !errors.Is(err, pcloud.ErrNotFound) {
+		return err
 	}
+	return nil
+}
 
-	return uploadResp.Metadata[0].FileID, uploadResp.Metadata[0].Hash, nil
+// ReleaseStorage credits the storage of a removed file back to its account
+func (s *PCloudService) ReleaseStorage(credentialID uuid.UUID, fileSizeMB float64) error {
+	freedGB := fileSizeMB / 1024 * storageOverheadFactor
+	return s.pcloudRepo.ReleaseStorage(credentialID, freedGB)
 }
 
-// GetFileLink gets streaming link from pCloud
-func (s *PCloudService) GetFileLink(fileID int64, apiToken string) (string, time.Time, error) {
-	url := fmt.Sprintf("%s/getfilelink?auth=%s&fileid=%d", config.GlobalConfig.PCloud.BaseURL, apiToken, fileID)
+// PurgeVideo permanently removes a trashed video: the pCloud file, its wrapper
+// links and dependent rows, then credits the storage back to the account
+func (s *PCloudService) PurgeVideo(ctx context.Context, video *models.Video) error {
+	if video.PCloudFileID != "" {
+		// Without the account the file could not be deleted, only orphaned
+		if video.PCloudCredential == nil {
+			return fmt.Errorf("pCloud account %s of file %s not found", video.PCloudCredentialID, video.PCloudFileID)
+		}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", time.Time{}, err
-	}
-	defer resp.Body.Close()
+		fileID := int64(0)
+		fmt.Sscanf(video.PCloudFileID, "%d", &fileID)
 
-	var linkResp PCloudLinkResponse
-	if err := json.NewDecoder(resp.Body).Decode(&linkResp); err != nil {
-		return "", time.Time{}, err
+		// Keep the row if pCloud refuses, so the next purge run retries
+		if err := s.DeleteFile(ctx, video.PCloudCredential, fileID); err != nil {
+			return fmt.Errorf("failed to delete pCloud file %s: %w", video.PCloudFileID, err)
+		}
 	}
 
-	if linkResp.Result != 0 {
-		return "", time.Time{}, fmt.Errorf("pCloud getfilelink failed: %s", linkResp.Error)
+	if err := s.videoRepo.HardDelete(video.ID); err != nil {
+		return err
 	}
 
-	// Construct full URL
-	if len(linkResp.Hosts) == 0 {
-		return "", time.Time{}, errors.New("no hosts returned from pCloud")
+	if err := s.ReleaseStorage(video.PCloudCredentialID, video.FileSizeMB); err != nil {
+		// ⚠️ NON-CRITICAL ERROR: video is gone, only storage tracking is off
+		fmt.Printf("⚠️  WARNING: Failed to release storage for video %s: %v\n", video.ID, err)
 	}
 
-	fullURL := fmt.Sprintf("https://%s%s", linkResp.Hosts[0], linkResp.Path)
-
-	// Parse expiry (pCloud returns "Thu, 01 Jan 2026 00:00:00 +0000" format)
-	expiresAt, _ := time.Parse(time.RFC1123, linkResp.Expires)
-	if expiresAt.IsZero() {
-		// Default to 24 hours from now if parse fails
-		expiresAt = time.Now().Add(24 * time.Hour)
-	}
-
-	return fullURL, expiresAt, nil
+	return nil
 }
 
-// RefreshExpiredLinks refreshes expired video source URLs (called by cron)
-func (s *PCloudService) RefreshExpiredLinks() error {
-	videos, err := s.videoRepo.GetExpiredSourceURLVideos()
+// PurgeDeletedVideos purges videos that have been in trash longer than retention (called by cron)
+func (s *PCloudService) PurgeDeletedVideos(ctx context.Context, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+
+	videos, err := s.videoRepo.GetPurgeableVideos(cutoff, time.Now(), 500)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	for _, video := range videos {
-		if video.PCloudCredential == nil {
+	purged := 0
+	for i := range videos {
+		video := &videos[i]
+		if err := s.PurgeVideo(ctx, video); err != nil {
+			// Back off so failing videos do not keep newer ones out of the batch
+			retryAt := time.Now().Add(purgeBackoff(video.PurgeFailures + 1))
+			if recordErr := s.videoRepo.RecordPurgeFailure(video.ID, err.Error(), retryAt); recordErr != nil {
+				fmt.Printf("⚠️  WARNING: Failed to record purge failure for video %s: %v\n", video.ID, recordErr)
+			}
+			fmt.Printf("⚠️  WARNING: Failed to purge video %s (failure #%d): %v\n", video.ID, video.PurgeFailures+1, err)
 			continue
 		}
+		purged++
+	}
 
-		// Get new link
-		fileID := int64(0)
-		fmt.Sscanf(video.PCloudFileID, "%d", &fileID)
-
-		newURL, expiresAt, err := s.GetFileLink(fileID, video.PCloudCredential.APIToken)
-		if err != nil {
-			// Log error but continue with other videos
-			continue
-		}
+	return purged, nil
+}
 
-		// Update video
-		if err := s.videoRepo.UpdateSourceURL(video.ID, newURL, expiresAt); err != nil {
-			continue
-		}
+// purgeBackoff returns the wait after the given number of consecutive purge failures
+func purgeBackoff(failures int) time.Duration {
+	delay := purgeBackoffBase
+	for i := 1; i < failures && delay < purgeBackoffMax; i++ {
+		delay *= 2
 	}
-
-	return nil
+	if delay > purgeBackoffMax {
+		delay = purgeBackoffMax
+	}
+	return delay
 }
 
 // CreateCredential creates new pCloud credential (admin)
@@ -273,9 +244,9 @@ func (s *PCloudService) CreateCredential(credential *models.PCloudCredential) er
 	return s.pcloudRepo.Create(credential)
 }
 
-// UpdateCredential updates pCloud credential
-func (s *PCloudService) UpdateCredential(credential *models.PCloudCredential) error {
-	return s.pcloudRepo.Update(credential)
+// UpdateCredential writes the given columns of a pCloud credential
+func (s *PCloudService) UpdateCredential(credential *models.PCloudCredential, columns ...string) error {
+	return s.pcloudRepo.Update(credential, columns...)
 }
 
 // DeleteCredential deletes pCloud credential
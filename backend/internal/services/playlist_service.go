@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"bobastream/internal/utils"
+	"errors"
+	"fmt"
+	"html"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPlaylistNotFound      = errors.New("playlist not found")
+	ErrPlaylistInvalidKind   = errors.New("kind must be playlist or series")
+	ErrPlaylistInvalidAccess = errors.New("visibility must be public, unlisted or private")
+	ErrPlaylistItemNotFound  = errors.New("video is not in the playlist")
+	ErrPlaylistVideoNotFound = errors.New("video not found")
+	ErrPlaylistOrderMismatch = repositories.ErrPlaylistOrderMismatch
+)
+
+// maxPlaylistSlugLength leaves room for a collision suffix in the 255 character column
+const maxPlaylistSlugLength = 200
+
+// PlaylistNavItem is a neighboring item in playlist playback; Position is 1-based
+type PlaylistNavItem struct {
+	Position int           `json:"position"`
+	Video    *models.Video `json:"video"`
+}
+
+// PlaylistContext places a video within a playlist for playback: its 1-based
+// position among the published items and the items before and after it
+type PlaylistContext struct {
+	ID       uuid.UUID        `json:"id"`
+	Slug     string           `json:"slug"`
+	Title    string           `json:"title"`
+	Kind     string           `json:"kind"`
+	Position int              `json:"position"`
+	Total    int64            `json:"total"`
+	Previous *PlaylistNavItem `json:"previous"`
+	Next     *PlaylistNavItem `json:"next"`
+}
+
+// PlaylistService manages playlists and series and their ordered items
+type PlaylistService struct {
+	playlistRepo *repositories.PlaylistRepository
+	videoRepo    *repositories.VideoRepository
+}
+
+func NewPlaylistService(playlistRepo *repositories.PlaylistRepository, videoRepo *repositories.VideoRepository) *PlaylistService {
+	return &PlaylistService{
+		playlistRepo: playlistRepo,
+		videoRepo:    videoRepo,
+	}
+}
+
+// CreatePlaylist creates a playlist with a slug derived from its title (admin)
+func (s *PlaylistService) CreatePlaylist(playlist *models.Playlist) error {
+	if playlist.Kind == "" {
+		playlist.Kind = models.PlaylistKindPlaylist
+	}
+	if playlist.Visibility == "" {
+		playlist.Visibility = models.PlaylistPublic
+	}
+	if err := validatePlaylist(playlist); err != nil {
+		return err
+	}
+
+	slug, err := s.uniqueSlug(playlist.Title)
+	if err != nil {
+		return err
+	}
+	playlist.Slug = slug
+
+	return s.playlistRepo.Create(playlist)
+}
+
+// UpdatePlaylist saves playlist details; the slug stays, so shared links keep working (admin)
+func (s *PlaylistService) UpdatePlaylist(playlist *models.Playlist) error {
+	if err := validatePlaylist(playlist); err != nil {
+		return err
+	}
+	return s.playlistRepo.Update(playlist)
+}
+
+// DeletePlaylist soft deletes a playlist; its videos are kept (admin)
+func (s *PlaylistService) DeletePlaylist(id uuid.UUID) error {
+	if _, err := s.GetPlaylistByID(id); err != nil {
+		return err
+	}
+	return s.playlistRepo.Delete(id)
+}
+
+// GetPlaylistByID gets a playlist of any visibility (admin)
+func (s *PlaylistService) GetPlaylistByID(id uuid.UUID) (*models.Playlist, error) {
+	playlist, err := s.playlistRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlaylistNotFound
+	}
+	return playlist, err
+}
+
+// GetPlaylistWithItems gets a playlist with all its items, including
+// unpublished and trashed videos (admin)
+func (s *PlaylistService) GetPlaylistWithItems(id uuid.UUID) (*models.Playlist, error) {
+	playlist, err := s.GetPlaylistByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist.Items, err = s.playlistRepo.GetItems(id, false)
+	if err != nil {
+		return nil, err
+	}
+	playlist.ItemCount = int64(len(playlist.Items))
+	return playlist, nil
+}
+
+// GetAllPlaylists gets playlists of any visibility (admin)
+func (s *PlaylistService) GetAllPlaylists(page, limit int) ([]models.Playlist, int64, error) {
+	return s.playlistRepo.GetAll(page, limit)
+}
+
+// GetPublicPlaylists gets the listed playlists
+func (s *PlaylistService) GetPublicPlaylists(page, limit int) ([]models.Playlist, int64, error) {
+	return s.playlistRepo.GetPublic(page, limit)
+}
+
+// GetPublicPlaylist gets a public or unlisted playlist with its published items
+func (s *PlaylistService) GetPublicPlaylist(slug string) (*models.Playlist, error) {
+	playlist, err := s.findViewable(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist.Items, err = s.playlistRepo.GetItems(playlist.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	playlist.ItemCount = int64(len(playlist.Items))
+	return playlist, nil
+}
+
+// AddVideos appends videos to a playlist; videos already in it keep their place (admin)
+func (s *PlaylistService) AddVideos(id uuid.UUID, videoIDs []uuid.UUID) (*models.Playlist, error) {
+	if _, err := s.GetPlaylistByID(id); err != nil {
+		return nil, err
+	}
+	for _, videoID := range videoIDs {
+		if _, err := s.videoRepo.FindByID(videoID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrPlaylistVideoNotFound, videoID)
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.playlistRepo.AddItems(id, videoIDs); err != nil {
+		return nil, err
+	}
+	return s.GetPlaylistWithItems(id)
+}
+
+// RemoveVideo removes a video from a playlist (admin)
+func (s *PlaylistService) RemoveVideo(id, videoID uuid.UUID) (*models.Playlist, error) {
+	if _, err := s.GetPlaylistByID(id); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.playlistRepo.RemoveItem(id, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrPlaylistItemNotFound
+	}
+	return s.GetPlaylistWithItems(id)
+}
+
+// ReorderVideos sets the order of a playlist from the full list of its video IDs (admin)
+func (s *PlaylistService) ReorderVideos(id uuid.UUID, videoIDs []uuid.UUID) (*models.Playlist, error) {
+	err := s.playlistRepo.Reorder(id, videoIDs)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPlaylistWithItems(id)
+}
+
+// GetPlaybackContext gets the previous and next items around a video watched
+// from a public or unlisted playlist. Returns ErrPlaylistNotFound when the
+// playlist is not viewable and ErrPlaylistItemNotFound when the video is not a
+// published item of it.
+func (s *PlaylistService) GetPlaybackContext(slug string, videoID uuid.UUID) (*PlaylistContext, error) {
+	playlist, err := s.findViewable(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors, err := s.playlistRepo.Neighbors(playlist.ID, videoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlaylistItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	playback := &PlaylistContext{
+		ID:       playlist.ID,
+		Slug:     playlist.Slug,
+		Title:    playlist.Title,
+		Kind:     playlist.Kind,
+		Position: neighbors.Position + 1,
+		Total:    neighbors.Total,
+	}
+	if neighbors.Previous != nil {
+		playback.Previous = &PlaylistNavItem{Position: neighbors.Position, Video: neighbors.Previous.Video}
+	}
+	if neighbors.Next != nil {
+		playback.Next = &PlaylistNavItem{Position: neighbors.Position + 2, Video: neighbors.Next.Video}
+	}
+	return playback, nil
+}
+
+// findViewable finds a playlist by slug unless it is private
+func (s *PlaylistService) findViewable(slug string) (*models.Playlist, error) {
+	playlist, err := s.playlistRepo.FindBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Visibility == models.PlaylistPrivate {
+		return nil, ErrPlaylistNotFound
+	}
+	return playlist, nil
+}
+
+// uniqueSlug derives a slug from the (sanitized) title, adding -2, -3, ... on collision
+func (s *PlaylistService) uniqueSlug(title string) (string, error) {
+	base := utils.Slugify(html.UnescapeString(title), maxPlaylistSlugLength)
+	if base == "" {
+		base = "playlist"
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		exists, err := s.playlistRepo.SlugExists(slug)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+func validatePlaylist(playlist *models.Playlist) error {
+	switch playlist.Kind {
+	case models.PlaylistKindPlaylist, models.PlaylistKindSeries:
+	default:
+		return ErrPlaylistInvalidKind
+	}
+	switch playlist.Visibility {
+	case models.PlaylistPublic, models.PlaylistUnlisted, models.PlaylistPrivate:
+	default:
+		return ErrPlaylistInvalidAccess
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"bobastream/internal/cache"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Where a recommendation comes from
+const (
+	RecommendationPersonalized = "personalized"
+	RecommendationTrending     = "trending"
+)
+
+const (
+	// likeInteraction is what a like adds to a view of the video (at most 1)
+	likeInteraction = 1.0
+	// similarityShrinkage damps similarities backed by few common viewers:
+	// with n of them a similarity counts n/(n+5)
+	similarityShrinkage = 5.0
+)
+
+// Trending videos are the same for every viewer, so the top of the list is
+// computed once per TTL and each viewer's share is picked from it
+const (
+	trendingCacheKey = "recommendations:trending"
+	trendingCacheTTL = 5 * time.Minute
+	trendingPoolSize = 200
+)
+
+// Viewer identifies whose history recommendations come from
+type Viewer = repositories.Viewer
+
+// RecommendationOptions configures personalized recommendations
+type RecommendationOptions struct {
+	HistoryWindow    time.Duration // Views and likes older than this are ignored
+	HistorySize      int           // Most recent history videos recommendations start from
+	MinHistory       int           // History videos an anonymous session needs for personalization
+	CompletedPercent float64       // Watched share at which a video is never recommended again
+	Neighbors        int           // Similar videos kept per video in the model
+	MinViewers       int           // Common viewers two videos need to be similar
+	TrendingPercent  int           // Share of the recommendations taken by trending videos
+	TrendingWindow   time.Duration // Views counted for trending videos
+}
+
+// RecommendationContribution is how much a video in the viewer's history
+// added to a recommendation: its interaction times its similarity
+type RecommendationContribution struct {
+	VideoID     uuid.UUID `json:"video_id"`
+	Title       string    `json:"title,omitempty"`
+	Interaction float64   `json:"interaction"`
+	Similarity  float64   `json:"similarity"`
+	Viewers     int       `json:"viewers"`
+	Score       float64   `json:"score"`
+}
+
+// Recommendation is a recommended video and where it comes from
+type Recommendation struct {
+	models.Video
+	Source  string                       `json:"recommendation_source"`
+	Score   float64                      `json:"-"`
+	Because []RecommendationContribution `json:"-"`
+}
+
+// RecommendationExplanation tells why a video is (not) recommended to a viewer
+type RecommendationExplanation struct {
+	VideoID      uuid.UUID                    `json:"video_id"`
+	Personalized bool                         `json:"personalized"` // The viewer gets videos similar to their history
+	HistorySize  int                          `json:"history_size"`
+	Recommended  bool                         `json:"recommended"`
+	Position     int                          `json:"position,omitempty"` // 1-based
+	Source       string                       `json:"source,omitempty"`
+	Completed    bool                         `json:"completed"` // Excluded: watched to the end
+	Score        float64                      `json:"score"`
+	Because      []RecommendationContribution `json:"because"`
+}
+
+// recommendationRun is a computation of recommendations with its inputs
+type recommendationRun struct {
+	history      []repositories.HistoryItem
+	personalized bool
+	candidates   map[uuid.UUID]*Recommendation // Scored from the history, unloaded
+	completed    map[uuid.UUID]bool
+	results      []Recommendation
+}
+
+// RecommendationService recommends videos to a viewer from the videos they
+// watched and liked, using an item-item similarity model rebuilt periodically,
+// blended with trending videos for diversity
+type RecommendationService struct {
+	recommendationRepo *repositories.RecommendationRepository
+	videoRepo          *repositories.VideoRepository
+	opts               RecommendationOptions
+}
+
+func NewRecommendationService(
+	recommendationRepo *repositories.RecommendationRepository,
+	videoRepo *repositories.VideoRepository,
+	opts RecommendationOptions,
+) *RecommendationService {
+	return &RecommendationService{
+		recommendationRepo: recommendationRepo,
+		videoRepo:          videoRepo,
+		opts:               opts,
+	}
+}
+
+// GetRecommendations recommends videos to a viewer. Logged-in users, and
+// anonymous sessions with enough history, get videos similar to what they
+// watched and liked; others get trending videos only (personalized is false).
+// Videos the viewer completed are never recommended.
+func (s *RecommendationService) GetRecommendations(viewer Viewer, limit int) ([]Recommendation, bool, error) {
+	run, err := s.recommend(viewer, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	return run.results, run.personalized, nil
+}
+
+// Explain tells why a video is or is not among the first limit
+// recommendations of a viewer (admin)
+func (s *RecommendationService) Explain(viewer Viewer, videoID uuid.UUID, limit int) (*RecommendationExplanation, error) {
+	if _, err := s.videoRepo.FindByID(videoID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+
+	run, err := s.recommend(viewer, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &RecommendationExplanation{
+		VideoID:      videoID,
+		Personalized: run.personalized,
+		HistorySize:  len(run.history),
+		Completed:    run.completed[videoID],
+		Because:      []RecommendationContribution{},
+	}
+	for i, recommendation := range run.results {
+		if recommendation.ID == videoID {
+			explanation.Recommended = true
+			explanation.Position = i + 1
+			explanation.Source = recommendation.Source
+			break
+		}
+	}
+	if candidate, ok := run.candidates[videoID]; ok {
+		explanation.Score = candidate.Score
+		explanation.Because = candidate.Because
+	}
+
+	// Name the history videos behind the score
+	ids := make([]uuid.UUID, len(explanation.Because))
+	for i, contribution := range explanation.Because {
+		ids[i] = contribution.VideoID
+	}
+	videos, err := s.videoRepo.FindPublishedByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[uuid.UUID]string, len(videos))
+	for _, video := range videos {
+		titles[video.ID] = video.Title
+	}
+	for i := range explanation.Because {
+		explanation.Because[i].Title = titles[explanation.Because[i].VideoID]
+	}
+
+	return explanation, nil
+}
+
+// RebuildModel recomputes the video similarity model and returns how many
+// similarities were stored
+func (s *RecommendationService) RebuildModel() (int64, error) {
+	return s.recommendationRepo.RebuildSimilarities(repositories.SimilarityModel{
+		Since:         time.Now().Add(-s.opts.HistoryWindow),
+		LikeWeight:    likeInteraction,
+		ViewerHistory: s.opts.HistorySize,
+		MinViewers:    s.opts.MinViewers,
+		Shrinkage:     similarityShrinkage,
+		Neighbors:     s.opts.Neighbors,
+	})
+}
+
+func (s *RecommendationService) recommend(viewer Viewer, limit int) (*recommendationRun, error) {
+	run := &recommendationRun{
+		candidates: make(map[uuid.UUID]*Recommendation),
+		completed:  make(map[uuid.UUID]bool),
+	}
+
+	if viewer.UserID != nil || viewer.SessionID != "" {
+		history, err := s.recommendationRepo.GetHistory(viewer, time.Now().Add(-s.opts.HistoryWindow), likeInteraction, s.opts.HistorySize)
+		if err != nil {
+			return nil, err
+		}
+		run.history = history
+
+		completed, err := s.recommendationRepo.GetCompleted(viewer, s.opts.CompletedPercent)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range completed {
+			run.completed[id] = true
+		}
+	}
+
+	var personalized []Recommendation
+	if viewer.UserID != nil || len(run.history) >= s.opts.MinHistory {
+		if err := s.scoreCandidates(run); err != nil {
+			return nil, err
+		}
+		var err error
+		if personalized, err = s.loadTop(run.candidates, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	// Trending videos fill the slots personalization leaves, at least its share
+	exclude := make(map[uuid.UUID]bool, len(run.completed)+len(personalized))
+	for id := range run.completed {
+		exclude[id] = true
+	}
+	for _, recommendation := range personalized {
+		exclude[recommendation.ID] = true
+	}
+	trending, err := s.trending(exclude, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without similar videos the viewer only gets trending ones
+	run.personalized = len(personalized) > 0
+	run.results = blendRecommendations(personalized, trending, limit, s.opts.TrendingPercent)
+	return run, nil
+}
+
+// trending gets the top trending videos not excluded, from the cached pool
+// unless the viewer excludes too much of it
+func (s *RecommendationService) trending(exclude map[uuid.UUID]bool, limit int) ([]models.Video, error) {
+	ctx := context.Background()
+	since := time.Now().Add(-s.opts.TrendingWindow)
+
+	var pool []models.Video
+	if err := cache.Get(ctx, trendingCacheKey, &pool); err != nil {
+		if pool, err = s.videoRepo.GetTrendingVideos(since, nil, trendingPoolSize); err != nil {
+			return nil, err
+		}
+		cache.Set(ctx, trendingCacheKey, pool, trendingCacheTTL)
+	}
+
+	trending := make([]models.Video, 0, limit)
+	for _, video := range pool {
+		if len(trending) == limit {
+			return trending, nil
+		}
+		if !exclude[video.ID] {
+			trending = append(trending, video)
+		}
+	}
+	if len(trending) == limit || len(pool) < trendingPoolSize {
+		return trending, nil // No more published videos beyond the pool
+	}
+
+	ids := make([]uuid.UUID, 0, len(exclude))
+	for id := range exclude {
+		ids = append(ids, id)
+	}
+	return s.videoRepo.GetTrendingVideos(since, ids, limit)
+}
+
+// scoreCandidates scores the videos similar to the history: each history
+// video adds its interaction times its similarity
+func (s *RecommendationService) scoreCandidates(run *recommendationRun) error {
+	interactions := make(map[uuid.UUID]float64, len(run.history))
+	ids := make([]uuid.UUID, len(run.history))
+	for i, item := range run.history {
+		interactions[item.VideoID] = item.Weight
+		ids[i] = item.VideoID
+	}
+
+	similarities, err := s.recommendationRepo.GetSimilar(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, similarity := range similarities {
+		if run.completed[similarity.SimilarVideoID] {
+			continue
+		}
+		contribution := RecommendationContribution{
+			VideoID:     similarity.VideoID,
+			Interaction: interactions[similarity.VideoID],
+			Similarity:  similarity.Score,
+			Viewers:     similarity.Viewers,
+			Score:       interactions[similarity.VideoID] * similarity.Score,
+		}
+
+		candidate, ok := run.candidates[similarity.SimilarVideoID]
+		if !ok {
+			candidate = &Recommendation{Source: RecommendationPersonalized}
+			candidate.ID = similarity.SimilarVideoID
+			run.candidates[similarity.SimilarVideoID] = candidate
+		}
+		candidate.Score += contribution.Score
+		candidate.Because = append(candidate.Because, contribution)
+	}
+
+	for _, candidate := range run.candidates {
+		sort.Slice(candidate.Because, func(i, j int) bool {
+			return candidate.Because[i].Score > candidate.Because[j].Score
+		})
+	}
+	return nil
+}
+
+// loadTop loads the best scored candidates that are still published, best first
+func (s *RecommendationService) loadTop(candidates map[uuid.UUID]*Recommendation, limit int) ([]Recommendation, error) {
+	ranked := make([]*Recommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		ranked = append(ranked, candidate)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ID.String() < ranked[j].ID.String()
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	ids := make([]uuid.UUID, len(ranked))
+	for i, candidate := range ranked {
+		ids[i] = candidate.ID
+	}
+	videos, err := s.videoRepo.FindPublishedByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[uuid.UUID]models.Video, len(videos))
+	for _, video := range videos {
+		loaded[video.ID] = video
+	}
+
+	top := make([]Recommendation, 0, len(ranked))
+	for _, candidate := range ranked {
+		if video, ok := loaded[candidate.ID]; ok {
+			recommendation := *candidate
+			recommendation.Video = video
+			top = append(top, recommendation)
+		}
+	}
+	return top, nil
+}
+
+// blendRecommendations spreads trending videos evenly among the personalized
+// ones, trendingPercent of the slots; when either runs out the other fills in
+func blendRecommendations(personalized []Recommendation, trending []models.Video, limit, trendingPercent int) []Recommendation {
+	trendingSlots := limit * trendingPercent / 100
+	blended := make([]Recommendation, 0, limit)
+
+	p, t := 0, 0
+	for i := 0; i < limit && (p < len(personalized) || t < len(trending)); i++ {
+		// Slot i is trending when it crosses the next multiple of the share
+		trendingSlot := (i+1)*trendingSlots/limit > i*trendingSlots/limit
+		if t < len(trending) && (trendingSlot || p == len(personalized)) {
+			blended = append(blended, Recommendation{Video: trending[t], Source: RecommendationTrending})
+			t++
+			continue
+		}
+		blended = append(blended, personalized[p])
+		p++
+	}
+	return blended
+}
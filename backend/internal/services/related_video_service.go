@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bobastream/internal/cache"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RelatedVideoOptions configures the related videos computation
+type RelatedVideoOptions struct {
+	PerVideo        int           // Related videos kept per video
+	CoViewWindow    time.Duration // How far back views count as co-views
+	RecencyHalfLife int           // Days after which the recency boost halves
+}
+
+// relatedCacheTTL bounds how long a video's related videos are served from
+// cache; a rebuild drops them all
+const relatedCacheTTL = 15 * time.Minute
+
+// relatedCachePattern matches the cached related videos of every video
+const relatedCachePattern = "related:*"
+
+// RelatedVideoService finds videos related to a video. Related videos are
+// precomputed nightly from shared tags, co-views (videos watched in the same
+// session), category and recency; videos not covered yet are scored live.
+type RelatedVideoService struct {
+	relatedRepo *repositories.RelatedVideoRepository
+	videoRepo   *repositories.VideoRepository
+	opts        RelatedVideoOptions
+}
+
+func NewRelatedVideoService(
+	relatedRepo *repositories.RelatedVideoRepository,
+	videoRepo *repositories.VideoRepository,
+	opts RelatedVideoOptions,
+) *RelatedVideoService {
+	return &RelatedVideoService{
+		relatedRepo: relatedRepo,
+		videoRepo:   videoRepo,
+		opts:        opts,
+	}
+}
+
+// GetRelatedVideos gets the videos related to a video, most related first.
+// Precomputed ones come first; new videos, and videos sharing too few tags or
+// viewers, are topped up by a live score of tags, category and recency.
+func (s *RelatedVideoService) GetRelatedVideos(videoID uuid.UUID, limit int) ([]models.Video, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf("related:%s:limit:%d", videoID, limit)
+
+	// ✅ Try cache first
+	var cached []models.Video
+	if err := cache.Get(ctx, cacheKey, &cached); err == nil {
+		return cached, nil
+	}
+
+	video, err := s.videoRepo.FindByID(videoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVideoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	videos, err := s.relatedRepo.GetRelated(videoID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(videos) < limit {
+		// ✅ Cold start fallback
+		exclude := make([]uuid.UUID, len(videos))
+		for i, related := range videos {
+			exclude[i] = related.ID
+		}
+		fallback, err := s.videoRepo.GetRelatedVideos(video, exclude, float64(s.opts.RecencyHalfLife), limit-len(videos))
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, fallback...)
+	}
+
+	cache.Set(ctx, cacheKey, videos, relatedCacheTTL)
+	return videos, nil
+}
+
+// Rebuild recomputes the related videos of every published video and returns
+// how many were stored
+func (s *RelatedVideoService) Rebuild() (int64, error) {
+	stored, err := s.relatedRepo.Rebuild(repositories.RelatedScoring{
+		CoViewSince:  time.Now().Add(-s.opts.CoViewWindow),
+		HalfLifeDays: float64(s.opts.RecencyHalfLife),
+		PerVideo:     s.opts.PerVideo,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	cache.DeletePattern(context.Background(), relatedCachePattern)
+	return stored, nil
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bobastream/internal/cache"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// searchConfigSetting is the settings key read by the search_vector trigger
+const searchConfigSetting = "search.ts_config"
+
+// Private-use characters mark matches in ts_headline output; they cannot be
+// confused with text and survive HTML escaping, then become <mark> tags
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// Autocomplete limits: prefixes shorter than suggestMinLength get no suggestions,
+// and each source contributes at most its cap to a list of suggestMaxResults
+const (
+	suggestMinLength     = 2
+	suggestMaxLength     = 50
+	suggestMaxResults    = 10
+	suggestQueryLimit    = 3
+	suggestCategoryLimit = 2
+	suggestTagLimit      = 3
+	suggestTitleLimit    = 5
+	popularMinSearches   = 2 // One-off queries are never suggested
+)
+
+// SearchConfigs lists the supported Postgres text search configurations
+var SearchConfigs = []string{"simple", "indonesian", "english"}
+
+// SearchOptions tunes full-text search
+type SearchOptions struct {
+	Config           string  // Text search configuration, one of SearchConfigs
+	PopularityWeight float64 // 0..1 share of the ranking taken by views and likes
+	SuggestCacheTTL  time.Duration
+}
+
+// SearchHighlights are HTML fragments with matches wrapped in <mark>
+type SearchHighlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// SearchResult is a video matched by search with its relevance
+type SearchResult struct {
+	models.Video
+	Rank       float64           `json:"rank"`
+	Highlights *SearchHighlights `json:"highlights,omitempty"`
+}
+
+// SearchSuggestion is an autocomplete entry for the search box
+type SearchSuggestion struct {
+	Text string `json:"text"`
+	Type string `json:"type"`           // query, category, tag or title
+	Slug string `json:"slug,omitempty"` // Category or tag slug, to open its page directly
+}
+
+// SearchService runs full-text video search and autocomplete
+type SearchService struct {
+	videoRepo       *repositories.VideoRepository
+	categoryRepo    *repositories.CategoryRepository
+	tagRepo         *repositories.TagRepository
+	searchQueryRepo *repositories.SearchQueryRepository
+	settingRepo     *repositories.SettingRepository
+	opts            SearchOptions
+}
+
+func NewSearchService(
+	videoRepo *repositories.VideoRepository,
+	categoryRepo *repositories.CategoryRepository,
+	tagRepo *repositories.TagRepository,
+	searchQueryRepo *repositories.SearchQueryRepository,
+	settingRepo *repositories.SettingRepository,
+	opts SearchOptions,
+) *SearchService {
+	if !validSearchConfig(opts.Config) {
+		fmt.Printf("⚠️  WARNING: Unknown search text configuration '%s', using simple\n", opts.Config)
+		opts.Config = "simple"
+	}
+	if opts.PopularityWeight < 0 || opts.PopularityWeight > 1 {
+		opts.PopularityWeight = 0.2
+	}
+	if opts.SuggestCacheTTL <= 0 {
+		opts.SuggestCacheTTL = time.Minute
+	}
+
+	return &SearchService{
+		videoRepo:       videoRepo,
+		categoryRepo:    categoryRepo,
+		tagRepo:         tagRepo,
+		searchQueryRepo: searchQueryRepo,
+		settingRepo:     settingRepo,
+		opts:            opts,
+	}
+}
+
+func validSearchConfig(name string) bool {
+	for _, config := range SearchConfigs {
+		if name == config {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncConfig makes the database use the configured text search configuration,
+// rebuilding all search vectors when it changed. Called once at startup.
+func (s *SearchService) SyncConfig() error {
+	exists, err := s.videoRepo.TextSearchConfigExists(s.opts.Config)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		fmt.Printf("⚠️  WARNING: Text search configuration '%s' is not installed in Postgres, using simple\n", s.opts.Config)
+		s.opts.Config = "simple"
+	}
+
+	current, ok, err := s.settingRepo.Get(searchConfigSetting)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		current = "simple" // Default of video_search_config()
+	}
+	if current == s.opts.Config {
+		return nil
+	}
+
+	// The setting only changes if the rebuild succeeds, so a failed one is retried next startup
+	rebuilt, err := s.videoRepo.RebuildSearchVectors(searchConfigSetting, s.opts.Config)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild search vectors: %w", err)
+	}
+
+	fmt.Printf("✅ Search configuration changed from %s to %s, rebuilt %d search vectors\n", current, s.opts.Config, rebuilt)
+	return nil
+}
+
+// Search finds published videos by keyword, narrowed by filter. The keyword
+// uses web search syntax: "exact phrase", or, -exclude.
+func (s *SearchService) Search(keyword string, filter VideoFilter, sort VideoSort, page, limit int) ([]SearchResult, int64, error) {
+	hits, total, err := s.videoRepo.SearchVideos(repositories.VideoSearchQuery{
+		TSConfig:         s.opts.Config,
+		Keyword:          keyword,
+		Filter:           filter,
+		Sort:             sort,
+		PopularityWeight: s.opts.PopularityWeight,
+		StartSel:         highlightStart,
+		StopSel:          highlightStop,
+	}, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Submitted queries that found something feed the popular suggestions
+	if keyword != "" && page == 1 && total > 0 {
+		if err := s.searchQueryRepo.Record(normalizeQuery(keyword, 100), int(total)); err != nil {
+			fmt.Printf("⚠️  WARNING: Failed to record search query: %v\n", err)
+		}
+	}
+
+	results := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = SearchResult{
+			Video: hit.Video,
+			Rank:  hit.Rank,
+		}
+		if keyword != "" {
+			results[i].Highlights = &SearchHighlights{
+				Title:       highlightHTML(hit.TitleHighlight),
+				Description: highlightHTML(hit.DescriptionHighlight),
+			}
+		}
+	}
+
+	return results, total, nil
+}
+
+// Facets counts the matches of a search per category and duration bucket
+func (s *SearchService) Facets(keyword string, filter VideoFilter) (*repositories.VideoFacets, error) {
+	return s.videoRepo.SearchFacets(repositories.VideoSearchQuery{
+		TSConfig: s.opts.Config,
+		Keyword:  keyword,
+		Filter:   filter,
+	})
+}
+
+// Suggest gets autocomplete entries for a partially typed query: popular past
+// queries, category names, tags and video titles. Results are cached briefly.
+func (s *SearchService) Suggest(prefix string) ([]SearchSuggestion, error) {
+	prefix = normalizeQuery(prefix, suggestMaxLength)
+	if len([]rune(prefix)) < suggestMinLength {
+		return []SearchSuggestion{}, nil
+	}
+
+	ctx := context.Background()
+	cacheKey := "search:suggest:" + prefix
+
+	var cached []SearchSuggestion
+	if err := cache.Get(ctx, cacheKey, &cached); err == nil {
+		return cached, nil
+	}
+
+	suggestions := make([]SearchSuggestion, 0, suggestMaxResults)
+	seen := make(map[string]bool)
+	add := func(text, kind, slug string) {
+		key := strings.ToLower(text)
+		if text == "" || seen[key] || len(suggestions) >= suggestMaxResults {
+			return
+		}
+		seen[key] = true
+		suggestions = append(suggestions, SearchSuggestion{Text: text, Type: kind, Slug: slug})
+	}
+
+	queries, err := s.searchQueryRepo.Popular(prefix, popularMinSearches, suggestQueryLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, query := range queries {
+		add(query, "query", "")
+	}
+
+	categories, err := s.categoryRepo.SuggestByName(prefix, suggestCategoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		add(html.UnescapeString(category.Name), "category", category.Slug)
+	}
+
+	tags, err := s.tagRepo.SuggestNames(prefix, suggestTagLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		add(tag.Name, "tag", tag.Slug)
+	}
+
+	titles, err := s.videoRepo.SuggestTitles(prefix, suggestTitleLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, title := range titles {
+		add(title, "title", "")
+	}
+
+	cache.Set(ctx, cacheKey, suggestions, s.opts.SuggestCacheTTL)
+	return suggestions, nil
+}
+
+// normalizeQuery lowercases a query, collapses whitespace and limits its length
+func normalizeQuery(query string, maxLength int) string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if runes := []rune(query); len(runes) > maxLength {
+		query = strings.TrimSpace(string(runes[:maxLength]))
+	}
+	return query
+}
+
+// highlightHTML escapes a ts_headline fragment and turns the match markers into <mark> tags
+func highlightHTML(fragment string) string {
+	escaped := html.EscapeString(fragment)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
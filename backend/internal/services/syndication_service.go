@@ -0,0 +1,484 @@
+package services
+
+import (
+	"bobastream/internal/cache"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrSitemapPageNotFound = errors.New("sitemap page not found")
+
+// syndicationCachePattern matches every cached sitemap and feed
+const syndicationCachePattern = "syndication:*"
+
+const (
+	siteName = "BOBA STREAM"
+
+	// Google video sitemap limits
+	maxSitemapDescription = 2048
+	maxSitemapTags        = 32
+)
+
+// FeedFormat is a syndication feed format
+type FeedFormat string
+
+const (
+	FeedRSS  FeedFormat = "rss"
+	FeedAtom FeedFormat = "atom"
+)
+
+// FeedScope narrows a feed to a category (with its subcategories) or a tag;
+// the zero value is the feed of all videos
+type FeedScope struct {
+	CategorySlug string // Slug path, e.g. "music/live"
+	TagSlug      string
+}
+
+// SyndicationOptions configures sitemaps and feeds
+type SyndicationOptions struct {
+	PublicURL       string // Prefix of every link, without a trailing slash
+	SitemapPageSize int
+	FeedSize        int
+	CacheTTL        time.Duration
+}
+
+// SyndicationService renders XML sitemaps and RSS/Atom feeds of published videos
+type SyndicationService struct {
+	videoRepo       *repositories.VideoRepository
+	categoryService *CategoryService
+	tagService      *TagService
+	opts            SyndicationOptions
+}
+
+func NewSyndicationService(
+	videoRepo *repositories.VideoRepository,
+	categoryService *CategoryService,
+	tagService *TagService,
+	opts SyndicationOptions,
+) *SyndicationService {
+	return &SyndicationService{
+		videoRepo:       videoRepo,
+		categoryService: categoryService,
+		tagService:      tagService,
+		opts:            opts,
+	}
+}
+
+// invalidateSyndication drops cached sitemaps and feeds after videos changed
+func invalidateSyndication() {
+	cache.DeletePattern(context.Background(), syndicationCachePattern)
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc string `xml:"loc"`
+}
+
+type sitemapURLSet struct {
+	XMLName    xml.Name     `xml:"urlset"`
+	Xmlns      string       `xml:"xmlns,attr"`
+	XmlnsVideo string       `xml:"xmlns:video,attr"`
+	URLs       []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string        `xml:"loc"`
+	LastMod string        `xml:"lastmod"`
+	Video   *sitemapVideo `xml:"video:video,omitempty"`
+}
+
+// sitemapVideo is a Google video sitemap entry
+type sitemapVideo struct {
+	ThumbnailLoc    string   `xml:"video:thumbnail_loc"`
+	Title           string   `xml:"video:title"`
+	Description     string   `xml:"video:description"`
+	ContentLoc      string   `xml:"video:content_loc"`
+	Duration        int      `xml:"video:duration,omitempty"`
+	ViewCount       int      `xml:"video:view_count"`
+	PublicationDate string   `xml:"video:publication_date,omitempty"`
+	Tags            []string `xml:"video:tag"`
+}
+
+// SitemapIndex renders the sitemap index listing one child sitemap per page of videos
+func (s *SyndicationService) SitemapIndex() ([]byte, error) {
+	return s.cached("syndication:sitemap", func() ([]byte, error) {
+		pages, err := s.sitemapPages()
+		if err != nil {
+			return nil, err
+		}
+
+		index := sitemapIndex{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+		for page := 1; page <= pages; page++ {
+			index.Sitemaps = append(index.Sitemaps, sitemapEntry{Loc: s.SitemapPageURL(page)})
+		}
+		return marshalXML(index)
+	})
+}
+
+// SitemapPage renders a child sitemap: the watch pages of a page of videos,
+// oldest first so pages rarely change, with video extensions
+func (s *SyndicationService) SitemapPage(page int) ([]byte, error) {
+	return s.cached(fmt.Sprintf("syndication:sitemap:%d", page), func() ([]byte, error) {
+		pages, err := s.sitemapPages()
+		if err != nil {
+			return nil, err
+		}
+		if page < 1 || page > pages {
+			return nil, ErrSitemapPageNotFound
+		}
+
+		videos, _, err := s.videoRepo.ListVideos(VideoFilter{}, repositories.SortOldest, page, s.opts.SitemapPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		set := sitemapURLSet{
+			Xmlns:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+			XmlnsVideo: "http://www.google.com/schemas/sitemap-video/1.1",
+			URLs:       []sitemapURL{},
+		}
+		for i := range videos {
+			video := &videos[i]
+			if video.WrapperLink == nil {
+				continue
+			}
+			entry := sitemapURL{
+				Loc:     s.WatchURL(video),
+				LastMod: video.UpdatedAt.UTC().Format(time.RFC3339),
+			}
+			// Google requires a thumbnail for video entries
+			if video.ThumbnailURL != "" {
+				entry.Video = s.sitemapVideo(video)
+			}
+			set.URLs = append(set.URLs, entry)
+		}
+		return marshalXML(set)
+	})
+}
+
+func (s *SyndicationService) sitemapVideo(video *models.Video) *sitemapVideo {
+	description := html.UnescapeString(video.Description)
+	if description == "" {
+		description = html.UnescapeString(video.Title)
+	}
+	if runes := []rune(description); len(runes) > maxSitemapDescription {
+		description = string(runes[:maxSitemapDescription])
+	}
+
+	tags := make([]string, 0, len(video.Tags))
+	for _, tag := range video.Tags {
+		if len(tags) == maxSitemapTags {
+			break
+		}
+		tags = append(tags, html.UnescapeString(tag))
+	}
+
+	entry := &sitemapVideo{
+		ThumbnailLoc: html.UnescapeString(video.ThumbnailURL),
+		Title:        html.UnescapeString(video.Title),
+		Description:  description,
+		ContentLoc:   s.opts.PublicURL + "/stream/" + video.WrapperLink.WrapperToken,
+		Duration:     video.DurationSeconds,
+		ViewCount:    video.ViewCount,
+		Tags:         tags,
+	}
+	if video.PublishedAt != nil {
+		entry.PublicationDate = video.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	return entry
+}
+
+func (s *SyndicationService) sitemapPages() (int, error) {
+	total, err := s.videoRepo.CountPublished()
+	if err != nil {
+		return 0, err
+	}
+	pages := int((total + int64(s.opts.SitemapPageSize) - 1) / int64(s.opts.SitemapPageSize))
+	return max(pages, 1), nil
+}
+
+// SitemapPageURL is the public URL of a child sitemap
+func (s *SyndicationService) SitemapPageURL(page int) string {
+	return fmt.Sprintf("%s/sitemaps/videos-%d.xml", s.opts.PublicURL, page)
+}
+
+// WatchURL is the public watch page URL of a video with a wrapper link
+func (s *SyndicationService) WatchURL(video *models.Video) string {
+	return s.opts.PublicURL + video.WrapperLink.WatchPath()
+}
+
+// feedSource is a resolved feed scope
+type feedSource struct {
+	key    string // Cache key part
+	title  string
+	path   string // Feed path with the scope query, without the format
+	filter VideoFilter
+}
+
+// resolve finds the category or tag of a scope; unknown ones return
+// ErrCategoryNotFound or ErrTagNotFound
+func (s *SyndicationService) resolve(scope FeedScope) (*feedSource, error) {
+	switch {
+	case scope.CategorySlug != "":
+		category, err := s.categoryService.GetCategoryBySlug(scope.CategorySlug)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := s.categoryService.GetSubtreeIDs(category.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &feedSource{
+			key:    "category:" + category.ID.String(),
+			title:  siteName + " - " + html.UnescapeString(category.Name),
+			path:   "?category=" + url.QueryEscape(scope.CategorySlug),
+			filter: VideoFilter{CategoryIDs: ids},
+		}, nil
+	case scope.TagSlug != "":
+		tag, err := s.tagService.GetTagBySlug(scope.TagSlug)
+		if err != nil {
+			return nil, err
+		}
+		return &feedSource{
+			key:    "tag:" + tag.ID.String(),
+			title:  siteName + " - #" + html.UnescapeString(tag.Name),
+			path:   "?tag=" + url.QueryEscape(tag.Slug),
+			filter: VideoFilter{Tags: []string{tag.Slug}},
+		}, nil
+	}
+	return &feedSource{key: "all", title: siteName + " - Latest videos"}, nil
+}
+
+// Feed renders the latest published videos of a scope as RSS 2.0 or Atom
+func (s *SyndicationService) Feed(format FeedFormat, scope FeedScope) ([]byte, error) {
+	source, err := s.resolve(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cached(fmt.Sprintf("syndication:%s:%s", format, source.key), func() ([]byte, error) {
+		videos, _, err := s.videoRepo.ListVideos(source.filter, repositories.SortNewest, 1, s.opts.FeedSize)
+		if err != nil {
+			return nil, err
+		}
+
+		selfURL := fmt.Sprintf("%s/feeds/%s.xml%s", s.opts.PublicURL, format, source.path)
+		if format == FeedAtom {
+			return marshalXML(s.atomFeed(source, selfURL, videos))
+		}
+		return marshalXML(s.rssFeed(source, selfURL, videos))
+	})
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	XmlnsAtom string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func (s *SyndicationService) rssFeed(source *feedSource, selfURL string, videos []models.Video) rssFeed {
+	feed := rssFeed{
+		Version:   "2.0",
+		XmlnsAtom: "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         source.title,
+			Link:          s.opts.PublicURL + "/",
+			Description:   source.title,
+			SelfLink:      atomLink{Href: selfURL, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for i := range videos {
+		video := &videos[i]
+		if video.WrapperLink == nil {
+			continue
+		}
+		item := rssItem{
+			Title:       html.UnescapeString(video.Title),
+			Link:        s.WatchURL(video),
+			GUID:        rssGUID{Value: videoURN(video.ID)},
+			PubDate:     videoPublished(video).UTC().Format(time.RFC1123Z),
+			Description: html.UnescapeString(video.Description),
+			Enclosure: &rssEnclosure{
+				URL:    s.opts.PublicURL + "/stream/" + video.WrapperLink.WrapperToken,
+				Length: video.FileSizeBytes,
+				Type:   ContainerMIMEType(video.Container),
+			},
+		}
+		if video.Category != nil {
+			item.Categories = append(item.Categories, html.UnescapeString(video.Category.Name))
+		}
+		for _, tag := range video.Tags {
+			item.Categories = append(item.Categories, html.UnescapeString(tag))
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Link       atomLink       `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func (s *SyndicationService) atomFeed(source *feedSource, selfURL string, videos []models.Video) atomFeed {
+	feed := atomFeed{
+		ID:    selfURL,
+		Title: source.title,
+		Links: []atomLink{
+			{Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: s.opts.PublicURL + "/", Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	// An empty feed was last updated when it was rendered
+	updated := time.Now()
+	if len(videos) > 0 {
+		updated = time.Time{}
+	}
+
+	for i := range videos {
+		video := &videos[i]
+		if video.UpdatedAt.After(updated) {
+			updated = video.UpdatedAt
+		}
+		if video.WrapperLink == nil {
+			continue
+		}
+		entry := atomEntry{
+			ID:        videoURN(video.ID),
+			Title:     html.UnescapeString(video.Title),
+			Updated:   video.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: videoPublished(video).UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: s.WatchURL(video), Rel: "alternate", Type: "text/html"},
+			Summary:   html.UnescapeString(video.Description),
+		}
+		for _, tag := range video.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: html.UnescapeString(tag)})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return feed
+}
+
+// videoURN identifies a video in feeds independently of its (renamable) watch URL
+func videoURN(id uuid.UUID) string {
+	return "urn:uuid:" + id.String()
+}
+
+// videoPublished falls back to the creation time for videos published without a date
+func videoPublished(video *models.Video) time.Time {
+	if video.PublishedAt != nil {
+		return *video.PublishedAt
+	}
+	return video.CreatedAt
+}
+
+// ContainerMIMEType gets the MIME type of a probed container, MP4 when unknown
+func ContainerMIMEType(container string) string {
+	switch container {
+	case "mov":
+		return "video/quicktime"
+	case "webm":
+		return "video/webm"
+	case "matroska":
+		return "video/x-matroska"
+	}
+	return "video/mp4"
+}
+
+func marshalXML(document interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// ✅ CACHED: cached renders a document once per cache TTL
+func (s *SyndicationService) cached(key string, render func() ([]byte, error)) ([]byte, error) {
+	ctx := context.Background()
+
+	var document string
+	if err := cache.Get(ctx, key, &document); err == nil {
+		return []byte(document), nil
+	}
+
+	body, err := render()
+	if err != nil {
+		return nil, err
+	}
+	cache.Set(ctx, key, string(body), s.opts.CacheTTL)
+	return body, nil
+}
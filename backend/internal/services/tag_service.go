@@ -0,0 +1,227 @@
+package services
+
+import (
+	"bobastream/internal/cache"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"bobastream/internal/utils"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagSlugTaken     = errors.New("slug already belongs to another tag")
+	ErrTagAliasExists   = errors.New("slug already resolves to this tag")
+	ErrTagMergeSelf     = errors.New("a tag cannot be merged into itself")
+	ErrTagAliasNotFound = errors.New("alias not found")
+	ErrTagNameEmpty     = errors.New("tag name is empty")
+)
+
+// popularTagsCacheKey caches the top maxPopularTags tags; requests take a prefix
+const (
+	popularTagsCacheKey = "tags:popular"
+	maxPopularTags      = 100
+)
+
+// TagSlug derives the slug identifying a tag name (stored HTML-escaped, as
+// sanitized). Names without letters or digits get a hash-based slug.
+// Migration 024 computes the same slugs in SQL.
+func TagSlug(name string) string {
+	name = strings.TrimSpace(name)
+	if slug := utils.Slugify(html.UnescapeString(name), 100); slug != "" {
+		return slug
+	}
+	sum := md5.Sum([]byte(name))
+	return "tag-" + hex.EncodeToString(sum[:])[:8]
+}
+
+// tagCandidates turns sanitized tag names into tags to resolve or create
+func tagCandidates(names []string) []models.Tag {
+	candidates := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			candidates = append(candidates, models.Tag{Name: name, Slug: TagSlug(name)})
+		}
+	}
+	return candidates
+}
+
+// TagService manages canonical tags, their aliases and tag pages
+type TagService struct {
+	tagRepo *repositories.TagRepository
+}
+
+func NewTagService(tagRepo *repositories.TagRepository) *TagService {
+	return &TagService{tagRepo: tagRepo}
+}
+
+// GetTagBySlug gets the tag owning a slug; aliases resolve to their tag
+func (s *TagService) GetTagBySlug(slug string) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTagNotFound
+	}
+	return tag, err
+}
+
+// GetTagByID gets a tag with its aliases
+func (s *TagService) GetTagByID(id uuid.UUID) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTagNotFound
+	}
+	return tag, err
+}
+
+// CountVideos counts the published videos of a tag
+func (s *TagService) CountVideos(id uuid.UUID) (int64, error) {
+	return s.tagRepo.CountVideos(id)
+}
+
+// ✅ CACHED: GetPopularTags gets the tags with the most published videos
+func (s *TagService) GetPopularTags(limit int) ([]repositories.TagCount, error) {
+	ctx := context.Background()
+
+	var tags []repositories.TagCount
+	if err := cache.Get(ctx, popularTagsCacheKey, &tags); err != nil {
+		tags, err = s.tagRepo.GetPopular(maxPopularTags)
+		if err != nil {
+			return nil, err
+		}
+		cache.Set(ctx, popularTagsCacheKey, tags, 5*time.Minute)
+	}
+
+	if limit < len(tags) {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+// GetAllTags lists tags with aliases and video counts (admin)
+func (s *TagService) GetAllTags(search string, page, limit int) ([]repositories.TagCount, int64, error) {
+	return s.tagRepo.GetAll(strings.ToLower(strings.TrimSpace(search)), page, limit)
+}
+
+// RenameTag changes a tag's canonical spelling; its old slug keeps working as an alias (admin)
+func (s *TagService) RenameTag(id uuid.UUID, name string) (*models.Tag, error) {
+	name = utils.TruncateString(utils.SanitizeString(name), 50)
+	if name == "" {
+		return nil, ErrTagNameEmpty
+	}
+
+	tag, err := s.GetTagByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := TagSlug(name)
+	if err := s.checkSlugFree(slug, id, true); err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.Rename(id, name, slug); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	return s.GetTagByID(tag.ID)
+}
+
+// MergeTags moves all videos and aliases of a tag into another and deletes it (admin)
+func (s *TagService) MergeTags(sourceID, targetID uuid.UUID) (*models.Tag, error) {
+	if sourceID == targetID {
+		return nil, ErrTagMergeSelf
+	}
+	if _, err := s.GetTagByID(sourceID); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetTagByID(targetID); err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.Merge(sourceID, targetID); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	return s.GetTagByID(targetID)
+}
+
+// AddAlias makes another spelling resolve to the tag, e.g. "komedi" to "comedy" (admin)
+func (s *TagService) AddAlias(id uuid.UUID, alias string) (*models.Tag, error) {
+	if _, err := s.GetTagByID(id); err != nil {
+		return nil, err
+	}
+
+	slug := TagSlug(utils.SanitizeString(alias))
+	if err := s.checkSlugFree(slug, id, false); err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.AddAlias(id, slug); err != nil {
+		return nil, err
+	}
+	return s.GetTagByID(id)
+}
+
+// DeleteAlias removes an alias from the tag (admin)
+func (s *TagService) DeleteAlias(id uuid.UUID, slug string) (*models.Tag, error) {
+	deleted, err := s.tagRepo.DeleteAlias(id, slug)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrTagAliasNotFound
+	}
+	return s.GetTagByID(id)
+}
+
+// DeleteTag removes a tag from every video and deletes it (admin)
+func (s *TagService) DeleteTag(id uuid.UUID) error {
+	err := s.tagRepo.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTagNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// checkSlugFree fails when the slug resolves to another tag. For a rename the
+// tag's own aliases are free; adding an alias it already resolves to is an error.
+func (s *TagService) checkSlugFree(slug string, id uuid.UUID, renaming bool) error {
+	owner, err := s.tagRepo.SlugOwner(slug)
+	if err != nil {
+		return err
+	}
+	switch {
+	case owner == nil:
+		return nil
+	case *owner != id:
+		return ErrTagSlugTaken
+	case !renaming:
+		return ErrTagAliasExists
+	}
+	return nil
+}
+
+// invalidate drops cached tag lists after tags changed. Renames, merges and
+// deletes also rewrite the tags of videos, so cached videos go too.
+func (s *TagService) invalidate() {
+	ctx := context.Background()
+	cache.Delete(ctx, popularTagsCacheKey)
+	cache.DeletePattern(ctx, "feed:*")
+	cache.DeletePattern(ctx, relatedCachePattern)
+	invalidateSyndication()
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bobastream/internal/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// VideoUploadPayload is the job payload of an admin upload. The file waits in
+// the spool directory until the job finishes.
+type VideoUploadPayload struct {
+	FilePath        string     `json:"file_path"`
+	Filename        string     `json:"filename"`
+	Size            int64      `json:"size"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	ThumbnailURL    string     `json:"thumbnail_url"`
+	CategoryID      *uuid.UUID `json:"category_id,omitempty"`
+	Tags            []string   `json:"tags"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Force           bool       `json:"force"` // Upload even if duplicate
+}
+
+// VideoUploadResult is the job result of a finished upload
+type VideoUploadResult struct {
+	VideoID       uuid.UUID `json:"video_id"`
+	WrapperToken  string    `json:"wrapper_token"`
+	PCloudAccount string    `json:"pcloud_account"`
+}
+
+// UploadService runs admin uploads as background jobs, so a slow pCloud
+// upload neither holds the admin's request open nor dies with it
+type UploadService struct {
+	jobQueue         *JobQueue
+	videoService     *VideoService
+	pcloudService    *PCloudService
+	faststartService *FaststartService
+	spoolDir         string
+}
+
+func NewUploadService(
+	jobQueue *JobQueue,
+	videoService *VideoService,
+	pcloudService *PCloudService,
+	faststartService *FaststartService,
+	spoolDir string,
+) *UploadService {
+	if err := os.MkdirAll(spoolDir, 0o750); err != nil {
+		fmt.Printf("⚠️  WARNING: Failed to create upload spool directory %s: %v\n", spoolDir, err)
+	}
+
+	s := &UploadService{
+		jobQueue:         jobQueue,
+		videoService:     videoService,
+		pcloudService:    pcloudService,
+		faststartService: faststartService,
+		spoolDir:         spoolDir,
+	}
+
+	jobQueue.Register(models.JobTypeVideoUpload, JobHandler{
+		Run:     s.run,
+		Cleanup: s.cleanup,
+		// Cancelling deletes the spooled file
+		NoRetryAfterCancel: true,
+	})
+	return s
+}
+
+// Enqueue copies the received file to the spool directory and queues its upload
+func (s *UploadService) Enqueue(file *multipart.FileHeader, payload VideoUploadPayload, createdBy *uuid.UUID) (*models.Job, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	spooled, err := os.CreateTemp(s.spoolDir, "upload-*"+filepath.Ext(file.Filename))
+	if err != nil {
+		return nil, err
+	}
+	defer spooled.Close()
+
+	if _, err := io.Copy(spooled, src); err != nil {
+		os.Remove(spooled.Name())
+		return nil, err
+	}
+	if err := spooled.Sync(); err != nil {
+		os.Remove(spooled.Name())
+		return nil, err
+	}
+
+	payload.FilePath = spooled.Name()
+	payload.Filename = file.Filename
+	payload.Size = file.Size
+
+	job, err := s.jobQueue.Enqueue(models.JobTypeVideoUpload, payload, createdBy)
+	if err != nil {
+		os.Remove(spooled.Name())
+		return nil, err
+	}
+	return job, nil
+}
+
+// run uploads a spooled file to pCloud and creates the video
+func (s *UploadService) run(ctx context.Context, job *models.Job) (interface{}, error) {
+	var payload VideoUploadPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, PermanentError(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	file, err := os.Open(payload.FilePath)
+	if err != nil {
+		return nil, PermanentError(errors.New("uploaded file is no longer available; upload it again"))
+	}
+	defer file.Close()
+
+	mediaInfo, err := s.videoService.ProbeMedia(file, payload.Size)
+	if err != nil {
+		return nil, PermanentError(err)
+	}
+
+	// Duplicates are recognised by the file as received, not as stored:
+	// a rewritten copy hashes differently
+	contentSHA256, err := hashFile(file, payload.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash uploaded file: %w", err)
+	}
+
+	// ✅ MOVE MP4 INDEX (MOOV) TO THE FRONT SO PLAYBACK STARTS WITHOUT A TAIL FETCH
+	prepared := s.faststartService.Prepare(file, payload.Size, mediaInfo)
+	defer prepared.Close()
+
+	body := file
+	if prepared.Rewritten() {
+		body = prepared.File
+	}
+
+	// Upload to pCloud (account chosen by the selection strategy)
+	upload, err := s.pcloudService.UploadFile(ctx, body, payload.Filename, payload.Size, UploadPlacement{
+		CategoryID: payload.CategoryID,
+		Tags:       payload.Tags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to pCloud: %w", err)
+	}
+
+	// Anything after a successful upload must clean the pCloud file up on failure
+	videoCreated := false
+	defer func() {
+		if !videoCreated {
+			if err := s.pcloudService.DiscardUpload(context.Background(), upload); err != nil {
+				fmt.Printf("⚠️  WARNING: Failed to discard pCloud file %d: %v\n", upload.FileID, err)
+			}
+		}
+	}()
+
+	// ✅ POST-UPLOAD DUPLICATE CHECK (server-side SHA-256 and pCloud hash)
+	if !payload.Force {
+		existing, err := s.videoService.FindDuplicate(contentSHA256, upload.Hash, upload.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for duplicates: %w", err)
+		}
+		if existing != nil {
+			return nil, PermanentError(fmt.Errorf("duplicate of video %s (%s); upload again with force to upload anyway", existing.ID, existing.Title))
+		}
+	}
+
+	// Get streaming link from pCloud
+	sourceURL, expiresAt, err := s.pcloudService.GetFileLink(ctx, upload.Credential, upload.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pCloud link: %w", err)
+	}
+
+	// Create video record and wrapper link
+	video, wrapperLink, err := s.videoService.CreateUploadedVideo(VideoMetadata{
+		Title:           payload.Title,
+		Description:     payload.Description,
+		ThumbnailURL:    payload.ThumbnailURL,
+		CategoryID:      payload.CategoryID,
+		Tags:            payload.Tags,
+		DurationSeconds: payload.DurationSeconds,
+		Media:           mediaInfo,
+		ContentSHA256:   contentSHA256,
+
+		StreamingOptimized: prepared.StreamingOptimized,
+	}, upload, sourceURL, expiresAt)
+	if video != nil {
+		videoCreated = true
+	}
+	if err != nil {
+		if video == nil {
+			return nil, fmt.Errorf("failed to save video: %w", err)
+		}
+		// Retrying would upload the file a second time
+		return nil, PermanentError(fmt.Errorf("video %s saved but its wrapper link failed: %w", video.ID, err))
+	}
+
+	return VideoUploadResult{
+		VideoID:       video.ID,
+		WrapperToken:  wrapperLink.WrapperToken,
+		PCloudAccount: upload.Credential.AccountName,
+	}, nil
+}
+
+// hashFile computes the hex SHA-256 of the first size bytes of a file
+func hashFile(file io.ReaderAt, size int64) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, io.NewSectionReader(file, 0, size)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// cleanup removes the spooled file once the job will not run again
+func (s *UploadService) cleanup(job *models.Job) {
+	var payload VideoUploadPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil || payload.FilePath == "" {
+		return
+	}
+	if filepath.Dir(filepath.Clean(payload.FilePath)) != filepath.Clean(s.spoolDir) {
+		return // Never delete outside the spool directory
+	}
+
+	if err := os.Remove(payload.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("⚠️  WARNING: Failed to remove spooled upload %s: %v\n", payload.FilePath, err)
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bobastream/internal/media"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UploadErrorCode identifies why an upload was rejected, for clients to act on
+type UploadErrorCode string
+
+const (
+	UploadErrEmptyFile           UploadErrorCode = "empty_file"
+	UploadErrFileTooLarge        UploadErrorCode = "file_too_large"
+	UploadErrExtensionNotAllowed UploadErrorCode = "extension_not_allowed"
+	UploadErrDisguisedFile       UploadErrorCode = "disguised_file"      // Executable, archive, image or document
+	UploadErrUnrecognizedFormat  UploadErrorCode = "unrecognized_format" // No known video signature
+	UploadErrTypeNotAllowed      UploadErrorCode = "type_not_allowed"    // Video, but not an allowed MIME type
+	UploadErrInvalidMedia        UploadErrorCode = "invalid_media"       // Headers could not be parsed
+	UploadErrNoVideoTrack        UploadErrorCode = "no_video_track"
+	UploadErrDurationTooLong     UploadErrorCode = "duration_too_long"
+)
+
+// UploadValidationError is a rejected upload with a machine-readable code
+type UploadValidationError struct {
+	Code     UploadErrorCode `json:"code"`
+	Message  string          `json:"message"`
+	Detected string          `json:"detected,omitempty"` // Sniffed MIME type, if any
+}
+
+func (e *UploadValidationError) Error() string {
+	return e.Message
+}
+
+// UploadPolicy limits what may be uploaded
+type UploadPolicy struct {
+	AllowedMIMETypes []string      // e.g. video/mp4, video/webm
+	MaxSizeBytes     int64         // 0 means no limit
+	MaxDuration      time.Duration // 0 means no limit
+}
+
+// mimeExtensions lists the file extensions accepted for each video MIME type
+var mimeExtensions = map[string][]string{
+	"video/mp4":        {".mp4", ".m4v"},
+	"video/quicktime":  {".mov", ".qt"},
+	"video/webm":       {".webm"},
+	"video/x-matroska": {".mkv"},
+	"video/x-msvideo":  {".avi"},
+	"video/x-flv":      {".flv"},
+	"video/x-ms-asf":   {".wmv", ".asf"},
+	"video/mpeg":       {".mpg", ".mpeg"},
+	"video/mp2t":       {".ts", ".m2ts", ".mts"},
+	"video/3gpp":       {".3gp"},
+	"video/3gpp2":      {".3g2"},
+}
+
+// probedTypes are the MIME types media.Probe parses; for these a parse failure rejects the file
+var probedTypes = map[string]bool{
+	"video/mp4":        true,
+	"video/quicktime":  true,
+	"video/3gpp":       true,
+	"video/3gpp2":      true,
+	"video/webm":       true,
+	"video/x-matroska": true,
+}
+
+// UploadValidator checks uploads against the upload policy before anything
+// is sent to pCloud
+type UploadValidator struct {
+	policy     UploadPolicy
+	allowed    map[string]bool
+	extensions map[string]bool
+}
+
+func NewUploadValidator(policy UploadPolicy) *UploadValidator {
+	v := &UploadValidator{
+		policy:     policy,
+		allowed:    make(map[string]bool),
+		extensions: make(map[string]bool),
+	}
+	for _, mimeType := range policy.AllowedMIMETypes {
+		mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+		if mimeType == "" {
+			continue
+		}
+		v.allowed[mimeType] = true
+		for _, ext := range mimeExtensions[mimeType] {
+			v.extensions[ext] = true
+		}
+	}
+	return v
+}
+
+// CheckFile validates name, size and leading bytes without parsing the container.
+// Streaming imports call it with just the first media.SniffLen bytes.
+func (v *UploadValidator) CheckFile(filename string, size int64, header []byte) error {
+	if size == 0 {
+		return &UploadValidationError{Code: UploadErrEmptyFile, Message: "File is empty"}
+	}
+	if v.policy.MaxSizeBytes > 0 && size > v.policy.MaxSizeBytes {
+		return &UploadValidationError{
+			Code:    UploadErrFileTooLarge,
+			Message: fmt.Sprintf("File size (%d MB) exceeds the upload limit (%d MB)", size/(1024*1024), v.policy.MaxSizeBytes/(1024*1024)),
+		}
+	}
+
+	if filename != "" {
+		ext := strings.ToLower(filepath.Ext(filename))
+		if !v.extensions[ext] {
+			return &UploadValidationError{
+				Code:    UploadErrExtensionNotAllowed,
+				Message: fmt.Sprintf("File extension %q is not allowed", ext),
+			}
+		}
+	}
+
+	sig := media.SniffBytes(header)
+	switch sig.Kind {
+	case media.KindVideo:
+		if !v.allowed[sig.MIME] {
+			return &UploadValidationError{
+				Code:     UploadErrTypeNotAllowed,
+				Message:  fmt.Sprintf("Video type %s is not allowed", sig.MIME),
+				Detected: sig.MIME,
+			}
+		}
+		return nil
+	case media.KindUnknown:
+		return &UploadValidationError{
+			Code:    UploadErrUnrecognizedFormat,
+			Message: "File is not a recognized video format",
+		}
+	default:
+		return &UploadValidationError{
+			Code:     UploadErrDisguisedFile,
+			Message:  fmt.Sprintf("File content is %s (%s), not a video", sig.Kind, sig.MIME),
+			Detected: sig.MIME,
+		}
+	}
+}
+
+// CheckMedia validates probed metadata: a playable video track and the duration limit.
+// A nil info (container the prober cannot parse) passes.
+func (v *UploadValidator) CheckMedia(info *media.Info) error {
+	if info == nil {
+		return nil
+	}
+	if err := info.Validate(); err != nil {
+		return &UploadValidationError{Code: UploadErrNoVideoTrack, Message: "File has no playable video track"}
+	}
+	if v.policy.MaxDuration > 0 && info.Duration > v.policy.MaxDuration {
+		return &UploadValidationError{
+			Code: UploadErrDurationTooLong,
+			Message: fmt.Sprintf("Video duration (%s) exceeds the limit (%s)",
+				info.Duration.Round(time.Second), v.policy.MaxDuration),
+		}
+	}
+	return nil
+}
+
+// Validate runs every check on a complete file and returns its probed metadata
+func (v *UploadValidator) Validate(r io.ReaderAt, filename string, size int64) (*media.Info, error) {
+	header := make([]byte, min(size, media.SniffLen))
+	if len(header) > 0 {
+		if _, err := r.ReadAt(header, 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+	if err := v.CheckFile(filename, size, header); err != nil {
+		return nil, err
+	}
+
+	info, err := media.Probe(r, size)
+	if errors.Is(err, media.ErrUnsupportedFormat) && !probedTypes[media.SniffBytes(header).MIME] {
+		return nil, nil // e.g. AVI: accepted without metadata
+	}
+	if err != nil {
+		return nil, &UploadValidationError{
+			Code:    UploadErrInvalidMedia,
+			Message: "Invalid video file: " + err.Error(),
+		}
+	}
+	if err := v.CheckMedia(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import "bobastream/internal/repositories"
+
+// VideoFilter narrows the feed, category and search listings
+type VideoFilter = repositories.VideoFilter
+
+// VideoSort orders the feed, category and search listings
+type VideoSort = repositories.VideoSort
+
+// SortDefault keeps a listing's own order
+const SortDefault = repositories.SortDefault
+
+// ValidVideoSort reports whether sort is a known sort option
+func ValidVideoSort(sort string) bool {
+	for _, known := range repositories.VideoSorts {
+		if VideoSort(sort) == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidDurationBucket reports whether name is a known duration bucket
+func ValidDurationBucket(name string) bool {
+	_, ok := repositories.FindDurationBucket(name)
+	return ok
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bobastream/internal/cache"
+	"bobastream/internal/models"
+	"bobastream/internal/repositories"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVideoNotFound          = errors.New("video not found")
+	ErrVideoVersionConflict   = repositories.ErrVideoVersionConflict
+	ErrVideoRevisionNotFound  = errors.New("revision not found")
+	ErrVideoRevisionIsCurrent = errors.New("video is already at this version")
+)
+
+// EditableMetadata is the metadata editors change on a video; every change is
+// recorded as a revision
+type EditableMetadata struct {
+	Title        string
+	Description  string
+	ThumbnailURL string
+	CategoryID   *uuid.UUID
+	Tags         []string
+	IsPublished  bool
+}
+
+// metadataField is a metadata field by its JSON name, pointing into an EditableMetadata
+type metadataField struct {
+	name  string
+	value interface{}
+}
+
+func metadataOf(video *models.Video) EditableMetadata {
+	return EditableMetadata{
+		Title:        video.Title,
+		Description:  video.Description,
+		ThumbnailURL: video.ThumbnailURL,
+		CategoryID:   video.CategoryID,
+		Tags:         append([]string{}, video.Tags...),
+		IsPublished:  video.IsPublished,
+	}
+}
+
+func (m *EditableMetadata) fields() []metadataField {
+	return []metadataField{
+		{"title", &m.Title},
+		{"description", &m.Description},
+		{"thumbnail_url", &m.ThumbnailURL},
+		{"category_id", &m.CategoryID},
+		{"tags", &m.Tags},
+		{"is_published", &m.IsPublished},
+	}
+}
+
+// applyTo copies the metadata to a video; a first publication is dated now
+func (m EditableMetadata) applyTo(video *models.Video) {
+	video.Title = m.Title
+	video.Description = m.Description
+	video.ThumbnailURL = m.ThumbnailURL
+	video.CategoryID = m.CategoryID
+	video.Tags = m.Tags
+	video.IsPublished = m.IsPublished
+	if m.IsPublished && video.PublishedAt == nil {
+		now := time.Now()
+		video.PublishedAt = &now
+	}
+}
+
+// undo restores the values a revision changed to what they were before it
+func (m *EditableMetadata) undo(revision models.VideoRevision) error {
+	var changes []models.VideoFieldChange
+	if err := json.Unmarshal(revision.Changes, &changes); err != nil {
+		return err
+	}
+
+	fields := m.fields()
+	for _, change := range changes {
+		for _, field := range fields {
+			if field.name == change.Field {
+				if err := json.Unmarshal(change.Old, field.value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if m.Tags == nil {
+		m.Tags = []string{}
+	}
+	return nil
+}
+
+// diffMetadata lists the fields that differ between two versions of the metadata
+func diffMetadata(before, after EditableMetadata) ([]models.VideoFieldChange, error) {
+	oldFields, newFields := before.fields(), after.fields()
+
+	var changes []models.VideoFieldChange
+	for i := range oldFields {
+		oldValue, err := json.Marshal(oldFields[i].value)
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := json.Marshal(newFields[i].value)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(oldValue, newValue) {
+			changes = append(changes, models.VideoFieldChange{Field: oldFields[i].name, Old: oldValue, New: newValue})
+		}
+	}
+	return changes, nil
+}
+
+// VideoRevisionService records metadata edits of videos as revisions and
+// reverts videos to earlier versions
+type VideoRevisionService struct {
+	videoRepo    *repositories.VideoRepository
+	revisionRepo *repositories.VideoRevisionRepository
+	tagRepo      *repositories.TagRepository
+	videoService *VideoService
+}
+
+func NewVideoRevisionService(
+	videoRepo *repositories.VideoRepository,
+	revisionRepo *repositories.VideoRevisionRepository,
+	tagRepo *repositories.TagRepository,
+	videoService *VideoService,
+) *VideoRevisionService {
+	return &VideoRevisionService{
+		videoRepo:    videoRepo,
+		revisionRepo: revisionRepo,
+		tagRepo:      tagRepo,
+		videoService: videoService,
+	}
+}
+
+// EditVideo saves the edited metadata of a video loaded at version, recording
+// the changed fields as a revision by editorID. Returns ErrVideoVersionConflict
+// when the video was changed since; an edit changing nothing is not recorded.
+func (s *VideoRevisionService) EditVideo(video *models.Video, version int, editorID *uuid.UUID) error {
+	before, err := s.findVideo(video.ID)
+	if err != nil {
+		return err
+	}
+	if before.Version != version {
+		return ErrVideoVersionConflict
+	}
+
+	after := metadataOf(video)
+	if after.Tags, err = s.canonicalTagNames(after.Tags); err != nil {
+		return err
+	}
+	return s.save(video, before, after, version, editorID, nil)
+}
+
+// RevertVideo restores the metadata a video had at an earlier version by
+// undoing every later revision. The revert is itself recorded as a revision,
+// so it can be reverted too.
+func (s *VideoRevisionService) RevertVideo(id uuid.UUID, toVersion, version int, editorID *uuid.UUID) (*models.Video, error) {
+	video, err := s.findVideo(id)
+	if err != nil {
+		return nil, err
+	}
+	if video.Version != version {
+		return nil, ErrVideoVersionConflict
+	}
+	if toVersion == video.Version {
+		return nil, ErrVideoRevisionIsCurrent
+	}
+	if toVersion < 1 || toVersion > video.Version {
+		return nil, ErrVideoRevisionNotFound
+	}
+
+	revisions, err := s.revisionRepo.GetAfter(id, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	target := metadataOf(video)
+	for _, revision := range revisions {
+		if err := target.undo(revision); err != nil {
+			return nil, err
+		}
+	}
+	// Tags renamed or merged since resolve to their current names
+	if target.Tags, err = s.canonicalTagNames(target.Tags); err != nil {
+		return nil, err
+	}
+
+	before := *video
+	if err := s.save(video, &before, target, version, editorID, &toVersion); err != nil {
+		return nil, err
+	}
+	return video, nil
+}
+
+// GetRevisions gets the revisions of a video, newest first
+func (s *VideoRevisionService) GetRevisions(videoID uuid.UUID, page, limit int) ([]models.VideoRevision, int64, error) {
+	if _, err := s.findVideo(videoID); err != nil {
+		return nil, 0, err
+	}
+	return s.revisionRepo.GetByVideo(videoID, page, limit)
+}
+
+// save writes the metadata to the video with a revision of the fields that
+// differ from before
+func (s *VideoRevisionService) save(video, before *models.Video, after EditableMetadata, version int, editorID *uuid.UUID, revertedTo *int) error {
+	changes, err := diffMetadata(metadataOf(before), after)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		video.Version = before.Version
+		return nil
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	after.applyTo(video)
+	revision := &models.VideoRevision{
+		EditorID:   editorID,
+		Changes:    encoded,
+		RevertedTo: revertedTo,
+	}
+	if err := s.revisionRepo.SaveEdit(video, version, revision); err != nil {
+		return err
+	}
+
+	// ✅ Invalidate caches once the slug and tags are synced too, so no
+	// request re-caches the video in between
+	defer func() {
+		cache.DeletePattern(context.Background(), "feed:*")
+		invalidateSyndication()
+	}()
+
+	if err := s.videoService.RefreshWatchSlug(video); err != nil {
+		return err
+	}
+	return s.videoService.syncTags(video)
+}
+
+// canonicalTagNames gives the names syncTags will store for tag names:
+// existing tags (also by alias) keep their spelling, duplicates are dropped
+func (s *VideoRevisionService) canonicalTagNames(names []string) ([]string, error) {
+	canonical := []string{}
+	seen := make(map[string]bool)
+	for _, candidate := range tagCandidates(names) {
+		tag, err := s.tagRepo.FindBySlug(candidate.Slug)
+		if err == nil {
+			candidate = *tag
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		if !seen[candidate.Slug] {
+			seen[candidate.Slug] = true
+			canonical = append(canonical, candidate.Name)
+		}
+	}
+	return canonical, nil
+}
+
+func (s *VideoRevisionService) findVideo(id uuid.UUID) (*models.Video, error) {
+	video, err := s.videoRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVideoNotFound
+	}
+	return video, err
+}
@@ -2,13 +2,18 @@ package services
 
 import (
 	"bobastream/internal/cache"
+	"bobastream/internal/media"
 	"bobastream/internal/models"
 	"bobastream/internal/repositories"
 	"bobastream/internal/utils"
 	"context"
 	"errors"
 	"fmt"
+	"html"
+	"io"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +25,7 @@ type VideoService struct {
 	wrapperRepo   *repositories.WrapperLinkRepository
 	videoViewRepo *repositories.VideoViewRepository
 	videoLikeRepo *repositories.VideoLikeRepository
+	tagRepo       *repositories.TagRepository
 }
 
 func NewVideoService(
@@ -27,12 +33,14 @@ func NewVideoService(
 	wrapperRepo *repositories.WrapperLinkRepository,
 	videoViewRepo *repositories.VideoViewRepository,
 	videoLikeRepo *repositories.VideoLikeRepository,
+	tagRepo *repositories.TagRepository,
 ) *VideoService {
 	return &VideoService{
 		videoRepo:     videoRepo,
 		wrapperRepo:   wrapperRepo,
 		videoViewRepo: videoViewRepo,
 		videoLikeRepo: videoLikeRepo,
+		tagRepo:       tagRepo,
 	}
 }
 
@@ -102,39 +110,26 @@ func (s *VideoService) GetVideoByWrapperToken(token string) (*models.Video, erro
 	return s.videoRepo.FindByWrapperToken(token)
 }
 
-// SearchVideos searches videos with optional category filter
-func (s *VideoService) SearchVideos(keyword string, categoryID *uuid.UUID, page, limit int) ([]models.Video, int64, error) {
-	// If category filter is set, search within category
-	if categoryID != nil {
-		return s.videoRepo.SearchVideosByCategory(keyword, *categoryID, page, limit)
+// FindDuplicate finds an existing video with the same content. Returns nil when none exists.
+func (s *VideoService) FindDuplicate(sha256Hex, pcloudHash string, size int64) (*models.Video, error) {
+	video, err := s.videoRepo.FindByContentFingerprint(sha256Hex, pcloudHash, size)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
 	}
-	return s.videoRepo.SearchVideos(keyword, page, limit)
+	return video, err
 }
 
-// GetVideosByCategory gets videos by category
-func (s *VideoService) GetVideosByCategory(categoryID uuid.UUID, page, limit int) ([]models.Video, int64, error) {
-	return s.videoRepo.GetVideosByCategory(categoryID, page, limit)
+// ListVideos gets published videos narrowed by a filter and sort, for feeds
+// that do not use the default scored order
+func (s *VideoService) ListVideos(filter VideoFilter, sort VideoSort, page, limit int) ([]models.Video, int64, error) {
+	return s.videoRepo.ListVideos(filter, sort, page, limit)
 }
 
-// GetRelatedVideos gets related videos based on current video
-func (s *VideoService) GetRelatedVideos(videoID uuid.UUID, limit int) ([]models.Video, error) {
-	// Get current video
-	video, err := s.videoRepo.FindByID(videoID)
-	if err != nil {
-		return nil, err
-	}
-
-	categoryID := ""
-	if video.CategoryID != nil {
-		categoryID = video.CategoryID.String()
-	}
-
-	tags := []string{}
-	if video.Tags != nil {
-		tags = video.Tags
-	}
-
-	return s.videoRepo.GetRelatedVideos(videoID, categoryID, tags, limit)
+// GetVideosByCategory gets videos in any of the categories (a category, or a
+// category and its descendants), newest first unless sorted otherwise
+func (s *VideoService) GetVideosByCategory(categoryIDs []uuid.UUID, filter VideoFilter, sort VideoSort, page, limit int) ([]models.Video, int64, error) {
+	filter.CategoryIDs = categoryIDs
+	return s.videoRepo.ListVideos(filter, sort, page, limit)
 }
 
 // TrackVideoView tracks video view with proper view count increment logic
@@ -197,10 +192,8 @@ func (s *VideoService) TrackVideoView(videoID uuid.UUID, userID *uuid.UUID, sess
 
 	// Increment view count if conditions met
 	if shouldIncrementView {
-		// ✅ Invalidate feed cache when view count changes
-		ctx := context.Background()
-		cache.Delete(ctx, "feed:*") // Delete all feed cache keys
-		
+		// Counters reach the cached feed when it expires; scanning for feed
+		// keys on every view would put a keyspace SCAN on the hottest path
 		return s.videoRepo.IncrementViewCount(videoID)
 	}
 
@@ -228,11 +221,7 @@ func (s *VideoService) LikeVideo(videoID, userID uuid.UUID) error {
 		return err
 	}
 
-	// ✅ Invalidate feed cache
-	ctx := context.Background()
-	cache.Delete(ctx, "feed:*")
-
-	// Increment like count
+	// Increment like count (the cached feed picks it up when it expires)
 	return s.videoRepo.IncrementLikeCount(videoID)
 }
 
@@ -252,11 +241,7 @@ func (s *VideoService) UnlikeVideo(videoID, userID uuid.UUID) error {
 		return err
 	}
 
-	// ✅ Invalidate feed cache
-	ctx := context.Background()
-	cache.Delete(ctx, "feed:*")
-
-	// Decrement like count
+	// Decrement like count (the cached feed picks it up when it expires)
 	return s.videoRepo.DecrementLikeCount(videoID)
 }
 
@@ -275,38 +260,292 @@ func (s *VideoService) GetTopVideos(limit, days int) ([]models.Video, error) {
 	return s.videoRepo.GetTopVideos(limit, days)
 }
 
-// CreateVideo creates a new video (admin)
+// CreateVideo creates a new video (admin). Sitemaps and feeds only list videos
+// with a wrapper link, so their caches are dropped once it exists.
 func (s *VideoService) CreateVideo(video *models.Video) error {
 	// ✅ Invalidate feed cache
 	ctx := context.Background()
-	cache.Delete(ctx, "feed:*")
+	cache.DeletePattern(ctx, "feed:*")
 	
-	return s.videoRepo.Create(video)
+	tags, err := s.videoRepo.CreateWithTags(video, tagCandidates(video.Tags))
+	if err != nil {
+		return err
+	}
+	applyTags(video, tags)
+	return nil
+}
+
+// VideoMetadata is the admin-supplied metadata for a newly stored video
+type VideoMetadata struct {
+	Title           string
+	Description     string
+	ThumbnailURL    string
+	CategoryID      *uuid.UUID
+	Tags            []string
+	DurationSeconds int         // Manual value, used when probing found no duration
+	Media           *media.Info // Probed container metadata, if available
+	ContentSHA256   string      // SHA-256 of the file as received, if the stored file differs
+	Draft           bool        // Create the video unpublished
+
+	StreamingOptimized *bool // Whether the stored file has its index first; nil if unknown
+}
+
+// CreateUploadedVideo creates the video record and its wrapper link for a file
+// already stored on pCloud (admin upload and remote import)
+func (s *VideoService) CreateUploadedVideo(meta VideoMetadata, upload *UploadResult, sourceURL string, expiresAt time.Time) (*models.Video, *models.WrapperLink, error) {
+	video := &models.Video{
+		Title:              meta.Title,
+		Description:        meta.Description,
+		ThumbnailURL:       meta.ThumbnailURL,
+		SourceURL:          sourceURL,
+		SourceURLExpiresAt: &expiresAt,
+		DurationSeconds:    meta.DurationSeconds,
+		FileSizeMB:         float64(upload.Size) / (1024 * 1024),
+		FileSizeBytes:      upload.Size,
+		ContentHash:        upload.Hash,
+		ContentSHA256:      upload.SHA256,
+		PCloudFileID:       fmt.Sprintf("%d", upload.FileID),
+		PCloudCredentialID: upload.Credential.ID,
+		CategoryID:         meta.CategoryID,
+		Tags:               meta.Tags,
+		IsPublished:        !meta.Draft,
+	}
+	applyMediaInfo(video, meta.Media)
+	video.StreamingOptimized = meta.StreamingOptimized
+	if meta.ContentSHA256 != "" {
+		video.ContentSHA256 = meta.ContentSHA256
+	}
+
+	if err := s.CreateVideo(video); err != nil {
+		return nil, nil, fmt.Errorf("failed to save video: %w", err)
+	}
+
+	wrapperLink := &models.WrapperLink{VideoID: video.ID}
+	err := s.assignWatchSlug(video.Title, uuid.Nil, func(slug string) error {
+		wrapperLink.Slug = slug
+		return s.CreateWrapperLink(wrapperLink)
+	})
+	if err != nil {
+		return video, nil, fmt.Errorf("failed to create wrapper link: %w", err)
+	}
+
+	// Sitemaps and feeds list the video from now on
+	invalidateSyndication()
+
+	return video, wrapperLink, nil
+}
+
+// ProbeMedia reads container metadata of an uploaded file. Files in containers
+// the prober cannot parse are accepted without metadata (nil info); parsable
+// files without a playable video track are rejected with media.ErrNoVideoTrack.
+func (s *VideoService) ProbeMedia(r io.ReaderAt, size int64) (*media.Info, error) {
+	info, err := media.Probe(r, size)
+	if errors.Is(err, media.ErrUnsupportedFormat) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read video headers: %w", err)
+	}
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// applyMediaInfo copies probed metadata onto a video; a probed duration wins over manual input
+func applyMediaInfo(video *models.Video, info *media.Info) {
+	if info == nil {
+		return
+	}
+	video.Container = info.Container
+	video.Width = info.Width
+	video.Height = info.Height
+	video.VideoCodec = info.VideoCodec
+	video.AudioCodec = info.AudioCodec
+	video.BitrateKbps = info.BitrateKbps()
+	if seconds := info.DurationSeconds(); seconds > 0 {
+		video.DurationSeconds = seconds
+	}
 }
 
 // UpdateVideo updates video (admin)
 func (s *VideoService) UpdateVideo(video *models.Video) error {
 	// ✅ Invalidate feed cache
 	ctx := context.Background()
-	cache.Delete(ctx, "feed:*")
+	cache.DeletePattern(ctx, "feed:*")
+	// Sitemaps and feeds are dropped once the change is written
+	defer invalidateSyndication()
 	
-	return s.videoRepo.Update(video)
+	if err := s.videoRepo.Update(video); err != nil {
+		return err
+	}
+	if err := s.RefreshWatchSlug(video); err != nil {
+		return err
+	}
+	return s.syncTags(video)
 }
 
-// DeleteVideo deletes video (admin)
+// syncTags links the video to tag entities for its tag names, creating missing
+// tags, and replaces video.Tags with the canonical spellings
+func (s *VideoService) syncTags(video *models.Video) error {
+	tags, err := s.tagRepo.SetVideoTags(video.ID, tagCandidates(video.Tags))
+	if err != nil {
+		return fmt.Errorf("failed to save tags: %w", err)
+	}
+	applyTags(video, tags)
+	return nil
+}
+
+// applyTags replaces video.Tags with the canonical spellings of its saved tags
+func applyTags(video *models.Video, tags []models.Tag) {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	video.Tags = names
+
+	cache.Delete(context.Background(), popularTagsCacheKey)
+}
+
+// DeleteVideo moves video to trash; it is purged after the retention window (admin)
 func (s *VideoService) DeleteVideo(id uuid.UUID) error {
 	// ✅ Invalidate feed cache
 	ctx := context.Background()
-	cache.Delete(ctx, "feed:*")
+	cache.DeletePattern(ctx, "feed:*")
+	// Sitemaps and feeds are dropped once the change is written
+	defer invalidateSyndication()
 	
 	return s.videoRepo.Delete(id)
 }
 
+// GetTrashedVideos gets soft-deleted videos still inside the retention window,
+// optionally only those that failed to purge (admin)
+func (s *VideoService) GetTrashedVideos(purgeFailedOnly bool, page, limit int) ([]models.Video, int64, error) {
+	return s.videoRepo.GetTrashedVideos(purgeFailedOnly, page, limit)
+}
+
+// GetTrashedVideoByID gets a soft-deleted video (admin)
+func (s *VideoService) GetTrashedVideoByID(id uuid.UUID) (*models.Video, error) {
+	return s.videoRepo.FindTrashedByID(id)
+}
+
+// RestoreVideo moves a video out of trash (admin)
+func (s *VideoService) RestoreVideo(id uuid.UUID) error {
+	// ✅ Invalidate feed cache
+	ctx := context.Background()
+	cache.DeletePattern(ctx, "feed:*")
+	// Sitemaps and feeds are dropped once the change is written
+	defer invalidateSyndication()
+
+	return s.videoRepo.Restore(id)
+}
+
 // CreateWrapperLink creates wrapper link for video
 func (s *VideoService) CreateWrapperLink(link *models.WrapperLink) error {
 	return s.wrapperRepo.Create(link)
 }
 
+// maxWatchSlugLength leaves room for a collision suffix in the 500 character column
+const maxWatchSlugLength = 200
+
+// WatchSlug derives the watch URL slug of a (sanitized) title. Migration 028
+// computes the same slugs in SQL.
+func WatchSlug(title string) string {
+	if slug := utils.Slugify(html.UnescapeString(title), maxWatchSlugLength); slug != "" {
+		return slug
+	}
+	return "video"
+}
+
+// assignWatchSlug saves a slug derived from the title that no other wrapper
+// link has or had, adding -2, -3, ... on collision. save is retried with the
+// next slug when a concurrent save took the slug first.
+func (s *VideoService) assignWatchSlug(title string, linkID uuid.UUID, save func(slug string) error) error {
+	base := WatchSlug(title)
+
+	slug := base
+	for n := 2; ; n++ {
+		available, err := s.wrapperRepo.SlugAvailable(slug, linkID)
+		if err != nil {
+			return err
+		}
+		if available {
+			err := save(slug)
+			if !errors.Is(err, repositories.ErrSlugTaken) {
+				return err
+			}
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// RefreshWatchSlug gives the wrapper link of a renamed video a slug matching
+// the new title; the previous slug keeps redirecting to it
+func (s *VideoService) RefreshWatchSlug(video *models.Video) error {
+	link, err := s.wrapperRepo.FindByVideoID(video.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	base := WatchSlug(video.Title)
+	if link.Slug == base || hasSlugSuffix(link.Slug, base) {
+		return nil
+	}
+
+	err = s.assignWatchSlug(video.Title, link.ID, func(slug string) error {
+		return s.wrapperRepo.ChangeSlug(link, slug)
+	})
+	if err != nil {
+		return err
+	}
+	if video.WrapperLink != nil {
+		video.WrapperLink.Slug = link.Slug
+	}
+	return nil
+}
+
+// hasSlugSuffix reports whether slug is base with a -N collision suffix
+func hasSlugSuffix(slug, base string) bool {
+	suffix := strings.TrimPrefix(slug, base+"-")
+	if suffix == slug {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+// ResolveWatchPath finds the video of a watch URL, given by its slug or its
+// wrapper token. An old slug of a renamed video returns the current watch
+// path to redirect to instead of "". Slugs only resolve to published videos;
+// unpublished ones stay reachable by token for admin previews.
+func (s *VideoService) ResolveWatchPath(ref string) (*models.Video, string, error) {
+	video, err := s.videoRepo.FindByWrapperToken(ref)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return video, "", err
+	}
+
+	video, err = s.videoRepo.FindByWrapperSlug(ref)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return video, "", err
+	}
+
+	link, err := s.wrapperRepo.FindByOldSlug(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	video, err = s.videoRepo.FindByID(link.VideoID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !video.IsPublished {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+	return video, link.WatchPath(), nil
+}
+
 // GetAllVideos gets all videos (admin)
 func (s *VideoService) GetAllVideos(page, limit int) ([]models.Video, int64, error) {
 	return s.videoRepo.GetAllVideos(page, limit)
@@ -26,6 +26,15 @@ func ErrorResponse(c *fiber.Ctx, status int, message string) error {
 	})
 }
 
+// ErrorResponseWithData returns error response carrying extra details
+func ErrorResponseWithData(c *fiber.Ctx, status int, message string, data interface{}) error {
+	return c.Status(status).JSON(Response{
+		Success: false,
+		Error:   message,
+		Data:    data,
+	})
+}
+
 // ValidationErrorResponse returns validation error
 func ValidationErrorResponse(c *fiber.Ctx, errors interface{}) error {
 	return c.Status(fiber.StatusBadRequest).JSON(Response{
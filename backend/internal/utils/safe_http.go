@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a remote URL resolves to a private,
+// loopback or otherwise internal address
+var ErrForbiddenAddress = errors.New("remote address is not allowed")
+
+// ValidateRemoteURL checks that a user-supplied URL is an absolute http(s) URL
+// without credentials. Address checks happen at dial time (see NewPublicHTTPClient)
+// so DNS rebinding and redirects cannot bypass them.
+func ValidateRemoteURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.New("invalid URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("URL must use http or https")
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("URL must include a host")
+	}
+	if u.User != nil {
+		return nil, errors.New("URL must not contain credentials")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isPublicIP(ip) {
+		return nil, ErrForbiddenAddress
+	}
+	return u, nil
+}
+
+// NewPublicHTTPClient returns an HTTP client that refuses to connect to
+// non-public addresses, for fetching URLs supplied by users
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   15 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // A proxy would be dialed instead of the target and defeat the check
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       60 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("redirect to unsupported scheme")
+			}
+			return nil
+		},
+	}
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// 100.64.0.0/10 carrier-grade NAT
+		if ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+			return false
+		}
+		// 0.0.0.0/8 and 240.0.0.0/4 (reserved, includes broadcast)
+		if ip4[0] == 0 || ip4[0] >= 240 {
+			return false
+		}
+		return true
+	}
+	return ip.IsGlobalUnicast()
+}
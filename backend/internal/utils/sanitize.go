@@ -31,6 +31,23 @@ func SanitizeStrings(arr []string) []string {
 	return result
 }
 
+// SanitizeTags sanitizes video tags: max 20 tags of max 50 chars each
+func SanitizeTags(raw []string) []string {
+	tags := SanitizeStrings(raw)
+
+	// ✅ LIMIT NUMBER OF TAGS (max 20)
+	if len(tags) > 20 {
+		tags = tags[:20]
+	}
+
+	// ✅ LIMIT TAG LENGTH (max 50 chars each)
+	for i := range tags {
+		tags[i] = TruncateString(tags[i], 50)
+	}
+
+	return tags
+}
+
 // SanitizeURL basic URL sanitization (allows only http/https)
 func SanitizeURL(url string) string {
 	url = strings.TrimSpace(url)
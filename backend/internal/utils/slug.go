@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations covers letters that do not decompose into a base letter
+// plus accents (matches Postgres unaccent for these)
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+}
+
+// Slugify turns text into a URL slug: lowercase ASCII letters and digits
+// separated by single hyphens, at most maxLen bytes. Accents are stripped
+// (é → e); any other character separates words. Returns "" when nothing is left.
+func Slugify(s string, maxLen int) string {
+	var b strings.Builder
+	hyphen := false
+
+	for _, r := range norm.NFKD.String(strings.ToLower(s)) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			hyphen = false
+		case unicode.Is(unicode.Mn, r):
+			// Accent split off by NFKD
+		case transliterations[r] != "":
+			b.WriteString(transliterations[r])
+			hyphen = false
+		case !hyphen && b.Len() > 0:
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+
+	slug := b.String()
+	if maxLen > 0 && len(slug) > maxLen {
+		slug = slug[:maxLen]
+	}
+	return strings.Trim(slug, "-")
+}
@@ -0,0 +1,11 @@
+-- Content fingerprints used to detect duplicate uploads
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS content_sha256 VARCHAR(64);
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS file_size_bytes BIGINT;
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_videos_content_sha256 ON videos(content_sha256) WHERE content_sha256 IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_videos_content_hash ON videos(content_hash, file_size_bytes) WHERE content_hash IS NOT NULL;
+
+COMMENT ON COLUMN videos.content_hash IS 'Content hash reported by pCloud /uploadfile';
+COMMENT ON COLUMN videos.content_sha256 IS 'Hex SHA-256 of the uploaded bytes, computed while streaming to pCloud';
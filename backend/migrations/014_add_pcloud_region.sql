@@ -0,0 +1,4 @@
+-- pCloud accounts live on either the US (api.pcloud.com) or EU (eapi.pcloud.com) API host
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS region VARCHAR(8) NOT NULL DEFAULT 'us';
+
+COMMENT ON COLUMN pcloud_credentials.region IS 'pCloud API region: us or eu';
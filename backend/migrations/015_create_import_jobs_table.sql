@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source_url TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    title VARCHAR(500) NOT NULL,
+    description TEXT,
+    thumbnail_url TEXT,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    tags TEXT[],
+    duration_seconds INTEGER DEFAULT 0,
+    force BOOLEAN DEFAULT false,
+    bytes_total BIGINT DEFAULT 0,
+    bytes_done BIGINT DEFAULT 0,
+    error TEXT,
+    video_id UUID REFERENCES videos(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_import_jobs_status ON import_jobs(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_created_at ON import_jobs(created_at DESC);
@@ -0,0 +1,12 @@
+-- Per-video tracking of failed pCloud link refreshes
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS link_refresh_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS link_refresh_error TEXT;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS link_refresh_failed_at TIMESTAMP;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS link_refresh_retry_at TIMESTAMP;
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_videos_source_url_expires_at ON videos(source_url_expires_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_videos_link_refresh_failures ON videos(link_refresh_failures DESC) WHERE link_refresh_failures > 0;
+
+COMMENT ON COLUMN videos.link_refresh_failures IS 'Consecutive failed link refreshes; reset on success';
+COMMENT ON COLUMN videos.link_refresh_retry_at IS 'Next link refresh attempt after a failure (exponential backoff)';
@@ -0,0 +1,31 @@
+-- Health probe state per pCloud account
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS health_status VARCHAR(20) NOT NULL DEFAULT 'unknown';
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS health_error TEXT;
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS health_latency_ms INTEGER;
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS health_checked_at TIMESTAMP;
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS health_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS health_successes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS quarantined_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_pcloud_quarantined_at ON pcloud_credentials(quarantined_at);
+
+COMMENT ON COLUMN pcloud_credentials.health_failures IS 'Consecutive failed health checks';
+COMMENT ON COLUMN pcloud_credentials.health_successes IS 'Consecutive passed health checks';
+COMMENT ON COLUMN pcloud_credentials.quarantined_at IS 'Set while the account is removed from upload rotation by health checks';
+
+-- Health check history
+CREATE TABLE IF NOT EXISTS pcloud_health_checks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    credential_id UUID NOT NULL REFERENCES pcloud_credentials(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    quota_bytes BIGINT,
+    used_quota_bytes BIGINT,
+    error_code INTEGER,
+    error TEXT,
+    checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_pcloud_health_checks_credential ON pcloud_health_checks(credential_id, checked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_pcloud_health_checks_checked_at ON pcloud_health_checks(checked_at);
@@ -0,0 +1,15 @@
+-- Per-account inputs for upload account selection strategies
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS selection_weight INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS pinned_tags TEXT[] DEFAULT '{}';
+ALTER TABLE pcloud_credentials ADD COLUMN IF NOT EXISTS last_selected_at TIMESTAMP;
+
+COMMENT ON COLUMN pcloud_credentials.selection_weight IS 'Relative share of uploads under the weighted strategy (at least 1)';
+COMMENT ON COLUMN pcloud_credentials.pinned_tags IS 'Category slugs or video tags reserved for this account under the tag_pinned strategy';
+COMMENT ON COLUMN pcloud_credentials.last_selected_at IS 'Last time the account received an upload (round_robin strategy)';
+
+-- Runtime settings changed by admins, overriding environment defaults
+CREATE TABLE IF NOT EXISTS settings (
+    key VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+-- Technical metadata read from the container headers on upload
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS container VARCHAR(16);
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS width INTEGER;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS height INTEGER;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS video_codec VARCHAR(32);
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(32);
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS bitrate_kbps INTEGER;
+
+COMMENT ON COLUMN videos.container IS 'mp4, mov, webm or matroska';
+COMMENT ON COLUMN videos.bitrate_kbps IS 'Overall bitrate (file size over duration)';
@@ -0,0 +1,9 @@
+-- Whether the file can start playing without fetching its end (MP4 moov before mdat).
+-- NULL means not checked yet; the faststart batch job checks and fixes those.
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS streaming_optimized BOOLEAN;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS streaming_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_videos_streaming_pending ON videos(created_at)
+    WHERE streaming_optimized IS NOT TRUE AND streaming_error IS NULL AND deleted_at IS NULL;
+
+COMMENT ON COLUMN videos.streaming_error IS 'Why the file could not be streaming-optimized; such videos are not retried';
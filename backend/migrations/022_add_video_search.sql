@@ -0,0 +1,47 @@
+-- Full-text search: title (weight A), tags (B) and description (C) in one tsvector,
+-- kept up to date by a trigger and searched through a GIN index
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Text search configuration used for the vectors (simple, indonesian or english).
+-- The backend stores SEARCH_TS_CONFIG here and rebuilds all vectors when it changes.
+CREATE OR REPLACE FUNCTION video_search_config()
+RETURNS regconfig AS $$
+    SELECT COALESCE((SELECT value FROM settings WHERE key = 'search.ts_config'), 'simple')::regconfig;
+$$ LANGUAGE sql STABLE;
+
+-- Titles, tags and descriptions are stored HTML-escaped; index the plain text
+CREATE OR REPLACE FUNCTION video_search_unescape(input TEXT)
+RETURNS TEXT AS $$
+    SELECT replace(replace(replace(replace(replace(COALESCE(input, ''),
+        '&lt;', '<'), '&gt;', '>'), '&#34;', '"'), '&#39;', ''''), '&amp;', '&');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION video_search_vector(cfg regconfig, title TEXT, tags TEXT[], description TEXT)
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector(cfg, video_search_unescape(title)), 'A') ||
+           setweight(to_tsvector(cfg, video_search_unescape(array_to_string(tags, ' '))), 'B') ||
+           setweight(to_tsvector(cfg, video_search_unescape(description)), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION videos_search_vector_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector = video_search_vector(video_search_config(), NEW.title, NEW.tags, NEW.description);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS videos_search_vector_update ON videos;
+CREATE TRIGGER videos_search_vector_update
+    BEFORE INSERT OR UPDATE OF title, tags, description ON videos
+    FOR EACH ROW
+    EXECUTE FUNCTION videos_search_vector_update();
+
+-- Backfill existing videos without bumping updated_at
+ALTER TABLE videos DISABLE TRIGGER update_videos_updated_at;
+UPDATE videos SET search_vector = video_search_vector(video_search_config(), title, tags, description);
+ALTER TABLE videos ENABLE TRIGGER update_videos_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_videos_search_vector ON videos USING GIN(search_vector);
+
+COMMENT ON COLUMN videos.search_vector IS 'Weighted full-text document (title A, tags B, description C), maintained by trigger';
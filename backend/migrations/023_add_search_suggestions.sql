@@ -0,0 +1,30 @@
+-- Search autocomplete: trigram indexes for substring/prefix matches on titles,
+-- tags and category names, and a log of past queries for popular suggestions
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- array_to_string is only STABLE; tags are plain text so joining them is immutable
+CREATE OR REPLACE FUNCTION video_tags_text(tags TEXT[])
+RETURNS TEXT AS $$
+    SELECT COALESCE(array_to_string(tags, ' '), '');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE INDEX IF NOT EXISTS idx_videos_title_trgm ON videos
+    USING GIN (lower(video_search_unescape(title)) gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_videos_tags_trgm ON videos
+    USING GIN (lower(video_search_unescape(video_tags_text(tags))) gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories
+    USING GIN (lower(video_search_unescape(name)) gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- One row per normalized query (lowercase, single spaces)
+CREATE TABLE IF NOT EXISTS search_queries (
+    query VARCHAR(100) PRIMARY KEY,
+    search_count INTEGER NOT NULL DEFAULT 1,
+    result_count INTEGER NOT NULL DEFAULT 0,
+    last_searched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Prefix lookups (query LIKE 'abc%') over queries that found something
+CREATE INDEX IF NOT EXISTS idx_search_queries_prefix ON search_queries(query text_pattern_ops)
+    WHERE result_count > 0;
+
+COMMENT ON COLUMN search_queries.result_count IS 'Results of the latest search; queries without results are never suggested';
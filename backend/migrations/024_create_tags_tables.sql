@@ -0,0 +1,86 @@
+-- First-class tags: canonical tags with slugs, aliases for other spellings,
+-- and a join table. videos.tags keeps a copy of the canonical names, in order,
+-- for display and the search vector.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_tags_updated_at ON tags;
+CREATE TRIGGER update_tags_updated_at
+    BEFORE UPDATE ON tags
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    slug VARCHAR(120) PRIMARY KEY,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag_id);
+
+CREATE TABLE IF NOT EXISTS video_tags (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (video_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_tags_tag ON video_tags(tag_id, video_id);
+
+-- Autocomplete now matches tag names instead of the videos.tags arrays
+DROP INDEX IF EXISTS idx_videos_tags_trgm;
+DROP FUNCTION IF EXISTS video_tags_text(TEXT[]);
+CREATE INDEX IF NOT EXISTS idx_tags_name_trgm ON tags USING GIN (lower(video_search_unescape(name)) gin_trgm_ops);
+
+-- Same slug as the backend's TagSlug: accents stripped, lowercase ASCII words
+-- joined by hyphens, or tag-<md5 prefix> for names without any letters or digits
+CREATE OR REPLACE FUNCTION tag_slug(name TEXT)
+RETURNS TEXT AS $$
+    SELECT CASE WHEN slug = '' THEN 'tag-' || left(md5(btrim(name)), 8) ELSE slug END
+    FROM (
+        SELECT btrim(left(regexp_replace(lower(unaccent(video_search_unescape(btrim(name)))), '[^a-z0-9]+', '-', 'g'), 100), '-') AS slug
+    ) slugged;
+$$ LANGUAGE sql STABLE;
+
+-- Migrate existing tag arrays: one tag per slug, named by its most used spelling
+INSERT INTO tags (name, slug)
+SELECT DISTINCT ON (slug) name, slug
+FROM (
+    SELECT tag_slug(tag) AS slug, left(btrim(tag), 100) AS name, COUNT(*) AS uses
+    FROM videos, unnest(videos.tags) AS tag
+    WHERE btrim(tag) <> ''
+    GROUP BY 1, 2
+) spellings
+ORDER BY slug, uses DESC, name
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO video_tags (video_id, tag_id, position)
+SELECT tagged.video_id, tags.id, MIN(tagged.position) - 1
+FROM (
+    SELECT videos.id AS video_id, tag_slug(t.tag) AS slug, t.position
+    FROM videos, unnest(videos.tags) WITH ORDINALITY AS t(tag, position)
+    WHERE btrim(t.tag) <> ''
+) tagged
+JOIN tags ON tags.slug = tagged.slug
+GROUP BY tagged.video_id, tags.id
+ON CONFLICT DO NOTHING;
+
+-- Rewrite the arrays with canonical names (variants like "comedy " become "Comedy")
+ALTER TABLE videos DISABLE TRIGGER update_videos_updated_at;
+UPDATE videos SET tags = COALESCE((
+    SELECT array_agg(tags.name ORDER BY video_tags.position)
+    FROM video_tags JOIN tags ON tags.id = video_tags.tag_id
+    WHERE video_tags.video_id = videos.id
+), '{}')
+WHERE tags IS NOT NULL AND cardinality(tags) > 0;
+ALTER TABLE videos ENABLE TRIGGER update_videos_updated_at;
+
+COMMENT ON TABLE tag_aliases IS 'Other spellings and old slugs that resolve to a canonical tag';
+COMMENT ON COLUMN video_tags.position IS 'Order of the tag on the video, as entered';
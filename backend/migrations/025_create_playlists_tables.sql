@@ -0,0 +1,35 @@
+-- Playlists and series: ordered groups of videos. Series number their items
+-- as episodes; unlisted playlists are reachable by link but never listed.
+CREATE TABLE IF NOT EXISTS playlists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+    cover_url TEXT,
+    kind VARCHAR(20) NOT NULL DEFAULT 'playlist' CHECK (kind IN ('playlist', 'series')),
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlists_visibility ON playlists(visibility, created_at DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_playlists_deleted_at ON playlists(deleted_at);
+
+DROP TRIGGER IF EXISTS update_playlists_updated_at ON playlists;
+CREATE TRIGGER update_playlists_updated_at
+    BEFORE UPDATE ON playlists
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- A video appears at most once per playlist
+CREATE TABLE IF NOT EXISTS playlist_items (
+    playlist_id UUID NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (playlist_id, video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlist_items_position ON playlist_items(playlist_id, position);
+CREATE INDEX IF NOT EXISTS idx_playlist_items_video ON playlist_items(video_id);
@@ -0,0 +1,24 @@
+-- Hierarchical categories: a parent link plus a materialized path of
+-- ancestor IDs ("<root id>/<child id>/...") for subtree queries and
+-- breadcrumbs. Existing categories become roots.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories(id);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS path TEXT;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+
+UPDATE categories SET path = id::text WHERE path IS NULL;
+ALTER TABLE categories ALTER COLUMN path SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_categories_path ON categories(path text_pattern_ops);
+
+-- Names and slugs are unique among the live children of a parent instead of
+-- globally, so "Music › Live" and "Comedy › Live" can coexist
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_slug_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_parent_name
+    ON categories(COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), name)
+    WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_parent_slug
+    ON categories(COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), slug)
+    WHERE deleted_at IS NULL;
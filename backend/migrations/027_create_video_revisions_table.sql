@@ -0,0 +1,18 @@
+-- Metadata change history: every edit of a video's title, description,
+-- thumbnail, category, tags or publish state is stored as a revision with
+-- the changed fields. videos.version is bumped on each edit, so an editor
+-- saving over a newer version is rejected.
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS video_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    reverted_to INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (video_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_revisions_editor ON video_revisions(editor_id);
@@ -0,0 +1,45 @@
+-- Human-readable watch URLs: wrapper_links.slug is derived from the video
+-- title like the backend's watch slugs (accents stripped, lowercase ASCII
+-- words joined by hyphens), with -2, -3, ... on collision. Slugs a link had
+-- before its video was renamed stay in wrapper_link_slugs and redirect.
+CREATE TABLE IF NOT EXISTS wrapper_link_slugs (
+    slug VARCHAR(500) PRIMARY KEY,
+    wrapper_link_id UUID NOT NULL REFERENCES wrapper_links(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_wrapper_link_slugs_link ON wrapper_link_slugs(wrapper_link_id);
+
+-- Backfill links without a slug; older videos get the bare slug
+DO $$
+DECLARE
+    link RECORD;
+    base TEXT;
+    candidate TEXT;
+    n INTEGER;
+BEGIN
+    FOR link IN
+        SELECT wrapper_links.id, videos.title
+        FROM wrapper_links
+        JOIN videos ON videos.id = wrapper_links.video_id
+        WHERE wrapper_links.slug IS NULL OR wrapper_links.slug = ''
+        ORDER BY videos.created_at, wrapper_links.id
+    LOOP
+        base := btrim(left(regexp_replace(lower(unaccent(video_search_unescape(link.title))), '[^a-z0-9]+', '-', 'g'), 200), '-');
+        IF base = '' THEN
+            base := 'video';
+        END IF;
+
+        candidate := base;
+        n := 2;
+        WHILE EXISTS (SELECT 1 FROM wrapper_links WHERE slug = candidate)
+           OR EXISTS (SELECT 1 FROM wrapper_link_slugs WHERE slug = candidate) LOOP
+            candidate := base || '-' || n;
+            n := n + 1;
+        END LOOP;
+
+        UPDATE wrapper_links SET slug = candidate WHERE id = link.id;
+    END LOOP;
+END $$;
+
+COMMENT ON TABLE wrapper_link_slugs IS 'Previous slugs of wrapper links; /watch/<old slug> redirects to the current one';
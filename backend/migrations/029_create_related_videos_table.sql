@@ -0,0 +1,16 @@
+-- Related videos precomputed nightly from tag overlap, co-views, category and
+-- recency; rank 1 is the most related. Videos missing here (new ones, or ones
+-- sharing no tags or viewers) fall back to a live query.
+CREATE TABLE IF NOT EXISTS related_videos (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    related_video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, related_video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_related_videos_rank ON related_videos(video_id, rank);
+
+-- Co-views pair the videos watched within the same session
+CREATE INDEX IF NOT EXISTS idx_video_views_session_video ON video_views(session_id, video_id, viewed_at);
@@ -0,0 +1,17 @@
+-- Item-item similarity of videos by who watched and liked them (cosine over
+-- viewers: users, or sessions for anonymous viewers), rebuilt by a cron job.
+-- Personalized recommendations score a viewer's history against it.
+CREATE TABLE IF NOT EXISTS video_similarities (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    similar_video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    viewers INTEGER NOT NULL,
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, similar_video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_similarities_score ON video_similarities(video_id, score DESC);
+
+-- A viewer's history: by user when logged in, by session otherwise
+CREATE INDEX IF NOT EXISTS idx_video_views_user_viewed ON video_views(user_id, viewed_at DESC) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_video_likes_user_created ON video_likes(user_id, created_at DESC);
@@ -0,0 +1,8 @@
+-- Remote imports run as job queue jobs; the import keeps its progress and
+-- points at the job that runs it
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS job_id UUID REFERENCES jobs(id) ON DELETE SET NULL;
+
+-- Imports still unfinished from before the queue have no job to resume them
+UPDATE import_jobs
+SET status = 'failed', error = 'interrupted by server restart', completed_at = CURRENT_TIMESTAMP
+WHERE job_id IS NULL AND status IN ('pending', 'downloading', 'processing');
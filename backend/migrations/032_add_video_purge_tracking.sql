@@ -0,0 +1,12 @@
+-- Per-video tracking of failed trash purges, so videos pCloud keeps refusing
+-- back off instead of blocking the purge batch
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS purge_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS purge_error TEXT;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS purge_failed_at TIMESTAMP;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS purge_retry_at TIMESTAMP;
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_videos_purge_failures ON videos(purge_failures DESC) WHERE purge_failures > 0;
+
+COMMENT ON COLUMN videos.purge_failures IS 'Consecutive failed purges of a trashed video; reset on restore';
+COMMENT ON COLUMN videos.purge_retry_at IS 'Next purge attempt after a failure (exponential backoff)';
@@ -0,0 +1,5 @@
+-- Selection weights are at least 1; weighted picking divides uploads by them
+UPDATE pcloud_credentials SET selection_weight = 1 WHERE selection_weight < 1;
+
+ALTER TABLE pcloud_credentials DROP CONSTRAINT IF EXISTS pcloud_credentials_selection_weight_check;
+ALTER TABLE pcloud_credentials ADD CONSTRAINT pcloud_credentials_selection_weight_check CHECK (selection_weight >= 1);
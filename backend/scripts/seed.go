@@ -353,6 +353,7 @@ func seedSampleVideo(db *gorm.DB) error {
 	wrapperLink := models.WrapperLink{
 		VideoID:      video.ID,
 		WrapperToken: uuid.New().String(),
+		Slug:         utils.Slugify(video.Title, 200),
 	}
 
 	if err := db.Create(&wrapperLink).Error; err != nil {
@@ -360,7 +361,7 @@ func seedSampleVideo(db *gorm.DB) error {
 	}
 
 	log.Printf("  ✅ Created sample video with wrapper token: %s", wrapperLink.WrapperToken)
-	log.Printf("     Watch at: http://localhost:8080/watch/%s", wrapperLink.WrapperToken)
+	log.Printf("     Watch at: http://localhost:8080%s", wrapperLink.WatchPath())
 
 	return nil
 }
\ No newline at end of file